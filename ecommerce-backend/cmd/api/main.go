@@ -11,9 +11,29 @@ import (
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/auth"
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/download"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/exchange"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/giftcard"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/inventory"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/license"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/loyalty"
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/middleware"
-	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"   // NEW: Import order package
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/migration"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/notification"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order" // NEW: Import order package
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/orderstream"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/ordertemplate"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/payment"
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product" // Import product package
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/recommendation"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/stockalert"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/storage"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/storecredit"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/subscription"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/warehouse"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/webhook"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/wishlist"
 )
 
 func main() {
@@ -34,6 +54,17 @@ func main() {
 		}
 	}()
 
+	// Bring existing documents up to date before serving requests.
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 5*time.Minute)
+	if err := migration.Run(migrateCtx, cfg); err != nil {
+		log.Fatalf("Failed to apply data migrations: %v", err)
+	}
+	cancelMigrate()
+
+	// Background jobs are tied to this context and stop when main returns.
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
 	// 3. Initialize Gin Router
 	router := gin.Default()
 
@@ -41,35 +72,130 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Be specific in production, e.g., "http://localhost:3000"
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", "If-None-Match", "Last-Event-ID"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// 5. Initialize Services and Handlers
-	authService := auth.NewAuthService(cfg)
-	authHandler := auth.NewAuthHandler(authService)
+	exchangeService := exchange.NewExchangeService(cfg)
+	exchangeHandler := exchange.NewExchangeHandler(exchangeService)
+
+	// Domain events are recorded in the outbox with the change they describe and dispatched
+	// to subscribers in the background, at least once.
+	eventBus := event.NewEventBus(cfg)
+
+	// Email/webhook notifications and the stock alerts that use them.
+	notifier := notification.NewNotifier(cfg)
+	stockAlertService := stockalert.NewStockAlertService(cfg, notifier)
+	stockAlertHandler := stockalert.NewStockAlertHandler(stockAlertService)
+
+	// Per-warehouse stock levels; product stock is kept as their sum.
+	warehouseService := warehouse.NewWarehouseService(cfg, stockAlertService, eventBus)
+	warehouseHandler := warehouse.NewWarehouseHandler(warehouseService)
+
+	// Uploaded files, such as the files delivered for digital products.
+	fileStorage := storage.NewStorage(cfg)
 
-	productService := product.NewProductService()
-	productHandler := product.NewProductHandler(productService)
+	productService := product.NewProductService(cfg, warehouseService, stockAlertService, fileStorage)
+	productService.StartPublisher(jobsCtx, cfg.ProductPublishInterval) // Publishes drafts once their publishAt passes
+	productHandler := product.NewProductHandler(productService, exchangeService, cfg)
 
 	// NEW: Initialize Order Service and Handler.
 	// OrderService needs ProductService injected because it interacts with product stock.
-	orderService := order.NewOrderService(productService)
-	orderHandler := order.NewOrderHandler(orderService)
+	// Checkout holds stock through the inventory service; unpaid holds are swept once they expire.
+	inventoryService := inventory.NewInventoryService(eventBus)
+	// Software products can sell from a pool of license keys, claimed at checkout.
+	licenseService := license.NewLicenseService()
+	licenseHandler := license.NewLicenseHandler(licenseService)
+	// Gift cards and store credit can pay for all or part of an order.
+	giftCardService := giftcard.NewGiftCardService(cfg)
+	giftCardHandler := giftcard.NewGiftCardHandler(giftCardService)
+	storeCreditService := storecredit.NewStoreCreditService(cfg)
+	storeCreditHandler := storecredit.NewStoreCreditHandler(storeCreditService)
+	// Delivered orders earn loyalty points, which can be spent as a discount until they expire.
+	loyaltyService := loyalty.NewLoyaltyService(cfg)
+	loyaltyService.StartExpiry(jobsCtx, cfg.LoyaltyExpiryInterval)
+	loyaltyHandler := loyalty.NewLoyaltyHandler(loyaltyService)
+	// Payments are taken for subscription renewals and refunded when a paid order is cancelled.
+	paymentProvider := payment.NewProvider(cfg)
+	orderService := order.NewOrderService(productService, exchangeService, inventoryService, warehouseService, licenseService, giftCardService, storeCreditService, loyaltyService, stockAlertService, paymentProvider, eventBus, cfg)
+	orderService.StartReservationSweeper(jobsCtx, cfg.ReservationSweepInterval)
+	orderService.StartBackorderFiller(jobsCtx, cfg.BackorderFillInterval) // Backordered lines get stock as it arrives
+	// Cancelled paid orders are refunded once the cancellation commits, with retries.
+	eventBus.Subscribe("payment-refunds", orderService.RefundPayment, event.OrderStatusChanged)
+	orderHandler := order.NewOrderHandler(orderService, cfg)
+
+	// Verifying an email address claims the guest orders placed with it.
+	authService := auth.NewAuthService(cfg, notifier, orderService, eventBus)
+	authHandler := auth.NewAuthHandler(authService)
+
+	// Subscriptions place and pay for an order on every renewal.
+	subscriptionService := subscription.NewSubscriptionService(productService, orderService, paymentProvider, notifier)
+	subscriptionService.StartScheduler(jobsCtx, cfg.SubscriptionRunInterval)
+	subscriptionHandler := subscription.NewSubscriptionHandler(subscriptionService)
+
+	// Digital items are delivered through signed, expiring, count-limited download links.
+	downloadService := download.NewDownloadService(cfg, orderService, productService, fileStorage)
+	downloadHandler := download.NewDownloadHandler(downloadService)
+
+	// Wishlists show live product data and can be turned into an order in one call.
+	wishlistService := wishlist.NewWishlistService(productService, orderService)
+	wishlistHandler := wishlist.NewWishlistHandler(wishlistService)
+
+	// Order templates are saved baskets for repeat purchases; ordering skips what is unavailable.
+	orderTemplateService := ordertemplate.NewOrderTemplateService(productService, orderService)
+	orderTemplateHandler := ordertemplate.NewOrderTemplateHandler(orderTemplateService)
+
+	// Recommendations are served from an in-memory snapshot that is rebuilt periodically.
+	recommendationService := recommendation.NewRecommendationService(productService)
+	recommendationService.Start(jobsCtx, cfg.RecommendationRebuildInterval)
+	recommendationHandler := recommendation.NewRecommendationHandler(recommendationService)
+
+	// Outbound webhooks: admin-managed endpoints receive signed POSTs for the events they subscribe to.
+	webhookService := webhook.NewWebhookService(cfg)
+	eventBus.Subscribe("webhooks", webhookService.HandleEvent) // Queues a delivery per endpoint
+	webhookService.StartDispatcher(jobsCtx, cfg.WebhookDispatchInterval)
+	webhookHandler := webhook.NewWebhookHandler(webhookService)
+
+	// Live order updates over server-sent events, fed by a change stream on the outbox.
+	orderStreamService := orderstream.NewOrderStreamService(orderService)
+	orderStreamService.Start(jobsCtx)
+	orderStreamHandler := orderstream.NewOrderStreamHandler(orderStreamService, cfg)
+
+	// Subscribers register while services are built above, so dispatch starts last.
+	eventBus.Start(jobsCtx, cfg.EventDispatchInterval)
 
 	// 6. Define Routes
 	// Public routes (no authentication required)
 	publicRoutes := router.Group("/api")
+	publicRoutes.Use(middleware.OptionalAuth(cfg)) // Logged-in shoppers see their segment's sale prices, admins see drafts
 	{
 		// Authentication routes
 		publicRoutes.POST("/auth/register", authHandler.Register)
 		publicRoutes.POST("/auth/login", authHandler.Login)
+		publicRoutes.POST("/auth/verify-email", authHandler.VerifyEmail) // Token from the verification email
+
+		// Guest checkout; the order access token stands in for a login on GET /orders/:id
+		publicRoutes.POST("/orders/guest", orderHandler.CreateGuestOrder)
+		publicRoutes.GET("/orders/:id", orderHandler.GetOrderByID) // Owner, admin or ?token= (checked inside handler)
+		// Live updates of an order as server-sent events, with the same access as above
+		publicRoutes.GET("/orders/:id/events", orderStreamHandler.StreamOrderEvents)
 
 		// Public product routes (view products without login)
 		publicRoutes.GET("/products", productHandler.GetAllProducts)
 		publicRoutes.GET("/products/:id", productHandler.GetProductByID)
+		publicRoutes.GET("/products/:id/related", recommendationHandler.GetRelatedProducts)
+
+		// Signed download links; the signature authorizes the download
+		publicRoutes.GET("/downloads/:grantId", downloadHandler.Download)
+
+		// Wishlists shared through their public link
+		publicRoutes.GET("/wishlists/shared/:shareId", wishlistHandler.GetSharedWishlist)
+
+		// Currencies prices can be requested in (?currency=EUR)
+		publicRoutes.GET("/exchange-rates", exchangeHandler.ListRates)
 	}
 
 	// Authenticated routes (require a valid JWT)
@@ -77,6 +203,55 @@ func main() {
 	protectedRoutes.Use(middleware.AuthMiddleware(cfg)) // Apply the authentication middleware
 	{
 		protectedRoutes.GET("/auth/me", authHandler.GetMe)
+		protectedRoutes.POST("/auth/resend-verification", authHandler.ResendVerification)
+		protectedRoutes.POST("/auth/claim-guest-orders", authHandler.ClaimGuestOrders) // Needs a verified email
+		protectedRoutes.GET("/users/me/recommendations", recommendationHandler.GetUserRecommendations)
+		protectedRoutes.GET("/users/me/store-credit", storeCreditHandler.GetMyStoreCredit)
+		protectedRoutes.GET("/users/me/points", loyaltyHandler.GetMyPoints) // Loyalty points balance and ledger
+		protectedRoutes.GET("/gift-cards/:code/balance", giftCardHandler.CheckBalance)
+
+		// Wishlists of the authenticated user
+		userWishlists := protectedRoutes.Group("/users/me/wishlists")
+		{
+			userWishlists.POST("/", wishlistHandler.CreateWishlist)
+			userWishlists.GET("/", wishlistHandler.GetUserWishlists)
+			userWishlists.GET("/:id", wishlistHandler.GetWishlist)
+			userWishlists.PATCH("/:id", wishlistHandler.RenameWishlist)
+			userWishlists.DELETE("/:id", wishlistHandler.DeleteWishlist)
+			userWishlists.POST("/:id/items", wishlistHandler.AddItem)
+			userWishlists.DELETE("/:id/items/:productId", wishlistHandler.RemoveItem)
+			userWishlists.POST("/:id/share", wishlistHandler.ShareWishlist)
+			userWishlists.DELETE("/:id/share", wishlistHandler.UnshareWishlist)
+			userWishlists.POST("/:id/order", wishlistHandler.MoveToOrder) // Move products to a new order
+		}
+
+		// Order templates of the authenticated user
+		userOrderTemplates := protectedRoutes.Group("/users/me/order-templates")
+		{
+			userOrderTemplates.POST("/", orderTemplateHandler.CreateTemplate)
+			userOrderTemplates.GET("/", orderTemplateHandler.GetUserTemplates)
+			userOrderTemplates.GET("/:id", orderTemplateHandler.GetTemplate)
+			userOrderTemplates.PUT("/:id", orderTemplateHandler.ReplaceTemplate)
+			userOrderTemplates.DELETE("/:id", orderTemplateHandler.DeleteTemplate)
+			userOrderTemplates.POST("/:id/order", orderTemplateHandler.PlaceOrder) // Order the template's items; unavailable ones are skipped
+		}
+
+		// Recurring orders of the authenticated user
+		userSubscriptions := protectedRoutes.Group("/users/me/subscriptions")
+		{
+			userSubscriptions.POST("/", subscriptionHandler.CreateSubscription)
+			userSubscriptions.GET("/", subscriptionHandler.GetUserSubscriptions)
+			userSubscriptions.GET("/:id", subscriptionHandler.GetSubscription)
+			userSubscriptions.PATCH("/:id", subscriptionHandler.UpdateSubscription)
+			userSubscriptions.POST("/:id/pause", subscriptionHandler.PauseSubscription)
+			userSubscriptions.POST("/:id/resume", subscriptionHandler.ResumeSubscription)
+			userSubscriptions.POST("/:id/skip", subscriptionHandler.SkipRenewal) // Skip the next renewal
+			userSubscriptions.POST("/:id/cancel", subscriptionHandler.CancelSubscription)
+		}
+
+		// Back-in-stock notifications for out-of-stock products
+		protectedRoutes.POST("/products/:id/notify-me", stockAlertHandler.Subscribe)
+		protectedRoutes.DELETE("/products/:id/notify-me", stockAlertHandler.Unsubscribe)
 
 		// Admin-only product routes (create, update, delete)
 		adminProducts := protectedRoutes.Group("/products")
@@ -87,12 +262,105 @@ func main() {
 			adminProducts.DELETE("/:id", productHandler.DeleteProduct)
 		}
 
+		// Admin-only catalog management (archive, bulk import/export)
+		adminCatalog := protectedRoutes.Group("/admin/products")
+		adminCatalog.Use(middleware.AuthorizeRole("admin"))
+		{
+			adminCatalog.GET("/archived", productHandler.GetArchivedProducts)
+			adminCatalog.POST("/purge", productHandler.PurgeArchivedProducts) // Purge everything past retention
+			adminCatalog.POST("/:id/restore", productHandler.RestoreProduct)
+			adminCatalog.POST("/:id/purge", productHandler.PurgeProduct)
+
+			adminCatalog.POST("/import", productHandler.ImportProducts)
+			adminCatalog.GET("/import/jobs/:jobId", productHandler.GetImportJob)
+			adminCatalog.GET("/export", productHandler.ExportProducts)
+
+			adminCatalog.GET("/:id/stock", warehouseHandler.GetStockLevels) // Per-warehouse stock
+			adminCatalog.GET("/low-stock", stockAlertHandler.GetLowStockProducts)
+
+			adminCatalog.POST("/:id/price-schedules", productHandler.AddPriceSchedule)
+			adminCatalog.GET("/:id/price-schedules", productHandler.GetPriceSchedules)
+			adminCatalog.DELETE("/:id/price-schedules/:scheduleId", productHandler.DeletePriceSchedule)
+			adminCatalog.GET("/:id/price-history", productHandler.GetPriceHistory)
+
+			adminCatalog.POST("/:id/preview-token", productHandler.CreatePreviewToken) // Storefront link to a draft
+			adminCatalog.PUT("/:id/file", productHandler.UploadProductFile)            // File delivered for a digital product
+			adminCatalog.POST("/:id/license-keys", licenseHandler.UploadKeys)          // Add keys to the product's pool
+			adminCatalog.GET("/:id/license-keys", licenseHandler.GetPoolSummary)
+		}
+
+		// Admin-only user management
+		adminUsers := protectedRoutes.Group("/admin/users")
+		adminUsers.Use(middleware.AuthorizeRole("admin"))
+		{
+			adminUsers.PUT("/:id/segment", authHandler.SetUserSegment) // Customer segment for sale prices
+			adminUsers.GET("/:id/store-credit", storeCreditHandler.GetUserStoreCredit)
+			adminUsers.POST("/:id/store-credit", storeCreditHandler.AdjustStoreCredit) // Credit or debit with a reason
+		}
+
+		// Admin-only warehouse management
+		adminWarehouses := protectedRoutes.Group("/admin/warehouses")
+		adminWarehouses.Use(middleware.AuthorizeRole("admin"))
+		{
+			adminWarehouses.POST("/", warehouseHandler.CreateWarehouse)
+			adminWarehouses.GET("/", warehouseHandler.GetAllWarehouses)
+			adminWarehouses.PATCH("/:id", warehouseHandler.UpdateWarehouse)
+			adminWarehouses.PUT("/:id/stock/:productId", warehouseHandler.SetStock)
+		}
+
+		// Admin-only subscription overview
+		adminSubscriptions := protectedRoutes.Group("/admin/subscriptions")
+		adminSubscriptions.Use(middleware.AuthorizeRole("admin"))
+		{
+			adminSubscriptions.GET("/", subscriptionHandler.GetAllSubscriptions)
+		}
+
+		// Admin-only gift card management
+		adminGiftCards := protectedRoutes.Group("/admin/gift-cards")
+		adminGiftCards.Use(middleware.AuthorizeRole("admin"))
+		{
+			adminGiftCards.POST("/", giftCardHandler.IssueGiftCard)
+			adminGiftCards.GET("/", giftCardHandler.GetAllGiftCards)
+			adminGiftCards.GET("/:id", giftCardHandler.GetGiftCardByID)
+			adminGiftCards.POST("/:id/disable", giftCardHandler.DisableGiftCard)
+		}
+
+		// Admin-only exchange rate management
+		adminRates := protectedRoutes.Group("/admin/exchange-rates")
+		adminRates.Use(middleware.AuthorizeRole("admin"))
+		{
+			adminRates.PUT("/:currency", exchangeHandler.SetRate)
+			adminRates.DELETE("/:currency", exchangeHandler.DeleteRate)
+		}
+
+		// Admin-only webhook endpoints and their delivery log
+		adminWebhooks := protectedRoutes.Group("/admin/webhooks")
+		adminWebhooks.Use(middleware.AuthorizeRole("admin"))
+		{
+			adminWebhooks.POST("/", webhookHandler.CreateEndpoint)
+			adminWebhooks.GET("/", webhookHandler.GetEndpoints)
+			adminWebhooks.GET("/:id", webhookHandler.GetEndpoint)
+			adminWebhooks.PATCH("/:id", webhookHandler.UpdateEndpoint)
+			adminWebhooks.DELETE("/:id", webhookHandler.DeleteEndpoint)
+			adminWebhooks.POST("/:id/rotate-secret", webhookHandler.RotateSecret)
+			adminWebhooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+		}
+		adminWebhookDeliveries := protectedRoutes.Group("/admin/webhook-deliveries")
+		adminWebhookDeliveries.Use(middleware.AuthorizeRole("admin"))
+		{
+			adminWebhookDeliveries.GET("/:id", webhookHandler.GetDelivery)
+			adminWebhookDeliveries.POST("/:id/redeliver", webhookHandler.Redeliver)
+		}
+
 		// User-authenticated order routes
 		userOrders := protectedRoutes.Group("/orders")
 		{
-			userOrders.POST("/", orderHandler.CreateOrder)    // Create a new order
-			userOrders.GET("/my", orderHandler.GetUserOrders) // Get all orders for the authenticated user
-			userOrders.GET("/:id", orderHandler.GetOrderByID) // Get a specific order (with ownership/admin check inside handler)
+			userOrders.POST("/", orderHandler.CreateOrder)                      // Create a new order
+			userOrders.GET("/my", orderHandler.GetUserOrders)                   // Get all orders for the authenticated user
+			userOrders.PATCH("/:id", orderHandler.AmendOrder)                   // Change items or address while pending (owner only)
+			userOrders.POST("/:id/cancel", orderHandler.CancelOrder)            // Cancel while pending or processing, within the cancellation window
+			userOrders.POST("/:id/reorder", orderHandler.Reorder)               // Order the same items again; unavailable ones are skipped
+			userOrders.GET("/:id/downloads", downloadHandler.GetOrderDownloads) // Download links for digital items
 		}
 
 		// Admin-only order routes
@@ -101,6 +369,8 @@ func main() {
 		{
 			adminOrders.GET("/", orderHandler.GetAllOrders)                  // Get all orders in the system
 			adminOrders.PATCH("/:id/status", orderHandler.UpdateOrderStatus) // Update order status
+			adminOrders.POST("/:id/confirm-payment", orderHandler.ConfirmPayment)
+			adminOrders.GET("/stream", orderStreamHandler.StreamAllOrders) // Server-sent events for new orders and status changes
 		}
 	}
 
@@ -145,3 +145,152 @@ func (h *AuthHandler) GetMe(c *gin.Context) {
 
 	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"user": userResp})
 }
+
+// SetUserSegment godoc
+// @Summary Assign a user to a customer segment (Admin only)
+// @Description Set or clear the segment used for targeted sale prices; it applies from the user's next login
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "User ID"
+// @Param   request body SetSegmentRequest true "Segment (empty to clear)"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Segment updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error or user ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/users/{id}/segment [put]
+func (h *AuthHandler) SetUserSegment(c *gin.Context) {
+	var req SetSegmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	userResp, err := h.Service.SetUserSegment(ctx, c.Param("id"), req.Segment)
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid user ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Segment updated successfully", "user": userResp})
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm a user's email address with the token mailed to them at registration. Orders placed as a guest with the same email are claimed into the account.
+// @Tags Auth
+// @Accept  json
+// @Produce  json
+// @Param   request body VerifyEmailRequest true "Verification token"
+// @Success 200 {object} map[string]interface{} "Email verified, with the number of guest orders claimed"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, or invalid or expired token"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	userResp, claimed, err := h.Service.VerifyEmail(ctx, req.Token)
+	if err != nil {
+		if err.Error() == "invalid or expired verification token" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Email verified", "user": userResp, "claimedOrders": claimed})
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Mail the authenticated user a new email verification token
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} map[string]interface{} "Verification email sent"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "Email is already verified"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Service.ResendVerification(ctx, c.GetString("userID")); err != nil {
+		switch err.Error() {
+		case "email is already verified":
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		case "user not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// ClaimGuestOrders godoc
+// @Summary Claim guest orders
+// @Description Move the orders placed as a guest with the authenticated user's verified email into their account. Verifying the email already does this for earlier orders.
+// @Tags Auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} map[string]interface{} "Number of orders claimed"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Email is not verified"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /auth/claim-guest-orders [post]
+func (h *AuthHandler) ClaimGuestOrders(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	claimed, err := h.Service.ClaimGuestOrders(ctx, c.GetString("userID"))
+	if err != nil {
+		switch err.Error() {
+		case "email is not verified":
+			utils.RespondWithError(c, http.StatusForbidden, "Verify your email address before claiming guest orders")
+		case "user not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"claimedOrders": claimed})
+}
@@ -17,8 +17,13 @@ type User struct {
 	Email     string             `bson:"email" json:"email" validate:"required,email"`
 	Password  string             `bson:"password" json:"password" validate:"required,min=6"` // Hashed password
 	Role      string             `bson:"role" json:"role"`                                   // e.g., "user", "admin"
+	Segment   string             `bson:"segment,omitempty" json:"segment,omitempty"`         // Customer segment for targeted pricing, e.g. "vip"
 	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// EmailVerified is set once the user follows the link mailed to them; only then can they
+	// claim orders placed as a guest with the same email.
+	EmailVerified   bool       `bson:"emailVerified,omitempty" json:"emailVerified"`
+	EmailVerifiedAt *time.Time `bson:"emailVerifiedAt,omitempty" json:"emailVerifiedAt,omitempty"`
 }
 
 // LoginRequest defines the structure for a login request body.
@@ -41,6 +46,20 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	Role      string    `json:"role"`
+	Segment   string    `json:"segment,omitempty"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	EmailVerified bool `json:"emailVerified"`
+}
+
+// VerifyEmailRequest defines the structure for verifying a user's email address.
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"` // From the verification email
+}
+
+// SetSegmentRequest defines the structure for assigning a user to a customer segment.
+// An empty segment removes the user from any segment.
+type SetSegmentRequest struct {
+	Segment string `json:"segment" validate:"omitempty,alphanum,max=50"`
 }
@@ -4,11 +4,14 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config" // Import config to get JWT_SECRET
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/notification"
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For password hashing and JWT
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -20,19 +23,51 @@ type AuthService interface {
 	RegisterUser(ctx context.Context, req *RegisterRequest) (string, *UserResponse, error)
 	LoginUser(ctx context.Context, req *LoginRequest) (string, *UserResponse, error)
 	GetUserByID(ctx context.Context, userID string) (*UserResponse, error)
+	SetUserSegment(ctx context.Context, userID, segment string) (*UserResponse, error) // Admin only; takes effect at the user's next login
+
+	// Email verification. Verifying claims the user's guest orders.
+	VerifyEmail(ctx context.Context, token string) (*UserResponse, int, error)
+	ResendVerification(ctx context.Context, userID string) error
+	ClaimGuestOrders(ctx context.Context, userID string) (int, error) // Guest orders placed after the email was verified
+}
+
+// GuestOrderClaimer hands the guest orders placed with an email address to the user who
+// verified it. Implemented by the order service.
+type GuestOrderClaimer interface {
+	ClaimGuestOrders(ctx context.Context, email, userID string) (int, error)
 }
 
 // service implements AuthService.
 type service struct {
 	usersCollection *mongo.Collection
 	cfg             *config.Config // Store config to access JWTSecret
+	notifier        notification.Notifier
+	guestOrders     GuestOrderClaimer
+	events          event.Recorder // Registrations are recorded as UserRegistered
 }
 
 // NewAuthService creates a new authentication service.
-func NewAuthService(cfg *config.Config) AuthService {
+func NewAuthService(cfg *config.Config, notifier notification.Notifier, guestOrders GuestOrderClaimer, events event.Recorder) AuthService {
 	return &service{
 		usersCollection: database.GetCollection("users"), // Get the 'users' collection
 		cfg:             cfg,
+		notifier:        notifier,
+		guestOrders:     guestOrders,
+		events:          events,
+	}
+}
+
+// userToResponse converts a User model to a UserResponse, leaving out the password.
+func userToResponse(u *User) *UserResponse {
+	return &UserResponse{
+		ID:            u.ID.Hex(),
+		Username:      u.Username,
+		Email:         u.Email,
+		Role:          u.Role,
+		Segment:       u.Segment,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+		EmailVerified: u.EmailVerified,
 	}
 }
 
@@ -67,31 +102,39 @@ func (s *service) RegisterUser(ctx context.Context, req *RegisterRequest) (strin
 		UpdatedAt: now,
 	}
 
-	// Insert user into MongoDB
-	result, err := s.usersCollection.InsertOne(ctx, user)
+	// Insert user into MongoDB, together with the UserRegistered event
+	err = database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		result, err := s.usersCollection.InsertOne(sessionContext, user)
+		if err != nil {
+			log.Printf("Error inserting new user: %v", err)
+			return errors.New("failed to register user")
+		}
+		user.ID = result.InsertedID.(primitive.ObjectID) // Set the generated ID
+
+		return s.events.Record(sessionContext, event.UserRegistered, user.ID.Hex(), event.UserRegisteredData{
+			UserID:   user.ID.Hex(),
+			Username: user.Username,
+			Email:    user.Email,
+		})
+	})
 	if err != nil {
-		log.Printf("Error inserting new user: %v", err)
-		return "", nil, errors.New("failed to register user")
+		return "", nil, err
 	}
 
-	user.ID = result.InsertedID.(primitive.ObjectID) // Set the generated ID
+	// The account works without a verified email; it is only needed to claim guest orders.
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", user.ID.Hex(), err)
+	}
 
 	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role, s.cfg.JWTSecret)
+	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role, user.Segment, s.cfg.JWTSecret)
 	if err != nil {
 		log.Printf("Error generating JWT for new user: %v", err)
 		return "", nil, errors.New("failed to generate authentication token")
 	}
 
 	// Prepare response data (without password)
-	userResp := &UserResponse{
-		ID:        user.ID.Hex(),
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}
+	userResp := userToResponse(user)
 
 	return token, userResp, nil
 }
@@ -114,49 +157,147 @@ func (s *service) LoginUser(ctx context.Context, req *LoginRequest) (string, *Us
 	}
 
 	// Generate JWT token
-	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role, s.cfg.JWTSecret)
+	token, err := utils.GenerateJWT(user.ID.Hex(), user.Role, user.Segment, s.cfg.JWTSecret)
 	if err != nil {
 		log.Printf("Error generating JWT for login: %v", err)
 		return "", nil, errors.New("failed to generate authentication token")
 	}
 
 	// Prepare response data (without password)
-	userResp := &UserResponse{
-		ID:        user.ID.Hex(),
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}
+	userResp := userToResponse(&user)
 
 	return token, userResp, nil
 }
 
 // GetUserByID retrieves a user by their ID for authenticated endpoints.
 func (s *service) GetUserByID(ctx context.Context, userID string) (*UserResponse, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return userToResponse(user), nil
+}
+
+// SetUserSegment assigns a user to a customer segment (or clears it).
+func (s *service) SetUserSegment(ctx context.Context, userID, segment string) (*UserResponse, error) {
 	objID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
 
-	var user User
-	err = s.usersCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user)
+	update := bson.M{"$set": bson.M{"segment": segment, "updatedAt": time.Now()}}
+	if segment == "" {
+		update = bson.M{"$unset": bson.M{"segment": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	res, err := s.usersCollection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		log.Printf("Error updating user segment: %v", err)
+		return nil, errors.New("failed to update user segment")
+	}
+	if res.MatchedCount == 0 {
+		return nil, errors.New("user not found")
+	}
+	return s.GetUserByID(ctx, userID)
+}
+
+// sendVerification mails the user a token that proves they own their email address.
+func (s *service) sendVerification(ctx context.Context, user *User) error {
+	token, err := utils.GenerateEmailVerificationToken(user.ID.Hex(), user.Email, s.cfg.JWTSecret, s.cfg.EmailVerificationTTL)
 	if err != nil {
+		return err
+	}
+	return s.notifier.Notify(ctx, notification.Notification{
+		Event:   "user.email_verification",
+		To:      []string{user.Email},
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nconfirm this is your email address by sending the token below to POST /api/auth/verify-email. "+
+			"Any orders you placed as a guest with it will then show up in your account.\n\n%s\n", user.Username, token),
+		Data: map[string]interface{}{"userId": user.ID.Hex()},
+	})
+}
+
+// VerifyEmail marks the email address in token as verified for its user and claims the guest
+// orders placed with it. It returns the user and the number of orders claimed.
+func (s *service) VerifyEmail(ctx context.Context, token string) (*UserResponse, int, error) {
+	claims, err := utils.ValidateEmailVerificationToken(token, s.cfg.JWTSecret)
+	if err != nil {
+		return nil, 0, errors.New("invalid or expired verification token")
+	}
+	objID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return nil, 0, errors.New("invalid or expired verification token")
+	}
+
+	now := time.Now()
+	// The email guard keeps a token from verifying an address the user no longer has.
+	res, err := s.usersCollection.UpdateOne(ctx,
+		bson.M{"_id": objID, "email": claims.Email},
+		bson.M{"$set": bson.M{"emailVerified": true, "emailVerifiedAt": now, "updatedAt": now}},
+	)
+	if err != nil {
+		log.Printf("Error verifying email: %v", err)
+		return nil, 0, errors.New("failed to verify email")
+	}
+	if res.MatchedCount == 0 {
+		return nil, 0, errors.New("invalid or expired verification token")
+	}
+
+	claimed, err := s.guestOrders.ClaimGuestOrders(ctx, claims.Email, claims.UserID)
+	if err != nil {
+		// The email is verified; the orders can still be claimed with ClaimGuestOrders.
+		log.Printf("Error claiming guest orders of user %s: %v", claims.UserID, err)
+	}
+
+	userResp, err := s.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return userResp, claimed, nil
+}
+
+// ResendVerification mails the user a new verification token.
+func (s *service) ResendVerification(ctx context.Context, userID string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return errors.New("email is already verified")
+	}
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", userID, err)
+		return errors.New("failed to send verification email")
+	}
+	return nil
+}
+
+// ClaimGuestOrders claims the guest orders placed with the user's email address, which must
+// be verified. Verifying already claims the orders placed before it.
+func (s *service) ClaimGuestOrders(ctx context.Context, userID string) (int, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if !user.EmailVerified {
+		return 0, errors.New("email is not verified")
+	}
+	return s.guestOrders.ClaimGuestOrders(ctx, user.Email, userID)
+}
+
+// findUser loads a user by ID.
+func (s *service) findUser(ctx context.Context, userID string) (*User, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+
+	var user User
+	if err := s.usersCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&user); err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errors.New("user not found")
 		}
 		log.Printf("Error retrieving user by ID: %v", err)
 		return nil, errors.New("database error retrieving user")
 	}
-
-	userResp := &UserResponse{
-		ID:        user.ID.Hex(),
-		Username:  user.Username,
-		Email:     user.Email,
-		Role:      user.Role,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}
-	return userResp, nil
+	return &user, nil
 }
@@ -4,6 +4,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,6 +16,83 @@ type Config struct {
 	MongoURI  string
 	JWTSecret string
 	Port      string
+
+	// RecommendationRebuildInterval controls how often the co-purchase matrix is rebuilt.
+	RecommendationRebuildInterval time.Duration
+
+	// ProductPurgeRetention is how long an archived product must stay archived before it can be purged.
+	ProductPurgeRetention time.Duration
+
+	// ProductPublishInterval controls how often drafts whose publishAt has passed are published.
+	ProductPublishInterval time.Duration
+	// PreviewTokenTTL is how long a draft preview link stays valid.
+	PreviewTokenTTL time.Duration
+
+	// BaseCurrency is the ISO 4217 currency all prices and order totals are stored in.
+	BaseCurrency string
+
+	// ReservationTTL is how long checkout holds stock for an unpaid order before it is auto-cancelled.
+	ReservationTTL time.Duration
+	// ReservationSweepInterval controls how often expired reservations are released.
+	ReservationSweepInterval time.Duration
+	// BackorderFillInterval controls how often backordered order lines are checked for new stock.
+	BackorderFillInterval time.Duration
+	// CancellationWindow is how long after placing an order its owner can still cancel it.
+	CancellationWindow time.Duration
+	// GuestOrderTokenTTL is how long the access token of an order placed without an account stays valid.
+	GuestOrderTokenTTL time.Duration
+	// EmailVerificationTTL is how long an email verification link stays valid.
+	EmailVerificationTTL time.Duration
+
+	// DefaultWarehouse is the code of the warehouse that stock given on product create/update goes to.
+	DefaultWarehouse string
+	// AllocationStrategy picks warehouses for order lines: "priority", "nearest" or "fewest_splits".
+	AllocationStrategy string
+
+	// StorageDir is where uploaded files, such as digital product files, are kept.
+	StorageDir string
+	// DownloadURLTTL is how long a signed download link stays valid.
+	DownloadURLTTL time.Duration
+	// DownloadMaxCount is how many times a customer can download each purchased file.
+	DownloadMaxCount int
+
+	// PaymentProvider takes payments for subscription renewals. Only "test" is built in.
+	PaymentProvider string
+	// SubscriptionRunInterval is how often due subscriptions are renewed.
+	SubscriptionRunInterval time.Duration
+	// GiftCardValidity is how long a gift card bought in an order stays redeemable.
+	GiftCardValidity time.Duration
+
+	// LoyaltyEarnRate is how many points a delivered order earns per unit of the base currency.
+	LoyaltyEarnRate float64
+	// LoyaltyCategoryMultipliers scale the earn rate for products in a category, keyed by category ID.
+	LoyaltyCategoryMultipliers map[string]float64
+	// LoyaltyPointValue is what one point takes off an order, in units of the base currency.
+	LoyaltyPointValue float64
+	// LoyaltyPointsValidity is how long earned points can be spent before they expire.
+	LoyaltyPointsValidity time.Duration
+	// LoyaltyExpiryInterval controls how often expired points are taken off balances.
+	LoyaltyExpiryInterval time.Duration
+
+	// EventDispatchInterval controls how often the outbox is checked for events to deliver.
+	EventDispatchInterval time.Duration
+	// EventMaxAttempts is how many times delivery of an event is tried before it is marked failed.
+	EventMaxAttempts int
+	// WebhookDispatchInterval controls how often due webhook deliveries are sent.
+	WebhookDispatchInterval time.Duration
+	// WebhookMaxAttempts is how many times a webhook delivery is tried before it is marked dead.
+	WebhookMaxAttempts int
+	// WebhookTimeout is how long a webhook endpoint has to respond.
+	WebhookTimeout time.Duration
+
+	// Outgoing notifications. Without SMTPHost emails are only logged.
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+	NotifyWebhookURL string   // Optional: every notification is also posted here as JSON
+	AdminAlertEmails []string // Recipients of operational alerts such as low stock
 }
 
 // LoadConfig reads configuration from .env file and environment variables.
@@ -42,9 +122,185 @@ func LoadConfig() *Config {
 		port = "8080" // Default port if not set
 	}
 
+	recommendationInterval := getDurationEnv("RECOMMENDATION_REBUILD_INTERVAL", time.Hour)
+	purgeRetention := getDurationEnv("PRODUCT_PURGE_RETENTION", 30*24*time.Hour)
+	publishInterval := getDurationEnv("PRODUCT_PUBLISH_INTERVAL", time.Minute)
+	previewTokenTTL := getDurationEnv("PREVIEW_TOKEN_TTL", 24*time.Hour)
+
+	reservationTTL := getDurationEnv("RESERVATION_TTL", 15*time.Minute)
+	reservationSweepInterval := getDurationEnv("RESERVATION_SWEEP_INTERVAL", time.Minute)
+	cancellationWindow := getDurationEnv("ORDER_CANCELLATION_WINDOW", 24*time.Hour)
+	guestOrderTokenTTL := getDurationEnv("GUEST_ORDER_TOKEN_TTL", 90*24*time.Hour)
+	emailVerificationTTL := getDurationEnv("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	backorderFillInterval := getDurationEnv("BACKORDER_FILL_INTERVAL", time.Minute)
+
+	baseCurrency := strings.ToUpper(os.Getenv("BASE_CURRENCY"))
+	if baseCurrency == "" {
+		baseCurrency = "USD"
+	}
+	if len(baseCurrency) != 3 {
+		log.Fatal("BASE_CURRENCY must be a three-letter ISO 4217 code, e.g. USD.")
+	}
+
+	defaultWarehouse := os.Getenv("DEFAULT_WAREHOUSE")
+	if defaultWarehouse == "" {
+		defaultWarehouse = "MAIN"
+	}
+
+	allocationStrategy := os.Getenv("ALLOCATION_STRATEGY")
+	switch allocationStrategy {
+	case "":
+		allocationStrategy = "priority"
+	case "priority", "nearest", "fewest_splits":
+	default:
+		log.Fatalf("ALLOCATION_STRATEGY must be one of priority, nearest or fewest_splits, got %q", allocationStrategy)
+	}
+
+	storageDir := os.Getenv("STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "./storage"
+	}
+	downloadURLTTL := getDurationEnv("DOWNLOAD_URL_TTL", 15*time.Minute)
+	downloadMaxCount := 5
+	if value := os.Getenv("DOWNLOAD_MAX_COUNT"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			log.Fatalf("DOWNLOAD_MAX_COUNT must be a positive number, got %q", value)
+		}
+		downloadMaxCount = n
+	}
+
+	paymentProvider := os.Getenv("PAYMENT_PROVIDER")
+	switch paymentProvider {
+	case "":
+		paymentProvider = "test"
+	case "test":
+	default:
+		log.Fatalf("PAYMENT_PROVIDER must be test, got %q", paymentProvider)
+	}
+	subscriptionRunInterval := getDurationEnv("SUBSCRIPTION_RUN_INTERVAL", 5*time.Minute)
+	giftCardValidity := getDurationEnv("GIFT_CARD_VALIDITY", 365*24*time.Hour)
+
+	loyaltyEarnRate := getFloatEnv("LOYALTY_EARN_RATE", 1)
+	loyaltyCategoryMultipliers := map[string]float64{}
+	for _, pair := range strings.Split(os.Getenv("LOYALTY_CATEGORY_MULTIPLIERS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		categoryID, value, ok := strings.Cut(pair, ":")
+		multiplier, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil || multiplier < 0 {
+			log.Fatalf("LOYALTY_CATEGORY_MULTIPLIERS must look like <categoryID>:<multiplier>,..., got %q", pair)
+		}
+		loyaltyCategoryMultipliers[strings.TrimSpace(categoryID)] = multiplier
+	}
+	loyaltyPointValue := getFloatEnv("LOYALTY_POINT_VALUE", 0.01)
+	loyaltyPointsValidity := getDurationEnv("LOYALTY_POINTS_VALIDITY", 365*24*time.Hour)
+	loyaltyExpiryInterval := getDurationEnv("LOYALTY_EXPIRY_INTERVAL", time.Hour)
+
+	eventDispatchInterval := getDurationEnv("EVENT_DISPATCH_INTERVAL", time.Second)
+	eventMaxAttempts := 10
+	if value := os.Getenv("EVENT_MAX_ATTEMPTS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			log.Fatalf("EVENT_MAX_ATTEMPTS must be a positive number, got %q", value)
+		}
+		eventMaxAttempts = n
+	}
+	webhookDispatchInterval := getDurationEnv("WEBHOOK_DISPATCH_INTERVAL", 5*time.Second)
+	webhookMaxAttempts := 10
+	if value := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			log.Fatalf("WEBHOOK_MAX_ATTEMPTS must be a positive number, got %q", value)
+		}
+		webhookMaxAttempts = n
+	}
+	webhookTimeout := getDurationEnv("WEBHOOK_TIMEOUT", 10*time.Second)
+
+	smtpPort := os.Getenv("SMTP_PORT")
+	if smtpPort == "" {
+		smtpPort = "587"
+	}
+	smtpFrom := os.Getenv("SMTP_FROM")
+	if smtpFrom == "" {
+		smtpFrom = "no-reply@localhost"
+	}
+
+	var adminAlertEmails []string
+	for _, email := range strings.Split(os.Getenv("ADMIN_ALERT_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			adminAlertEmails = append(adminAlertEmails, email)
+		}
+	}
+
 	return &Config{
 		MongoURI:  mongoURI,
 		JWTSecret: jwtSecret,
 		Port:      port,
+
+		RecommendationRebuildInterval: recommendationInterval,
+		ProductPurgeRetention:         purgeRetention,
+		ProductPublishInterval:        publishInterval,
+		PreviewTokenTTL:               previewTokenTTL,
+		BaseCurrency:                  baseCurrency,
+		ReservationTTL:                reservationTTL,
+		ReservationSweepInterval:      reservationSweepInterval,
+		BackorderFillInterval:         backorderFillInterval,
+		CancellationWindow:            cancellationWindow,
+		GuestOrderTokenTTL:            guestOrderTokenTTL,
+		EmailVerificationTTL:          emailVerificationTTL,
+		DefaultWarehouse:              defaultWarehouse,
+		AllocationStrategy:            allocationStrategy,
+		StorageDir:                    storageDir,
+		DownloadURLTTL:                downloadURLTTL,
+		DownloadMaxCount:              downloadMaxCount,
+		PaymentProvider:               paymentProvider,
+		SubscriptionRunInterval:       subscriptionRunInterval,
+		GiftCardValidity:              giftCardValidity,
+		LoyaltyEarnRate:               loyaltyEarnRate,
+		LoyaltyCategoryMultipliers:    loyaltyCategoryMultipliers,
+		LoyaltyPointValue:             loyaltyPointValue,
+		LoyaltyPointsValidity:         loyaltyPointsValidity,
+		LoyaltyExpiryInterval:         loyaltyExpiryInterval,
+		EventDispatchInterval:         eventDispatchInterval,
+		EventMaxAttempts:              eventMaxAttempts,
+		WebhookDispatchInterval:       webhookDispatchInterval,
+		WebhookMaxAttempts:            webhookMaxAttempts,
+		WebhookTimeout:                webhookTimeout,
+
+		SMTPHost:         os.Getenv("SMTP_HOST"),
+		SMTPPort:         smtpPort,
+		SMTPUsername:     os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:     os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:         smtpFrom,
+		NotifyWebhookURL: os.Getenv("NOTIFY_WEBHOOK_URL"),
+		AdminAlertEmails: adminAlertEmails,
+	}
+}
+
+// getDurationEnv reads a duration (e.g. "30m", "1h") from the environment, falling back to def when unset.
+func getDurationEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s must be a positive duration (e.g. \"30m\"), got %q", key, value)
 	}
-}
\ No newline at end of file
+	return d
+}
+
+// getFloatEnv reads a non-negative number from the environment, falling back to def when unset.
+func getFloatEnv(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		log.Fatalf("%s must be a non-negative number, got %q", key, value)
+	}
+	return f
+}
@@ -3,9 +3,11 @@ package database
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -47,3 +49,43 @@ func GetCollection(collectionName string) *mongo.Collection {
 	// "ecommerce" is the name of your database. Make sure it matches your MONGO_URI if different.
 	return MongoClient.Database("ecommerce").Collection(collectionName)
 }
+
+// WithVersion adds an optimistic-concurrency condition to a filter when expected is set.
+// Documents written before versioning have no "version" field and are treated as version 0.
+func WithVersion(filter bson.M, expected *int64) bson.M {
+	if expected == nil {
+		return filter
+	}
+	if *expected == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}} // null also matches a missing field
+	} else {
+		filter["version"] = *expected
+	}
+	return filter
+}
+
+// RunTransaction runs fn inside a multi-document transaction, committing when fn returns nil
+// and aborting otherwise. Pass the SessionContext to every collection call that should take part.
+func RunTransaction(ctx context.Context, fn func(sessionContext mongo.SessionContext) error) error {
+	session, err := MongoClient.StartSession()
+	if err != nil {
+		log.Printf("Error starting MongoDB session: %v", err)
+		return errors.New("failed to start database session")
+	}
+	defer session.EndSession(ctx)
+
+	return mongo.WithSession(ctx, session, func(sessionContext mongo.SessionContext) error {
+		if err := session.StartTransaction(); err != nil {
+			return err
+		}
+		if err := fn(sessionContext); err != nil {
+			session.AbortTransaction(sessionContext)
+			return err
+		}
+		if err := session.CommitTransaction(sessionContext); err != nil {
+			log.Printf("Error committing transaction: %v", err)
+			return errors.New("failed to commit transaction")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+// internal/download/handler.go
+package download
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// DownloadHandler handles HTTP requests related to digital downloads.
+type DownloadHandler struct {
+	Service DownloadService
+}
+
+// NewDownloadHandler creates a new DownloadHandler instance.
+func NewDownloadHandler(s DownloadService) *DownloadHandler {
+	return &DownloadHandler{Service: s}
+}
+
+// GetOrderDownloads godoc
+// @Summary Get download links for an order
+// @Description Get signed, expiring download links for the digital items of a paid order. Each link works a limited number of times.
+// @Tags Downloads
+// @Produce  json
+// @Param   id path string true "Order ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Download links"
+// @Failure 400 {object} map[string]interface{} "Invalid order ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Access denied (if not owner or admin)"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order is not paid yet"
+// @Failure 410 {object} map[string]interface{} "Order was refunded"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id}/downloads [get]
+func (h *DownloadHandler) GetOrderDownloads(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	links, err := h.Service.GetOrderDownloads(ctx, c.Param("id"), c.GetString("userID"), c.GetString("userRole") == "admin")
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid order ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		case "access denied":
+			utils.RespondWithError(c, http.StatusForbidden, "Access denied: You can only download from your own orders.")
+		case "downloads are available once the order is paid":
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		case "download is no longer available":
+			utils.RespondWithError(c, http.StatusGone, "Downloads are no longer available: the order was refunded.")
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store") // The links are credentials
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"downloads": links})
+}
+
+// Download godoc
+// @Summary Download a purchased file
+// @Description Stream a digital item's file through a signed link from GET /orders/{id}/downloads. No login is needed; the signature authorizes the download.
+// @Tags Downloads
+// @Produce  octet-stream
+// @Param   grantId path string true "Download ID"
+// @Param   expires query string true "Link expiry (Unix seconds)"
+// @Param   signature query string true "Link signature"
+// @Success 200 {file} file "The file"
+// @Failure 403 {object} map[string]interface{} "Invalid link or download limit reached"
+// @Failure 404 {object} map[string]interface{} "Download not found"
+// @Failure 410 {object} map[string]interface{} "Link expired or download no longer available"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /downloads/{grantId} [get]
+func (h *DownloadHandler) Download(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	file, err := h.Service.Open(ctx, c.Param("grantId"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid download link", "download limit reached":
+			utils.RespondWithError(c, http.StatusForbidden, err.Error())
+		case "download not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "download link has expired", "download is no longer available":
+			utils.RespondWithError(c, http.StatusGone, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	defer file.Body.Close()
+
+	c.DataFromReader(http.StatusOK, file.Size, file.ContentType, file.Body, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}),
+		"Cache-Control":       "private, no-store",
+	})
+}
@@ -0,0 +1,41 @@
+// internal/download/model.go
+package download
+
+import (
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Grant entitles the buyer of a digital item to a limited number of downloads of its file.
+// Grants are created the first time the buyer asks for download links of a fulfilled order.
+type Grant struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrderID        primitive.ObjectID `bson:"orderID" json:"orderId"`
+	ProductID      primitive.ObjectID `bson:"productID" json:"productId"`
+	UserID         primitive.ObjectID `bson:"userID,omitempty" json:"userId"` // Buyer when the grant was created; unset for unclaimed guest orders
+	Name           string             `bson:"name" json:"name"`               // Denormalized product name
+	Downloads      int                `bson:"downloads" json:"downloads"`
+	MaxDownloads   int                `bson:"maxDownloads" json:"maxDownloads"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+	LastDownloadAt *time.Time         `bson:"lastDownloadAt,omitempty" json:"lastDownloadAt,omitempty"`
+}
+
+// Link is a signed, expiring download URL for one digital item of an order.
+type Link struct {
+	ProductID          string     `json:"productId"`
+	Name               string     `json:"name"`
+	URL                string     `json:"url,omitempty"` // Omitted once no downloads remain
+	ExpiresAt          *time.Time `json:"expiresAt,omitempty"`
+	DownloadsUsed      int        `json:"downloadsUsed"`
+	DownloadsRemaining int        `json:"downloadsRemaining"`
+}
+
+// File is an open file ready to be streamed to the customer. Body must be closed.
+type File struct {
+	Name        string
+	ContentType string
+	Size        int64
+	Body        io.ReadCloser
+}
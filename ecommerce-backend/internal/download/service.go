@@ -0,0 +1,229 @@
+// internal/download/service.go
+package download
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/storage"
+)
+
+// fulfilledStatuses are the order statuses in which digital items can be downloaded.
+var fulfilledStatuses = map[string]bool{
+	order.StatusProcessing: true,
+	order.StatusShipped:    true,
+	order.StatusDelivered:  true,
+}
+
+// DownloadService defines the interface for digital item downloads.
+type DownloadService interface {
+	// GetOrderDownloads returns fresh signed links for the digital items of a fulfilled order.
+	GetOrderDownloads(ctx context.Context, orderID, userID string, isAdmin bool) ([]Link, error)
+	// Open checks a signed link, counts the download and opens the file.
+	Open(ctx context.Context, grantID, expires, signature string) (*File, error)
+}
+
+// service implements DownloadService.
+type service struct {
+	grantsCollection *mongo.Collection
+	orderService     order.OrderService
+	productService   product.ProductService
+	files            storage.Storage
+	signingKey       []byte
+	linkTTL          time.Duration
+	maxDownloads     int
+}
+
+// NewDownloadService creates a new download service.
+func NewDownloadService(cfg *config.Config, orderService order.OrderService, productService product.ProductService, files storage.Storage) DownloadService {
+	return &service{
+		grantsCollection: database.GetCollection("download_grants"),
+		orderService:     orderService,
+		productService:   productService,
+		files:            files,
+		signingKey:       []byte("download:" + cfg.JWTSecret), // Distinct from the JWT key so neither can stand in for the other
+		linkTTL:          cfg.DownloadURLTTL,
+		maxDownloads:     cfg.DownloadMaxCount,
+	}
+}
+
+// sign returns the signature of a download link for grantID that expires at expires (Unix seconds).
+func (s *service) sign(grantID, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(grantID + "." + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// fulfilledOrder loads an order the caller may download from.
+func (s *service) fulfilledOrder(ctx context.Context, orderID, userID string, isAdmin bool) (*order.OrderResponse, error) {
+	o, err := s.orderService.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.UserID != userID && !isAdmin {
+		return nil, errors.New("access denied")
+	}
+	if err := downloadable(o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// downloadable tells why an order's digital items cannot be downloaded, if they cannot.
+func downloadable(o *order.OrderResponse) error {
+	if o.Status == order.StatusRefunded || o.Status == order.StatusReturned {
+		return errors.New("download is no longer available")
+	}
+	if !fulfilledStatuses[o.Status] {
+		return errors.New("downloads are available once the order is paid")
+	}
+	return nil
+}
+
+// GetOrderDownloads returns a signed link for each digital item of an order, creating the
+// download grants on first use.
+func (s *service) GetOrderDownloads(ctx context.Context, orderID, userID string, isAdmin bool) ([]Link, error) {
+	o, err := s.fulfilledOrder(ctx, orderID, userID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	orderObjID, _ := primitive.ObjectIDFromHex(o.ID)
+
+	var buyerObjID *primitive.ObjectID // Unclaimed guest orders have no buyer yet
+	if o.UserID != "" {
+		objID, err := primitive.ObjectIDFromHex(o.UserID)
+		if err != nil {
+			log.Printf("Order %s has an invalid user ID %q", o.ID, o.UserID)
+			return nil, errors.New("failed to prepare downloads")
+		}
+		buyerObjID = &objID
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.linkTTL)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	links := []Link{}
+	for _, item := range o.Items {
+		if !item.Digital || item.GiftCard {
+			continue
+		}
+		newGrant := bson.M{
+			"name":         item.Name,
+			"downloads":    0,
+			"maxDownloads": s.maxDownloads,
+			"createdAt":    now,
+		}
+		if buyerObjID != nil {
+			newGrant["userID"] = *buyerObjID
+		}
+		var grant Grant
+		err := s.grantsCollection.FindOneAndUpdate(ctx,
+			bson.M{"orderID": orderObjID, "productID": item.ProductID},
+			bson.M{"$setOnInsert": newGrant},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&grant)
+		if err != nil {
+			log.Printf("Error creating download grant for order %s: %v", o.ID, err)
+			return nil, errors.New("failed to prepare downloads")
+		}
+
+		link := Link{
+			ProductID:          item.ProductID.Hex(),
+			Name:               grant.Name,
+			DownloadsUsed:      grant.Downloads,
+			DownloadsRemaining: max(grant.MaxDownloads-grant.Downloads, 0),
+		}
+		if link.DownloadsRemaining > 0 {
+			grantID := grant.ID.Hex()
+			link.URL = fmt.Sprintf("/api/downloads/%s?expires=%s&signature=%s", grantID, expires, s.sign(grantID, expires))
+			link.ExpiresAt = &expiresAt
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// Open validates a signed download link, opens the file and counts the download.
+// A download is only counted once the file has been opened successfully.
+func (s *service) Open(ctx context.Context, grantID, expires, signature string) (*File, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.sign(grantID, expires))) {
+		return nil, errors.New("invalid download link")
+	}
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid download link")
+	}
+	if time.Now().Unix() > expiresUnix {
+		return nil, errors.New("download link has expired")
+	}
+	grantObjID, err := primitive.ObjectIDFromHex(grantID)
+	if err != nil {
+		return nil, errors.New("invalid download link")
+	}
+
+	var grant Grant
+	if err := s.grantsCollection.FindOne(ctx, bson.M{"_id": grantObjID}).Decode(&grant); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("download not found")
+		}
+		log.Printf("Error finding download grant: %v", err)
+		return nil, errors.New("database error retrieving download")
+	}
+	// The signature stands in for the buyer, so only the order's state is checked: it may have
+	// been cancelled or refunded since the link was issued. Its owner may have changed too, when
+	// a guest order is claimed, which leaves the link valid.
+	if o, err := s.orderService.GetOrderByID(ctx, grant.OrderID.Hex()); err != nil || downloadable(o) != nil {
+		return nil, errors.New("download is no longer available")
+	}
+	if grant.Downloads >= grant.MaxDownloads {
+		return nil, errors.New("download limit reached")
+	}
+
+	meta, err := s.productService.GetProductFile(ctx, grant.ProductID.Hex())
+	if err != nil {
+		if err.Error() == "product not found" || err.Error() == "file not found" {
+			return nil, errors.New("download is no longer available")
+		}
+		return nil, err
+	}
+	body, err := s.files.Open(ctx, meta.Key)
+	if err != nil {
+		if err.Error() == "file not found" {
+			return nil, errors.New("download is no longer available")
+		}
+		return nil, err
+	}
+
+	// Counting is conditional so concurrent downloads cannot exceed the limit.
+	res, err := s.grantsCollection.UpdateOne(ctx,
+		bson.M{"_id": grantObjID, "$expr": bson.M{"$lt": bson.A{"$downloads", "$maxDownloads"}}},
+		bson.M{"$inc": bson.M{"downloads": 1}, "$set": bson.M{"lastDownloadAt": time.Now()}},
+	)
+	if err != nil || res.ModifiedCount == 0 {
+		body.Close()
+		if err != nil {
+			log.Printf("Error counting download: %v", err)
+			return nil, errors.New("failed to start download")
+		}
+		return nil, errors.New("download limit reached")
+	}
+
+	return &File{Name: meta.Name, ContentType: meta.ContentType, Size: meta.Size, Body: body}, nil
+}
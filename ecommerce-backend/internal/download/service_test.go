@@ -0,0 +1,107 @@
+// internal/download/service_test.go
+package download
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/storage"
+)
+
+// The fakes below implement what the tests call; everything else is left to the embedded
+// (nil) interfaces.
+
+type fakeOrders struct {
+	order.OrderService
+	order *order.OrderResponse
+}
+
+func (f *fakeOrders) GetOrderByID(ctx context.Context, orderID string) (*order.OrderResponse, error) {
+	o := *f.order
+	return &o, nil
+}
+
+type fakeProducts struct{ product.ProductService }
+
+func (fakeProducts) GetProductFile(ctx context.Context, id string) (*product.DigitalFile, error) {
+	return &product.DigitalFile{Key: "files/manual.pdf", Name: "manual.pdf", ContentType: "application/pdf", Size: 4}, nil
+}
+
+type fakeStorage struct{ storage.Storage }
+
+func (fakeStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("%PDF")), nil
+}
+
+// TestGuestOrderDownloadsAfterClaim issues links for a guest order, then has the order
+// claimed by an account and checks the links still open.
+func TestGuestOrderDownloadsAfterClaim(t *testing.T) {
+	orderID, productID := primitive.NewObjectID(), primitive.NewObjectID()
+	orders := &fakeOrders{order: &order.OrderResponse{
+		ID:     orderID.Hex(),
+		Status: order.StatusProcessing,
+		Items:  []order.OrderItem{{ProductID: productID, Name: "Manual", Digital: true}},
+	}}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("guest order", func(mt *mtest.T) {
+		s := &service{
+			grantsCollection: mt.DB.Collection("download_grants"),
+			orderService:     orders,
+			productService:   fakeProducts{},
+			files:            fakeStorage{},
+			signingKey:       []byte("download:test"),
+			linkTTL:          time.Hour,
+			maxDownloads:     3,
+		}
+
+		grant := bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "orderID", Value: orderID},
+			{Key: "productID", Value: productID},
+			{Key: "name", Value: "Manual"},
+			{Key: "downloads", Value: 0},
+			{Key: "maxDownloads", Value: 3},
+		}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: grant}))
+		links, err := s.GetOrderDownloads(context.Background(), orderID.Hex(), "", false)
+		if err != nil {
+			mt.Fatalf("GetOrderDownloads() error = %v", err)
+		}
+		if len(links) != 1 || links[0].URL == "" {
+			mt.Fatalf("GetOrderDownloads() = %+v, want one link", links)
+		}
+		update := mt.GetStartedEvent().Command.Lookup("update").Document()
+		if _, err := update.LookupErr("$setOnInsert", "userID"); err == nil {
+			mt.Errorf("grant for a guest order was created with a buyer: %s", update)
+		}
+
+		// The order is claimed; the link carries no buyer and keeps working.
+		orders.order.UserID = primitive.NewObjectID().Hex()
+		link, err := url.Parse(links[0].URL)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		grantID := strings.TrimPrefix(link.Path, "/api/downloads/")
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.download_grants", mtest.FirstBatch, grant),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+		f, err := s.Open(context.Background(), grantID, link.Query().Get("expires"), link.Query().Get("signature"))
+		if err != nil {
+			mt.Fatalf("Open() after the order was claimed error = %v", err)
+		}
+		f.Body.Close()
+	})
+}
@@ -0,0 +1,129 @@
+// internal/event/model.go
+package event
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// Event types. The data of each is the struct of the same name with a Data suffix.
+const (
+	OrderCreated        = "order.created"
+	OrderStatusChanged  = "order.status_changed"
+	ProductStockChanged = "product.stock_changed"
+	UserRegistered      = "user.registered"
+)
+
+// Types lists every event type.
+var Types = []string{OrderCreated, OrderStatusChanged, ProductStockChanged, UserRegistered}
+
+// Outbox statuses.
+const (
+	StatusPending   = "pending"   // Waiting to be handled by at least one subscriber
+	StatusDelivered = "delivered" // Every subscriber has handled it
+	StatusFailed    = "failed"    // A subscriber still failed after the last attempt; kept for inspection
+)
+
+// handlerTimeout is how long one subscriber may take to handle an event.
+const handlerTimeout = 30 * time.Second
+
+// dispatchLock is how long the dispatcher holds an event it is delivering. It is renewed
+// before each subscriber, so it only has to outlast one handlerTimeout. If an instance dies
+// mid-delivery, another one picks the event up once the lock runs out.
+const dispatchLock = time.Minute
+
+// Retry backoff: the first retry comes after retryBaseDelay, doubling up to retryMaxDelay.
+const (
+	retryBaseDelay = 5 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// Event is something that happened in the shop, recorded in the "outbox" collection in the
+// same transaction as the change itself and then delivered to subscribers at least once.
+type Event struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type          string             `bson:"type" json:"type"`
+	SubjectID     string             `bson:"subjectID" json:"subjectId"` // The order, product or user the event is about
+	Data          bson.Raw           `bson:"data" json:"-"`              // One of the *Data structs, see Decode
+	OccurredAt    time.Time          `bson:"occurredAt" json:"occurredAt"`
+	Status        string             `bson:"status" json:"status"`
+	Delivered     []string           `bson:"delivered,omitempty" json:"delivered,omitempty"` // Subscribers that have handled the event
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	LastError     string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	LockedUntil   *time.Time         `bson:"lockedUntil,omitempty" json:"-"`
+	CompletedAt   *time.Time         `bson:"completedAt,omitempty" json:"completedAt,omitempty"` // Delivered or given up on
+}
+
+// Decode unmarshals the event's data into v, which should point to the *Data struct for its type.
+func (e *Event) Decode(v interface{}) error {
+	return bson.Unmarshal(e.Data, v)
+}
+
+// DecodeData decodes the event's data into a new *Data struct of its type.
+func (e *Event) DecodeData() (interface{}, error) {
+	var data interface{}
+	switch e.Type {
+	case OrderCreated:
+		data = &OrderCreatedData{}
+	case OrderStatusChanged:
+		data = &OrderStatusChangedData{}
+	case ProductStockChanged:
+		data = &ProductStockChangedData{}
+	case UserRegistered:
+		data = &UserRegisteredData{}
+	default:
+		return nil, fmt.Errorf("unknown event type %q", e.Type)
+	}
+	if err := e.Decode(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// OrderCreatedData is the data of an OrderCreated event.
+type OrderCreatedData struct {
+	OrderID     string      `bson:"orderId" json:"orderId"`
+	UserID      string      `bson:"userId,omitempty" json:"userId,omitempty"`         // Empty for guest orders
+	GuestEmail  string      `bson:"guestEmail,omitempty" json:"guestEmail,omitempty"` // Guest orders only
+	Status      string      `bson:"status" json:"status"`
+	TotalAmount money.Money `bson:"totalAmount" json:"totalAmount"`
+	Items       []ItemData  `bson:"items" json:"items"`
+}
+
+// ItemData is an order line in event data.
+type ItemData struct {
+	ProductID string `bson:"productId" json:"productId"`
+	Quantity  int    `bson:"quantity" json:"quantity"`
+}
+
+// OrderStatusChangedData is the data of an OrderStatusChanged event.
+type OrderStatusChangedData struct {
+	OrderID string `bson:"orderId" json:"orderId"`
+	UserID  string `bson:"userId,omitempty" json:"userId,omitempty"` // Empty for unclaimed guest orders
+	From    string `bson:"from" json:"from"`
+	To      string `bson:"to" json:"to"`
+	Reason  string `bson:"reason,omitempty" json:"reason,omitempty"` // Cancellation reason, if any
+}
+
+// ProductStockChangedData is the data of a ProductStockChanged event: on-hand stock of a product
+// in a warehouse went up or down. Reservations do not change on-hand stock.
+type ProductStockChangedData struct {
+	ProductID   string `bson:"productId" json:"productId"`
+	WarehouseID string `bson:"warehouseId" json:"warehouseId"`
+	Delta       int    `bson:"delta" json:"delta"`
+	Reason      string `bson:"reason" json:"reason"` // "adjusted", "order committed" or "order restocked"
+	OrderID     string `bson:"orderId,omitempty" json:"orderId,omitempty"`
+}
+
+// UserRegisteredData is the data of a UserRegistered event.
+type UserRegisteredData struct {
+	UserID   string `bson:"userId" json:"userId"`
+	Username string `bson:"username" json:"username"`
+	Email    string `bson:"email" json:"email"`
+}
@@ -0,0 +1,268 @@
+// internal/event/service.go
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils"
+)
+
+// Recorder writes events to the outbox. Call Record with a transaction's session context so
+// the event is committed or rolled back together with the change it describes.
+type Recorder interface {
+	Record(ctx context.Context, eventType, subjectID string, data interface{}) error
+}
+
+// Handler reacts to an event. Delivery is at least once, so a handler can see the same event
+// again (e.g. after a crash) and should be idempotent, keyed on Event.ID if need be.
+// Returning an error has the event retried for this handler later.
+type Handler func(ctx context.Context, e *Event) error
+
+// EventBus records domain events in the outbox and dispatches them to in-process subscribers.
+type EventBus interface {
+	Recorder
+
+	// Subscribe registers handler under a unique name for the given event types, or for every
+	// type when none are given. Call it before Start. The name is what marks an event as
+	// handled, so renaming a subscriber has it receive pending events again.
+	Subscribe(name string, handler Handler, types ...string)
+
+	// DispatchDue claims due events one at a time, oldest first, and delivers them.
+	DispatchDue(ctx context.Context) (int, error)
+	// Start runs DispatchDue every interval until ctx is cancelled.
+	Start(ctx context.Context, interval time.Duration)
+}
+
+// subscriber is a handler registered with Subscribe.
+type subscriber struct {
+	name    string
+	handler Handler
+	types   map[string]bool // Empty for every type
+}
+
+// service implements EventBus.
+type service struct {
+	outboxCollection *mongo.Collection
+	maxAttempts      int
+
+	mu          sync.RWMutex
+	subscribers []subscriber
+}
+
+// NewEventBus creates a new event bus.
+func NewEventBus(cfg *config.Config) EventBus {
+	return &service{
+		outboxCollection: database.GetCollection("outbox"),
+		maxAttempts:      cfg.EventMaxAttempts,
+	}
+}
+
+// Record adds an event to the outbox, due for dispatch right away.
+func (s *service) Record(ctx context.Context, eventType, subjectID string, data interface{}) error {
+	raw, err := bson.Marshal(data)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", eventType, err)
+		return errors.New("failed to encode event")
+	}
+	now := time.Now()
+	_, err = s.outboxCollection.InsertOne(ctx, Event{
+		Type:          eventType,
+		SubjectID:     subjectID,
+		Data:          raw,
+		OccurredAt:    now,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+	})
+	if err != nil {
+		log.Printf("Error recording %s event: %v", eventType, err)
+		return errors.New("failed to record event")
+	}
+	return nil
+}
+
+// Subscribe registers a handler. Names must be unique; a duplicate is a programming error.
+func (s *service) Subscribe(name string, handler Handler, types ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subscribers {
+		if sub.name == name {
+			panic("event: duplicate subscriber " + name)
+		}
+	}
+	sub := subscriber{name: name, handler: handler, types: make(map[string]bool, len(types))}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+	s.subscribers = append(s.subscribers, sub)
+}
+
+// subscribersFor returns the subscribers of an event type.
+func (s *service) subscribersFor(eventType string) []subscriber {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var subs []subscriber
+	for _, sub := range s.subscribers {
+		if len(sub.types) == 0 || sub.types[eventType] {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// DispatchDue claims due events one at a time, oldest first, and delivers them.
+func (s *service) DispatchDue(ctx context.Context) (int, error) {
+	processed := 0
+	for {
+		now := time.Now()
+		var e Event
+		err := s.outboxCollection.FindOneAndUpdate(ctx,
+			bson.M{
+				"status":        StatusPending,
+				"nextAttemptAt": bson.M{"$lte": now},
+				"lockedUntil":   bson.M{"$not": bson.M{"$gt": now}},
+			},
+			bson.M{"$set": bson.M{"lockedUntil": now.Add(dispatchLock)}},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "occurredAt", Value: 1}}).SetReturnDocument(options.After),
+		).Decode(&e)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return processed, nil
+			}
+			return processed, fmt.Errorf("failed to claim due event: %w", err)
+		}
+
+		s.deliver(ctx, &e)
+		processed++
+	}
+}
+
+// deliver hands a claimed event to every subscriber that has not handled it yet, then marks
+// it delivered or schedules a retry for the ones that failed.
+func (s *service) deliver(ctx context.Context, e *Event) {
+	done := make(map[string]bool, len(e.Delivered))
+	for _, name := range e.Delivered {
+		done[name] = true
+	}
+
+	var failures []string
+	for _, sub := range s.subscribersFor(e.Type) {
+		if done[sub.name] {
+			continue
+		}
+		if !s.renewLock(ctx, e) {
+			// Another instance has taken the event over; it delivers the rest.
+			log.Printf("Lost the dispatch lock on event %s", e.ID.Hex())
+			return
+		}
+		if err := handle(ctx, sub, e); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", sub.name, err))
+			continue
+		}
+		// Saved right away so a later failure does not have this subscriber see the event again.
+		_, err := s.outboxCollection.UpdateOne(ctx,
+			bson.M{"_id": e.ID, "lockedUntil": e.LockedUntil},
+			bson.M{"$addToSet": bson.M{"delivered": sub.name}},
+		)
+		if err != nil {
+			log.Printf("Error marking event %s handled by %s: %v", e.ID.Hex(), sub.name, err)
+		}
+	}
+
+	now := time.Now()
+	set := bson.M{}
+	unset := bson.M{"lockedUntil": ""}
+	if len(failures) == 0 {
+		set["status"] = StatusDelivered
+		set["completedAt"] = now
+		unset["lastError"] = ""
+	} else {
+		attempts := e.Attempts + 1
+		set["attempts"] = attempts
+		set["lastError"] = strings.Join(failures, "; ")
+		if attempts >= s.maxAttempts {
+			set["status"] = StatusFailed
+			set["completedAt"] = now
+			log.Printf("Giving up on %s event %s after %d attempts: %s", e.Type, e.ID.Hex(), attempts, set["lastError"])
+		} else {
+			set["nextAttemptAt"] = now.Add(retryDelay(attempts))
+		}
+	}
+	_, err := s.outboxCollection.UpdateOne(ctx,
+		bson.M{"_id": e.ID, "lockedUntil": e.LockedUntil},
+		bson.M{"$set": set, "$unset": unset},
+	)
+	if err != nil {
+		// The lock runs out and the event is dispatched again.
+		log.Printf("Error saving dispatch of event %s: %v", e.ID.Hex(), err)
+	}
+}
+
+// renewLock extends the claim on an event before the next subscriber runs, and reports
+// whether it is still held.
+func (s *service) renewLock(ctx context.Context, e *Event) bool {
+	until := time.Now().Add(dispatchLock)
+	res, err := s.outboxCollection.UpdateOne(ctx,
+		bson.M{"_id": e.ID, "lockedUntil": e.LockedUntil},
+		bson.M{"$set": bson.M{"lockedUntil": until}},
+	)
+	if err != nil {
+		log.Printf("Error renewing dispatch lock on event %s: %v", e.ID.Hex(), err)
+		return false
+	}
+	if res.MatchedCount == 0 {
+		return false
+	}
+	e.LockedUntil = &until
+	return true
+}
+
+// handle runs one subscriber's handler, turning a panic into an error so it cannot take the
+// dispatcher down.
+func handle(ctx context.Context, sub subscriber, e *Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	handlerCtx, cancel := context.WithTimeout(ctx, handlerTimeout)
+	defer cancel()
+	return sub.handler(handlerCtx, e)
+}
+
+// retryDelay is how long to wait before the next attempt after attempts failed ones.
+func retryDelay(attempts int) time.Duration {
+	return utils.Backoff(attempts, retryBaseDelay, retryMaxDelay)
+}
+
+// Start runs the dispatcher in the background until ctx is cancelled.
+func (s *service) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+			if _, err := s.DispatchDue(runCtx); err != nil {
+				log.Printf("Event dispatch failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
@@ -0,0 +1,128 @@
+// internal/exchange/handler.go
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// ExchangeHandler handles HTTP requests related to exchange rates.
+type ExchangeHandler struct {
+	Service   ExchangeService
+	Validator *validator.Validate
+}
+
+// NewExchangeHandler creates a new ExchangeHandler instance.
+func NewExchangeHandler(s ExchangeService) *ExchangeHandler {
+	return &ExchangeHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// ListRates godoc
+// @Summary List exchange rates
+// @Description Retrieve the store base currency and every currency prices can be shown in
+// @Tags Currency
+// @Produce  json
+// @Success 200 {object} map[string]interface{} "Base currency and exchange rates"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /exchange-rates [get]
+func (h *ExchangeHandler) ListRates(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	rates, err := h.Service.ListRates(ctx)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"baseCurrency": h.Service.BaseCurrency(), "rates": rates})
+}
+
+// SetRate godoc
+// @Summary Create or update an exchange rate
+// @Description Set how many units of the given currency one unit of the base currency buys (admin only)
+// @Tags Currency
+// @Accept  json
+// @Produce  json
+// @Param   currency path string true "ISO 4217 currency code"
+// @Param   request body SetRateRequest true "Exchange rate"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Exchange rate saved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid currency code or rate"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/exchange-rates/{currency} [put]
+func (h *ExchangeHandler) SetRate(c *gin.Context) {
+	var req SetRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	rate, err := h.Service.SetRate(ctx, c.Param("currency"), &req)
+	if err != nil {
+		switch err.Error() {
+		case "invalid currency code", "cannot set a rate for the base currency", "rate must be a positive decimal number",
+			"rate is below the smallest supported rate of 0.00000001":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Exchange rate saved successfully", "rate": rate})
+}
+
+// DeleteRate godoc
+// @Summary Delete an exchange rate
+// @Description Remove a currency so prices can no longer be requested in it (admin only)
+// @Tags Currency
+// @Produce  json
+// @Param   currency path string true "ISO 4217 currency code"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Exchange rate deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid currency code"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Exchange rate not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/exchange-rates/{currency} [delete]
+func (h *ExchangeHandler) DeleteRate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.Service.DeleteRate(ctx, c.Param("currency"))
+	if err != nil {
+		if err.Error() == "exchange rate not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "invalid currency code" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+}
@@ -0,0 +1,19 @@
+// internal/exchange/model.go
+package exchange
+
+import (
+	"time"
+)
+
+// ExchangeRate is an admin-maintained conversion rate from the store base currency.
+// One major unit of the base currency equals Rate major units of Currency.
+type ExchangeRate struct {
+	Currency  string    `bson:"_id" json:"currency"` // ISO 4217 code; one document per currency
+	Rate      string    `bson:"rate" json:"rate"`    // Decimal string, kept exact (e.g. "0.9215")
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// SetRateRequest defines the structure for creating or updating an exchange rate.
+type SetRateRequest struct {
+	Rate string `json:"rate" validate:"required"`
+}
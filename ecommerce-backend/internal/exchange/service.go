@@ -0,0 +1,153 @@
+// internal/exchange/service.go
+package exchange
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// ExchangeService defines the interface for exchange-rate operations.
+type ExchangeService interface {
+	BaseCurrency() string
+	ListRates(ctx context.Context) ([]ExchangeRate, error)
+	GetRate(ctx context.Context, currency string) (*ExchangeRate, error)                      // The base currency always has rate "1"
+	SetRate(ctx context.Context, currency string, req *SetRateRequest) (*ExchangeRate, error) // Admin only
+	DeleteRate(ctx context.Context, currency string) error                                    // Admin only
+}
+
+// service implements ExchangeService.
+type service struct {
+	ratesCollection *mongo.Collection
+	baseCurrency    string
+}
+
+// NewExchangeService creates a new exchange-rate service.
+func NewExchangeService(cfg *config.Config) ExchangeService {
+	return &service{
+		ratesCollection: database.GetCollection("exchange_rates"),
+		baseCurrency:    cfg.BaseCurrency,
+	}
+}
+
+// Apply converts a base-currency amount using rate. The rate is validated when it is
+// stored, so a parse failure here means corrupted data and the amount is returned unchanged.
+func Apply(amount money.Money, rate *ExchangeRate) money.Money {
+	r, err := money.ParseRate(rate.Rate)
+	if err != nil {
+		log.Printf("Ignoring invalid stored exchange rate for %s: %q", rate.Currency, rate.Rate)
+		return amount
+	}
+	return amount.Convert(rate.Currency, r)
+}
+
+// normalizeCurrency upper-cases a currency code and checks its shape.
+func normalizeCurrency(currency string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(currency))
+	if !money.ValidCurrency(code) {
+		return "", errors.New("invalid currency code")
+	}
+	return code, nil
+}
+
+// BaseCurrency returns the store base currency all prices are kept in.
+func (s *service) BaseCurrency() string {
+	return s.baseCurrency
+}
+
+// ListRates retrieves all configured exchange rates, sorted by currency.
+func (s *service) ListRates(ctx context.Context) ([]ExchangeRate, error) {
+	cursor, err := s.ratesCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding exchange rates: %v", err)
+		return nil, errors.New("failed to retrieve exchange rates")
+	}
+	defer cursor.Close(ctx)
+
+	rates := []ExchangeRate{}
+	if err = cursor.All(ctx, &rates); err != nil {
+		log.Printf("Error decoding exchange rates: %v", err)
+		return nil, errors.New("failed to process exchange rate data")
+	}
+	return rates, nil
+}
+
+// GetRate retrieves the rate for a currency.
+func (s *service) GetRate(ctx context.Context, currency string) (*ExchangeRate, error) {
+	code, err := normalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if code == s.baseCurrency {
+		return &ExchangeRate{Currency: code, Rate: "1"}, nil
+	}
+
+	var rate ExchangeRate
+	err = s.ratesCollection.FindOne(ctx, bson.M{"_id": code}).Decode(&rate)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("unsupported currency")
+		}
+		log.Printf("Error finding exchange rate: %v", err)
+		return nil, errors.New("database error retrieving exchange rate")
+	}
+	return &rate, nil
+}
+
+// SetRate creates or replaces the rate for a currency.
+func (s *service) SetRate(ctx context.Context, currency string, req *SetRateRequest) (*ExchangeRate, error) {
+	code, err := normalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if code == s.baseCurrency {
+		return nil, errors.New("cannot set a rate for the base currency")
+	}
+	parsed, err := money.ParseRate(req.Rate)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := ExchangeRate{Currency: code, Rate: parsed.FloatString(8), UpdatedAt: time.Now()}
+	rate.Rate = strings.TrimRight(strings.TrimRight(rate.Rate, "0"), ".") // "0.92000000" -> "0.92"
+
+	// Rates too small for 8 decimal places would round to 0, which conversions ignore.
+	if rate.Rate == "0" {
+		return nil, errors.New("rate is below the smallest supported rate of 0.00000001")
+	}
+
+	_, err = s.ratesCollection.ReplaceOne(ctx, bson.M{"_id": code}, rate, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("Error saving exchange rate: %v", err)
+		return nil, errors.New("failed to save exchange rate")
+	}
+	return &rate, nil
+}
+
+// DeleteRate removes the rate for a currency, so prices can no longer be shown in it.
+func (s *service) DeleteRate(ctx context.Context, currency string) error {
+	code, err := normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.ratesCollection.DeleteOne(ctx, bson.M{"_id": code})
+	if err != nil {
+		log.Printf("Error deleting exchange rate: %v", err)
+		return errors.New("failed to delete exchange rate")
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("exchange rate not found")
+	}
+	return nil
+}
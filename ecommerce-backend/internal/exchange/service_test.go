@@ -0,0 +1,53 @@
+// internal/exchange/service_test.go
+package exchange
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestSetRate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    string
+		want    string // Rate as stored
+		wantErr string
+	}{
+		{"trailing zeros are trimmed", "0.92000", "0.92", ""},
+		{"whole number", "151", "151", ""},
+		{"rounded to 8 decimal places", "0.123456789", "0.12345679", ""},
+		{"smallest rate", "0.00000001", "0.00000001", ""},
+		{"rounds up to the smallest rate", "0.000000005", "0.00000001", ""},
+		{"rounds down to zero", "0.000000004", "", "rate is below the smallest supported rate of 0.00000001"},
+		{"far too small", "1/1000000000000", "", "rate is below the smallest supported rate of 0.00000001"},
+		{"zero", "0", "", "rate must be a positive decimal number"},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			s := &service{ratesCollection: mt.DB.Collection("exchange_rates"), baseCurrency: "USD"}
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+			got, err := s.SetRate(context.Background(), "eur", &SetRateRequest{Rate: tt.rate})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					mt.Fatalf("SetRate(%q) error = %v, want %q", tt.rate, err, tt.wantErr)
+				}
+				if events := mt.GetAllStartedEvents(); len(events) != 0 {
+					mt.Errorf("SetRate(%q) saved a rejected rate", tt.rate)
+				}
+				return
+			}
+			if err != nil {
+				mt.Fatalf("SetRate(%q) error = %v", tt.rate, err)
+			}
+			if got.Currency != "EUR" || got.Rate != tt.want {
+				mt.Errorf("SetRate(%q) = %s %s, want EUR %s", tt.rate, got.Currency, got.Rate, tt.want)
+			}
+		})
+	}
+}
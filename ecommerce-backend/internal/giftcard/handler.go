@@ -0,0 +1,183 @@
+// internal/giftcard/handler.go
+package giftcard
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// GiftCardHandler handles HTTP requests related to gift cards.
+type GiftCardHandler struct {
+	Service   GiftCardService
+	Validator *validator.Validate
+}
+
+// NewGiftCardHandler creates a new GiftCardHandler instance.
+func NewGiftCardHandler(s GiftCardService) *GiftCardHandler {
+	return &GiftCardHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// respondWithServiceError maps a gift card service error to an HTTP response.
+func respondWithServiceError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "gift card not found":
+		utils.RespondWithError(c, http.StatusNotFound, msg)
+	case strings.HasPrefix(msg, "invalid"):
+		utils.RespondWithError(c, http.StatusBadRequest, msg)
+	default:
+		utils.RespondWithError(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// IssueGiftCard godoc
+// @Summary Issue a gift card (Admin only)
+// @Description Create a gift card with a balance in the base currency, e.g. to compensate a customer. The response holds the full code to pass on.
+// @Tags Gift Cards
+// @Accept  json
+// @Produce  json
+// @Param   request body IssueGiftCardRequest true "Amount, optional expiry and note"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Gift card issued"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/gift-cards [post]
+func (h *GiftCardHandler) IssueGiftCard(c *gin.Context) {
+	var req IssueGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	card, err := h.Service.Issue(ctx, &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Gift card issued", "giftCard": card})
+}
+
+// GetAllGiftCards godoc
+// @Summary List gift cards (Admin only)
+// @Description Retrieve every gift card, issued or bought, newest first
+// @Tags Gift Cards
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of gift cards"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/gift-cards [get]
+func (h *GiftCardHandler) GetAllGiftCards(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cards, err := h.Service.GetAllGiftCards(ctx)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"giftCards": cards})
+}
+
+// GetGiftCardByID godoc
+// @Summary Get a gift card (Admin only)
+// @Description Retrieve one gift card with its code and balance
+// @Tags Gift Cards
+// @Produce  json
+// @Param   id path string true "Gift card ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Gift card data"
+// @Failure 400 {object} map[string]interface{} "Invalid gift card ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Gift card not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/gift-cards/{id} [get]
+func (h *GiftCardHandler) GetGiftCardByID(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	card, err := h.Service.GetGiftCardByID(ctx, c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"giftCard": card})
+}
+
+// DisableGiftCard godoc
+// @Summary Disable a gift card (Admin only)
+// @Description Stop a gift card from being redeemed, e.g. when it was lost or stolen. Its balance is kept.
+// @Tags Gift Cards
+// @Produce  json
+// @Param   id path string true "Gift card ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Gift card disabled"
+// @Failure 400 {object} map[string]interface{} "Invalid gift card ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Gift card not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/gift-cards/{id}/disable [post]
+func (h *GiftCardHandler) DisableGiftCard(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	card, err := h.Service.Disable(ctx, c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Gift card disabled", "giftCard": card})
+}
+
+// CheckBalance godoc
+// @Summary Check a gift card's balance
+// @Description Look up the remaining balance and expiry of a gift card by its code
+// @Tags Gift Cards
+// @Produce  json
+// @Param   code path string true "Gift card code"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Gift card balance"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Gift card not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /gift-cards/{code}/balance [get]
+func (h *GiftCardHandler) CheckBalance(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	balance, err := h.Service.CheckBalance(ctx, c.Param("code"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"giftCard": balance})
+}
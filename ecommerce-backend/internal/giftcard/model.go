@@ -0,0 +1,77 @@
+// internal/giftcard/model.go
+package giftcard
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// Gift card statuses.
+const (
+	StatusActive   = "active"
+	StatusDisabled = "disabled" // Disabled by an admin, or because the order that bought it was refunded
+)
+
+// Where a gift card came from.
+const (
+	SourceAdmin = "admin" // Issued by customer service
+	SourceOrder = "order" // Bought as a gift card product
+)
+
+// Ledger entry types.
+const (
+	TransactionRedeem  = "redeem"
+	TransactionRestore = "restore" // Puts a redemption back after its order was cancelled or refunded
+)
+
+// GiftCard is a code with a balance in the base currency that pays for orders.
+type GiftCard struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Code           string              `bson:"code" json:"code"` // XXXX-XXXX-XXXX-XXXX
+	InitialBalance money.Money         `bson:"initialBalance" json:"initialBalance"`
+	Balance        money.Money         `bson:"balance" json:"balance"`
+	ExpiresAt      *time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"` // Never expires when unset
+	Status         string              `bson:"status" json:"status"`
+	Source         string              `bson:"source" json:"source"`
+	OrderID        *primitive.ObjectID `bson:"orderID,omitempty" json:"orderId,omitempty"`         // Order that bought the card
+	PurchaserID    *primitive.ObjectID `bson:"purchaserID,omitempty" json:"purchaserId,omitempty"` // Customer who bought it
+	Note           string              `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Transaction records a change to a gift card's balance made by an order.
+type Transaction struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GiftCardID primitive.ObjectID `bson:"giftCardID" json:"giftCardId"`
+	OrderID    primitive.ObjectID `bson:"orderID" json:"orderId"`
+	Type       string             `bson:"type" json:"type"`                             // TransactionRedeem or TransactionRestore
+	Amount     money.Money        `bson:"amount" json:"amount"`                         // Negative for redemptions
+	Reversed   bool               `bson:"reversed,omitempty" json:"reversed,omitempty"` // Set on redemptions once restored
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// Redemption is the part of an order paid with one gift card.
+type Redemption struct {
+	GiftCardID primitive.ObjectID
+	Code       string // Masked, e.g. "****-****-****-7K2M"
+	Amount     money.Money
+}
+
+// IssueGiftCardRequest defines the structure for issuing a gift card by hand.
+type IssueGiftCardRequest struct {
+	Amount    float64    `json:"amount" validate:"required,gt=0"` // Major units of the base currency
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Note      string     `json:"note,omitempty" validate:"max=200"` // e.g. why it was issued
+}
+
+// BalanceResponse is what a customer sees when checking a gift card.
+type BalanceResponse struct {
+	Code      string      `json:"code"` // Masked
+	Balance   money.Money `json:"balance"`
+	ExpiresAt *time.Time  `json:"expiresAt,omitempty"`
+	Status    string      `json:"status"`
+}
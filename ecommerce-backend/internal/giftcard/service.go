@@ -0,0 +1,343 @@
+// internal/giftcard/service.go
+package giftcard
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// codeAlphabet leaves out characters that are easily confused (0/O, 1/I).
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GiftCardService defines the interface for gift cards.
+type GiftCardService interface {
+	Issue(ctx context.Context, req *IssueGiftCardRequest) (*GiftCard, error) // Admin only
+	GetAllGiftCards(ctx context.Context) ([]GiftCard, error)                 // Admin only
+	GetGiftCardByID(ctx context.Context, id string) (*GiftCard, error)       // Admin only
+	Disable(ctx context.Context, id string) (*GiftCard, error)               // Admin only
+	CheckBalance(ctx context.Context, code string) (*BalanceResponse, error)
+
+	// The methods below are called by the order service with its transaction's session
+	// context, so they commit or roll back together with the order.
+
+	// Redeem takes up to max from the card's balance for orderID.
+	Redeem(ctx context.Context, code string, orderID primitive.ObjectID, max money.Money) (*Redemption, error)
+	// RestoreForOrder puts back everything redeemed for orderID that has not been restored yet.
+	RestoreForOrder(ctx context.Context, orderID primitive.ObjectID) error
+	// IssueForOrder creates quantity cards worth value each, bought by userID with orderID.
+	IssueForOrder(ctx context.Context, orderID, userID primitive.ObjectID, value money.Money, quantity int) ([]string, error)
+	// DisableForOrder disables the cards bought with orderID.
+	DisableForOrder(ctx context.Context, orderID primitive.ObjectID) error
+}
+
+// service implements GiftCardService.
+type service struct {
+	cardsCollection        *mongo.Collection
+	transactionsCollection *mongo.Collection
+	baseCurrency           string
+	validity               time.Duration // Lifetime of purchased cards
+}
+
+// NewGiftCardService creates a new gift card service.
+func NewGiftCardService(cfg *config.Config) GiftCardService {
+	return &service{
+		cardsCollection:        database.GetCollection("gift_cards"),
+		transactionsCollection: database.GetCollection("gift_card_transactions"),
+		baseCurrency:           cfg.BaseCurrency,
+		validity:               cfg.GiftCardValidity,
+	}
+}
+
+// newCode returns a random code formatted as XXXX-XXXX-XXXX-XXXX (80 bits).
+func newCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < 16; i++ {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeCode accepts codes typed with any case, spacing or dashes.
+func NormalizeCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 16 {
+		return code
+	}
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+}
+
+// maskCode hides all but the last group of a code.
+func maskCode(code string) string {
+	if len(code) < 4 {
+		return code
+	}
+	return "****-****-****-" + code[len(code)-4:]
+}
+
+// insert stores a new card with a fresh code, retrying on the unlikely code collision.
+func (s *service) insert(ctx context.Context, card *GiftCard) error {
+	for attempt := 0; attempt < 3; attempt++ {
+		code, err := newCode()
+		if err != nil {
+			log.Printf("Error generating gift card code: %v", err)
+			return errors.New("failed to issue gift card")
+		}
+		card.ID, card.Code = primitive.NewObjectID(), code
+		_, err = s.cardsCollection.InsertOne(ctx, card)
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			log.Printf("Error inserting gift card: %v", err)
+			return errors.New("failed to issue gift card")
+		}
+	}
+	return errors.New("failed to issue gift card")
+}
+
+// Issue creates a gift card by hand, e.g. to compensate a customer.
+func (s *service) Issue(ctx context.Context, req *IssueGiftCardRequest) (*GiftCard, error) {
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, errors.New("invalid expiresAt: must be in the future")
+	}
+	amount := money.FromMajor(req.Amount, s.baseCurrency)
+	if amount.Amount <= 0 {
+		return nil, errors.New("invalid amount: must be at least one minor unit")
+	}
+
+	card := &GiftCard{
+		InitialBalance: amount,
+		Balance:        amount,
+		ExpiresAt:      req.ExpiresAt,
+		Status:         StatusActive,
+		Source:         SourceAdmin,
+		Note:           req.Note,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.insert(ctx, card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// GetAllGiftCards returns every gift card, newest first.
+func (s *service) GetAllGiftCards(ctx context.Context) ([]GiftCard, error) {
+	cursor, err := s.cardsCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		log.Printf("Error finding gift cards: %v", err)
+		return nil, errors.New("failed to retrieve gift cards")
+	}
+	defer cursor.Close(ctx)
+
+	cards := []GiftCard{}
+	if err := cursor.All(ctx, &cards); err != nil {
+		log.Printf("Error decoding gift cards: %v", err)
+		return nil, errors.New("failed to process gift card data")
+	}
+	return cards, nil
+}
+
+// GetGiftCardByID returns one gift card.
+func (s *service) GetGiftCardByID(ctx context.Context, id string) (*GiftCard, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid gift card ID format")
+	}
+	var card GiftCard
+	if err := s.cardsCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&card); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("gift card not found")
+		}
+		log.Printf("Error finding gift card: %v", err)
+		return nil, errors.New("database error retrieving gift card")
+	}
+	return &card, nil
+}
+
+// Disable stops a gift card from being redeemed. Its balance is kept.
+func (s *service) Disable(ctx context.Context, id string) (*GiftCard, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid gift card ID format")
+	}
+	var card GiftCard
+	err = s.cardsCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"status": StatusDisabled, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&card)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("gift card not found")
+		}
+		log.Printf("Error disabling gift card: %v", err)
+		return nil, errors.New("failed to disable gift card")
+	}
+	return &card, nil
+}
+
+// findByCode loads a gift card by its code.
+func (s *service) findByCode(ctx context.Context, code string) (*GiftCard, error) {
+	var card GiftCard
+	if err := s.cardsCollection.FindOne(ctx, bson.M{"code": NormalizeCode(code)}).Decode(&card); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("gift card not found")
+		}
+		log.Printf("Error finding gift card by code: %v", err)
+		return nil, errors.New("database error retrieving gift card")
+	}
+	return &card, nil
+}
+
+// CheckBalance returns a card's balance and expiry.
+func (s *service) CheckBalance(ctx context.Context, code string) (*BalanceResponse, error) {
+	card, err := s.findByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &BalanceResponse{Code: maskCode(card.Code), Balance: card.Balance, ExpiresAt: card.ExpiresAt, Status: card.Status}, nil
+}
+
+// Redeem takes up to max from a card for an order and records the redemption.
+func (s *service) Redeem(ctx context.Context, code string, orderID primitive.ObjectID, max money.Money) (*Redemption, error) {
+	card, err := s.findByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	masked := maskCode(card.Code)
+	switch {
+	case card.Status != StatusActive:
+		return nil, fmt.Errorf("gift card %s is disabled", masked)
+	case card.ExpiresAt != nil && time.Now().After(*card.ExpiresAt):
+		return nil, fmt.Errorf("gift card %s has expired", masked)
+	case card.Balance.Amount <= 0:
+		return nil, fmt.Errorf("gift card %s has no balance left", masked)
+	case card.Balance.Currency != max.Currency:
+		return nil, fmt.Errorf("gift card %s is in %s and cannot pay for an order in %s", masked, card.Balance.Currency, max.Currency)
+	}
+
+	amount := money.Min(card.Balance, max)
+	res, err := s.cardsCollection.UpdateOne(ctx,
+		bson.M{"_id": card.ID, "status": StatusActive, "balance.amount": bson.M{"$gte": amount.Amount}},
+		bson.M{"$inc": bson.M{"balance.amount": -amount.Amount}, "$set": bson.M{"updatedAt": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Error redeeming gift card %s: %v", card.ID.Hex(), err)
+		return nil, errors.New("failed to redeem gift card")
+	}
+	if res.ModifiedCount == 0 {
+		return nil, fmt.Errorf("gift card %s was used at the same time, try again", masked)
+	}
+
+	_, err = s.transactionsCollection.InsertOne(ctx, Transaction{
+		GiftCardID: card.ID,
+		OrderID:    orderID,
+		Type:       TransactionRedeem,
+		Amount:     money.Zero(amount.Currency).Sub(amount),
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error recording gift card redemption: %v", err)
+		return nil, errors.New("failed to redeem gift card")
+	}
+	return &Redemption{GiftCardID: card.ID, Code: masked, Amount: amount}, nil
+}
+
+// RestoreForOrder reverses the order's redemptions that are still in effect. Cards get the
+// amount back even if they have expired or been disabled since.
+func (s *service) RestoreForOrder(ctx context.Context, orderID primitive.ObjectID) error {
+	cursor, err := s.transactionsCollection.Find(ctx, bson.M{"orderID": orderID, "type": TransactionRedeem, "reversed": bson.M{"$ne": true}})
+	if err != nil {
+		log.Printf("Error finding gift card redemptions of order %s: %v", orderID.Hex(), err)
+		return errors.New("failed to restore gift card balance")
+	}
+	var redemptions []Transaction
+	if err := cursor.All(ctx, &redemptions); err != nil {
+		log.Printf("Error decoding gift card redemptions: %v", err)
+		return errors.New("failed to restore gift card balance")
+	}
+
+	now := time.Now()
+	for _, t := range redemptions {
+		restored := money.Zero(t.Amount.Currency).Sub(t.Amount)
+		_, err := s.transactionsCollection.UpdateOne(ctx, bson.M{"_id": t.ID}, bson.M{"$set": bson.M{"reversed": true}})
+		if err == nil {
+			_, err = s.cardsCollection.UpdateOne(ctx, bson.M{"_id": t.GiftCardID},
+				bson.M{"$inc": bson.M{"balance.amount": restored.Amount}, "$set": bson.M{"updatedAt": now}})
+		}
+		if err == nil {
+			_, err = s.transactionsCollection.InsertOne(ctx, Transaction{
+				GiftCardID: t.GiftCardID, OrderID: orderID, Type: TransactionRestore, Amount: restored, CreatedAt: now,
+			})
+		}
+		if err != nil {
+			log.Printf("Error restoring gift card %s for order %s: %v", t.GiftCardID.Hex(), orderID.Hex(), err)
+			return errors.New("failed to restore gift card balance")
+		}
+	}
+	return nil
+}
+
+// IssueForOrder creates the cards bought with an order. They expire after GIFT_CARD_VALIDITY.
+func (s *service) IssueForOrder(ctx context.Context, orderID, userID primitive.ObjectID, value money.Money, quantity int) ([]string, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.validity)
+	codes := make([]string, 0, quantity)
+	for i := 0; i < quantity; i++ {
+		card := &GiftCard{
+			InitialBalance: value,
+			Balance:        value,
+			ExpiresAt:      &expiresAt,
+			Status:         StatusActive,
+			Source:         SourceOrder,
+			OrderID:        &orderID,
+			PurchaserID:    &userID,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := s.insert(ctx, card); err != nil {
+			return nil, err
+		}
+		codes = append(codes, card.Code)
+	}
+	return codes, nil
+}
+
+// DisableForOrder disables the cards bought with an order, e.g. when it is refunded.
+// Whatever was already spent from them stays spent.
+func (s *service) DisableForOrder(ctx context.Context, orderID primitive.ObjectID) error {
+	_, err := s.cardsCollection.UpdateMany(ctx,
+		bson.M{"orderID": orderID, "source": SourceOrder},
+		bson.M{"$set": bson.M{"status": StatusDisabled, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Error disabling gift cards of order %s: %v", orderID.Hex(), err)
+		return errors.New("failed to disable gift cards")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// internal/inventory/model.go
+package inventory
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reservation statuses.
+const (
+	ReservationActive    = "active"    // Holding stock for an unpaid order
+	ReservationCommitted = "committed" // Converted into a stock deduction after payment
+	ReservationReleased  = "released"  // Returned to available stock (expired or cancelled)
+	ReservationRestocked = "restocked" // Deducted stock put back after a paid order was cancelled before shipping
+)
+
+// Reservation is a time-boxed hold on product stock in one warehouse for a pending order.
+// While active, its quantity counts towards the "reserved" field of both the warehouse stock
+// level and the product total and is not available to other buyers, but on-hand "stock" is
+// only reduced once the order is paid.
+type Reservation struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrderID       primitive.ObjectID `bson:"orderID" json:"orderId"`
+	ProductID     primitive.ObjectID `bson:"productID" json:"productId"`
+	WarehouseID   primitive.ObjectID `bson:"warehouseID" json:"warehouseId"`
+	Quantity      int                `bson:"quantity" json:"quantity"`
+	Status        string             `bson:"status" json:"status"`
+	ExpiresAt     time.Time          `bson:"expiresAt" json:"expiresAt"`
+	ReleaseReason string             `bson:"releaseReason,omitempty" json:"releaseReason,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
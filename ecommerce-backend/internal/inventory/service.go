@@ -0,0 +1,263 @@
+// internal/inventory/service.go
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+)
+
+// InventoryService defines the interface for stock reservation operations.
+// Methods that change stock must be called with the SessionContext of the caller's
+// transaction so that holds and the order they belong to commit or roll back together.
+type InventoryService interface {
+	Reserve(ctx context.Context, orderID, productID, warehouseID primitive.ObjectID, quantity int, expiresAt time.Time) error
+	Commit(ctx context.Context, orderID primitive.ObjectID) error                 // Payment received: deduct on-hand stock
+	Release(ctx context.Context, orderID primitive.ObjectID, reason string) error // Order expired or cancelled: free the hold
+	Restock(ctx context.Context, orderID primitive.ObjectID, reason string) error // Paid order cancelled before shipping: undo the deduction
+	GetOrderReservations(ctx context.Context, orderID primitive.ObjectID) ([]Reservation, error)
+	ExpiredOrderIDs(ctx context.Context, now time.Time) ([]primitive.ObjectID, error)
+
+	// Backorders are units ordered without stock. They are only counted on the product, to
+	// enforce its limit, until stock arrives and they are reserved like any other line.
+	AddBackorder(ctx context.Context, productID primitive.ObjectID, quantity, limit int) error // limit 0 means no limit
+	RemoveBackorder(ctx context.Context, productID primitive.ObjectID, quantity int) error     // Filled or cancelled
+}
+
+// service implements InventoryService.
+type service struct {
+	productsCollection     *mongo.Collection
+	stockLevelsCollection  *mongo.Collection // Per-warehouse levels (see warehouse package)
+	reservationsCollection *mongo.Collection
+	events                 event.Recorder // On-hand stock changes are recorded as ProductStockChanged
+}
+
+// NewInventoryService creates a new inventory service.
+func NewInventoryService(events event.Recorder) InventoryService {
+	return &service{
+		productsCollection:     database.GetCollection("products"),
+		stockLevelsCollection:  database.GetCollection("stock_levels"),
+		reservationsCollection: database.GetCollection("stock_reservations"),
+		events:                 events,
+	}
+}
+
+// availableAtLeast matches stock levels whose unreserved stock covers quantity.
+// A missing "reserved" field counts as 0.
+func availableAtLeast(quantity int) bson.M {
+	return bson.M{"$expr": bson.M{"$gte": bson.A{
+		bson.M{"$subtract": bson.A{"$stock", bson.M{"$ifNull": bson.A{"$reserved", 0}}}},
+		quantity,
+	}}}
+}
+
+// Reserve places a hold on quantity units of a product in a warehouse for an order.
+func (s *service) Reserve(ctx context.Context, orderID, productID, warehouseID primitive.ObjectID, quantity int, expiresAt time.Time) error {
+	now := time.Now()
+	filter := availableAtLeast(quantity)
+	filter["warehouseID"] = warehouseID
+	filter["productID"] = productID
+
+	res, err := s.stockLevelsCollection.UpdateOne(ctx, filter, bson.M{
+		"$inc": bson.M{"reserved": quantity},
+		"$set": bson.M{"updatedAt": now},
+	})
+	if err != nil {
+		log.Printf("Error reserving stock for product %s: %v", productID.Hex(), err)
+		return fmt.Errorf("failed to reserve stock for product %s", productID.Hex())
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("insufficient stock for product %s (concurrent modification)", productID.Hex())
+	}
+
+	// Keep the product total in step so listings can show availability without aggregating.
+	if _, err := s.productsCollection.UpdateOne(ctx, bson.M{"_id": productID}, bson.M{
+		"$inc": bson.M{"reserved": quantity, "version": 1},
+		"$set": bson.M{"updatedAt": now},
+	}); err != nil {
+		log.Printf("Error updating reserved total for product %s: %v", productID.Hex(), err)
+		return fmt.Errorf("failed to reserve stock for product %s", productID.Hex())
+	}
+
+	_, err = s.reservationsCollection.InsertOne(ctx, &Reservation{
+		OrderID:     orderID,
+		ProductID:   productID,
+		WarehouseID: warehouseID,
+		Quantity:    quantity,
+		Status:      ReservationActive,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+	if err != nil {
+		log.Printf("Error inserting stock reservation: %v", err)
+		return errors.New("failed to record stock reservation")
+	}
+	return nil
+}
+
+// Commit turns an order's active holds into on-hand stock deductions.
+func (s *service) Commit(ctx context.Context, orderID primitive.ObjectID) error {
+	return s.settle(ctx, orderID, ReservationActive, ReservationCommitted, "")
+}
+
+// Release returns an order's active holds to available stock.
+func (s *service) Release(ctx context.Context, orderID primitive.ObjectID, reason string) error {
+	return s.settle(ctx, orderID, ReservationActive, ReservationReleased, reason)
+}
+
+// Restock puts the stock deducted for an order's committed holds back on hand.
+func (s *service) Restock(ctx context.Context, orderID primitive.ObjectID, reason string) error {
+	return s.settle(ctx, orderID, ReservationCommitted, ReservationRestocked, reason)
+}
+
+// settle moves every reservation of an order in status from to status, adjusting product counters.
+func (s *service) settle(ctx context.Context, orderID primitive.ObjectID, from, status, reason string) error {
+	reservations, err := s.findReservations(ctx, bson.M{"orderID": orderID, "status": from})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, r := range reservations {
+		inc := bson.M{"reserved": -r.Quantity}
+		switch status {
+		case ReservationCommitted:
+			inc["stock"] = -r.Quantity
+		case ReservationRestocked:
+			inc = bson.M{"stock": r.Quantity}
+		}
+		if _, err := s.stockLevelsCollection.UpdateOne(ctx,
+			bson.M{"warehouseID": r.WarehouseID, "productID": r.ProductID},
+			bson.M{"$inc": inc, "$set": bson.M{"updatedAt": now}},
+		); err != nil {
+			log.Printf("Error settling reservation %s: %v", r.ID.Hex(), err)
+			return errors.New("failed to update warehouse stock")
+		}
+		inc["version"] = 1
+		if _, err := s.productsCollection.UpdateOne(ctx,
+			bson.M{"_id": r.ProductID},
+			bson.M{"$inc": inc, "$set": bson.M{"updatedAt": now}},
+		); err != nil {
+			log.Printf("Error settling reservation %s: %v", r.ID.Hex(), err)
+			return errors.New("failed to update product stock")
+		}
+
+		if delta, ok := inc["stock"].(int); ok {
+			stockReason := "order committed"
+			if status == ReservationRestocked {
+				stockReason = "order restocked"
+			}
+			if err := s.events.Record(ctx, event.ProductStockChanged, r.ProductID.Hex(), event.ProductStockChangedData{
+				ProductID:   r.ProductID.Hex(),
+				WarehouseID: r.WarehouseID.Hex(),
+				Delta:       delta,
+				Reason:      stockReason,
+				OrderID:     orderID.Hex(),
+			}); err != nil {
+				return err
+			}
+		}
+
+		set := bson.M{"status": status, "updatedAt": now}
+		if reason != "" {
+			set["releaseReason"] = reason
+		}
+		// Guard on the status so a concurrent settle of the same hold cannot apply twice.
+		res, err := s.reservationsCollection.UpdateOne(ctx,
+			bson.M{"_id": r.ID, "status": from},
+			bson.M{"$set": set},
+		)
+		if err != nil {
+			log.Printf("Error updating reservation %s: %v", r.ID.Hex(), err)
+			return errors.New("failed to update stock reservation")
+		}
+		if res.ModifiedCount == 0 {
+			return errors.New("stock reservation was settled concurrently")
+		}
+	}
+	return nil
+}
+
+// GetOrderReservations retrieves every reservation (in any status) made for an order.
+func (s *service) GetOrderReservations(ctx context.Context, orderID primitive.ObjectID) ([]Reservation, error) {
+	return s.findReservations(ctx, bson.M{"orderID": orderID})
+}
+
+// ExpiredOrderIDs returns the orders that still hold stock past their reservation expiry.
+func (s *service) ExpiredOrderIDs(ctx context.Context, now time.Time) ([]primitive.ObjectID, error) {
+	values, err := s.reservationsCollection.Distinct(ctx, "orderID", bson.M{
+		"status":    ReservationActive,
+		"expiresAt": bson.M{"$lte": now},
+	})
+	if err != nil {
+		log.Printf("Error finding expired reservations: %v", err)
+		return nil, errors.New("failed to find expired reservations")
+	}
+
+	orderIDs := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			orderIDs = append(orderIDs, id)
+		}
+	}
+	return orderIDs, nil
+}
+
+func (s *service) findReservations(ctx context.Context, filter bson.M) ([]Reservation, error) {
+	cursor, err := s.reservationsCollection.Find(ctx, filter)
+	if err != nil {
+		log.Printf("Error finding stock reservations: %v", err)
+		return nil, errors.New("failed to retrieve stock reservations")
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []Reservation
+	if err = cursor.All(ctx, &reservations); err != nil {
+		log.Printf("Error decoding stock reservations: %v", err)
+		return nil, errors.New("failed to process stock reservation data")
+	}
+	return reservations, nil
+}
+
+// AddBackorder counts quantity more units of a product as ordered without stock, as long as
+// the outstanding total stays within limit.
+func (s *service) AddBackorder(ctx context.Context, productID primitive.ObjectID, quantity, limit int) error {
+	filter := bson.M{"_id": productID}
+	if limit > 0 {
+		filter["$expr"] = bson.M{"$lte": bson.A{bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$backordered", 0}}, quantity}}, limit}}
+	}
+	res, err := s.productsCollection.UpdateOne(ctx, filter, bson.M{
+		"$inc": bson.M{"backordered": quantity, "version": 1},
+		"$set": bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		log.Printf("Error backordering product %s: %v", productID.Hex(), err)
+		return fmt.Errorf("failed to backorder product %s", productID.Hex())
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("insufficient stock for product %s (backorder limit reached)", productID.Hex())
+	}
+	return nil
+}
+
+// RemoveBackorder stops counting quantity units of a product as backordered.
+func (s *service) RemoveBackorder(ctx context.Context, productID primitive.ObjectID, quantity int) error {
+	if _, err := s.productsCollection.UpdateOne(ctx, bson.M{"_id": productID}, bson.M{
+		"$inc": bson.M{"backordered": -quantity, "version": 1},
+		"$set": bson.M{"updatedAt": time.Now()},
+	}); err != nil {
+		log.Printf("Error updating backorders of product %s: %v", productID.Hex(), err)
+		return fmt.Errorf("failed to update backorders of product %s", productID.Hex())
+	}
+	return nil
+}
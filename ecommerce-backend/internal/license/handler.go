@@ -0,0 +1,109 @@
+// internal/license/handler.go
+package license
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// LicenseHandler handles HTTP requests related to license key pools.
+type LicenseHandler struct {
+	Service   LicenseService
+	Validator *validator.Validate
+}
+
+// NewLicenseHandler creates a new LicenseHandler instance.
+func NewLicenseHandler(s LicenseService) *LicenseHandler {
+	return &LicenseHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// UploadKeys godoc
+// @Summary Add license keys to a product's pool (Admin only)
+// @Description Add a batch of license keys to a digital product. Each unit ordered claims one key; orders fail as out of stock once the pool is empty. Keys already in the pool are skipped.
+// @Tags License Keys
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   request body UploadKeysRequest true "License keys"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Keys added"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or product is not digital"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/license-keys [post]
+func (h *LicenseHandler) UploadKeys(c *gin.Context) {
+	var req UploadKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second) // Batches can hold thousands of keys
+	defer cancel()
+
+	result, err := h.Service.UploadKeys(ctx, c.Param("id"), req.Keys)
+	if err != nil {
+		switch {
+		case err.Error() == "product not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case err.Error() == "invalid product ID format" || err.Error() == "product is not digital" || strings.HasPrefix(err.Error(), "invalid keys"):
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "License keys added", "result": result})
+}
+
+// GetPoolSummary godoc
+// @Summary Get a product's license key pool (Admin only)
+// @Description Count a product's license keys by status: available, claimed by orders, and revoked after refunds
+// @Tags License Keys
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Key counts"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/license-keys [get]
+func (h *LicenseHandler) GetPoolSummary(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	summary, err := h.Service.GetPoolSummary(ctx, c.Param("id"))
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid product ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"pool": summary})
+}
@@ -0,0 +1,58 @@
+// internal/license/model.go
+package license
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Key statuses.
+const (
+	StatusAvailable = "available" // In the pool, waiting to be sold
+	StatusClaimed   = "claimed"   // Sold with an order
+	StatusRevoked   = "revoked"   // Taken back when its order was refunded; never sold again
+)
+
+// MaxKeysPerUpload caps how many keys one upload may add to a pool.
+const MaxKeysPerUpload = 10000
+
+// Key is one license key bought for a software product. Keys are claimed from the pool one per
+// unit ordered, inside the order's transaction.
+type Key struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ProductID primitive.ObjectID  `bson:"productID" json:"productId"`
+	Key       string              `bson:"key" json:"key"`
+	Status    string              `bson:"status" json:"status"`
+	OrderID   *primitive.ObjectID `bson:"orderID,omitempty" json:"orderId,omitempty"` // Set while claimed or revoked
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+	ClaimedAt *time.Time          `bson:"claimedAt,omitempty" json:"claimedAt,omitempty"`
+	RevokedAt *time.Time          `bson:"revokedAt,omitempty" json:"revokedAt,omitempty"`
+}
+
+// PoolSummary counts a product's keys by status.
+type PoolSummary struct {
+	ProductID string `json:"productId"`
+	Available int    `json:"available"`
+	Claimed   int    `json:"claimed"`
+	Revoked   int    `json:"revoked"`
+}
+
+// UploadResult reports what an upload added to a pool.
+type UploadResult struct {
+	Added      int         `json:"added"`
+	Duplicates int         `json:"duplicates"` // Keys already in the pool or repeated in the upload; skipped
+	Pool       PoolSummary `json:"pool"`
+}
+
+// UploadKeysRequest defines the structure for adding keys to a product's pool.
+type UploadKeysRequest struct {
+	Keys []string `json:"keys" validate:"required,min=1,max=10000,dive,required,max=200"`
+}
+
+// productDoc is the part of a product document the license service needs.
+type productDoc struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Digital  bool               `bson:"digital"`
+	GiftCard bool               `bson:"giftCard"`
+}
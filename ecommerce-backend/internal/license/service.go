@@ -0,0 +1,230 @@
+// internal/license/service.go
+package license
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// ErrPoolEmpty is returned by Claim when a product has fewer available keys than requested.
+var ErrPoolEmpty = errors.New("license key pool is empty")
+
+// LicenseService defines the interface for license key pools.
+type LicenseService interface {
+	UploadKeys(ctx context.Context, productID string, keys []string) (*UploadResult, error) // Admin only
+	GetPoolSummary(ctx context.Context, productID string) (*PoolSummary, error)             // Admin only
+
+	// Claim, Release and Revoke are called by the order service with its transaction's
+	// session context, so they commit or roll back together with the order.
+	Claim(ctx context.Context, productID, orderID primitive.ObjectID, quantity int) ([]string, error)
+	Release(ctx context.Context, orderID primitive.ObjectID) error // Returns an unpaid order's keys to the pool
+	Revoke(ctx context.Context, orderID primitive.ObjectID) error  // Takes back a refunded order's keys for good
+}
+
+// service implements LicenseService.
+type service struct {
+	keysCollection     *mongo.Collection
+	productsCollection *mongo.Collection
+}
+
+// NewLicenseService creates a new license service.
+func NewLicenseService() LicenseService {
+	return &service{
+		keysCollection:     database.GetCollection("license_keys"),
+		productsCollection: database.GetCollection("products"),
+	}
+}
+
+// UploadKeys adds keys to a digital product's pool and marks the product as selling one key
+// per unit. Keys already in the pool are skipped, so re-uploading a batch is harmless.
+func (s *service) UploadKeys(ctx context.Context, productID string, keys []string) (*UploadResult, error) {
+	objID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+	if len(keys) > MaxKeysPerUpload {
+		return nil, errors.New("invalid keys: too many keys in one upload")
+	}
+
+	var p productDoc
+	if err := s.productsCollection.FindOne(ctx, bson.M{"_id": objID, "deletedAt": nil}).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("product not found")
+		}
+		log.Printf("Error finding product for license key upload: %v", err)
+		return nil, errors.New("database error retrieving product")
+	}
+	if !p.Digital {
+		return nil, errors.New("product is not digital")
+	}
+	if p.GiftCard {
+		return nil, errors.New("invalid keys: gift cards cannot have license keys")
+	}
+
+	now := time.Now()
+	result := &UploadResult{}
+	seen := make(map[string]bool, len(keys))
+	docs := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			return nil, errors.New("invalid keys: keys cannot be blank")
+		}
+		if seen[k] {
+			result.Duplicates++
+			continue
+		}
+		seen[k] = true
+		docs = append(docs, Key{ProductID: objID, Key: k, Status: StatusAvailable, CreatedAt: now})
+	}
+
+	// Unordered, so keys already in the pool fail individually on the unique index
+	// without stopping the rest of the batch.
+	_, err = s.keysCollection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	duplicates := 0
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+			log.Printf("Error inserting license keys: %v", err)
+			return nil, errors.New("failed to add license keys")
+		}
+		for _, we := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(we) {
+				log.Printf("Error inserting license keys: %v", err)
+				return nil, errors.New("failed to add license keys")
+			}
+			duplicates++
+		}
+	}
+	result.Added = len(docs) - duplicates
+	result.Duplicates += duplicates
+
+	_, err = s.productsCollection.UpdateOne(ctx,
+		bson.M{"_id": objID, "licenseKeys": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"licenseKeys": true, "updatedAt": now}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		log.Printf("Error marking product %s as license-keyed: %v", productID, err)
+		return nil, errors.New("failed to add license keys")
+	}
+
+	summary, err := s.summarize(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	result.Pool = *summary
+	return result, nil
+}
+
+// GetPoolSummary counts a product's keys by status.
+func (s *service) GetPoolSummary(ctx context.Context, productID string) (*PoolSummary, error) {
+	objID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+	count, err := s.productsCollection.CountDocuments(ctx, bson.M{"_id": objID})
+	if err != nil {
+		log.Printf("Error finding product for license key summary: %v", err)
+		return nil, errors.New("database error retrieving product")
+	}
+	if count == 0 {
+		return nil, errors.New("product not found")
+	}
+	return s.summarize(ctx, objID)
+}
+
+// summarize counts the keys of productID by status.
+func (s *service) summarize(ctx context.Context, productID primitive.ObjectID) (*PoolSummary, error) {
+	cursor, err := s.keysCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"productID": productID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		log.Printf("Error counting license keys: %v", err)
+		return nil, errors.New("failed to retrieve license key pool")
+	}
+	defer cursor.Close(ctx)
+
+	var counts []struct {
+		Status string `bson:"_id"`
+		Count  int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		log.Printf("Error decoding license key counts: %v", err)
+		return nil, errors.New("failed to retrieve license key pool")
+	}
+
+	summary := &PoolSummary{ProductID: productID.Hex()}
+	for _, c := range counts {
+		switch c.Status {
+		case StatusAvailable:
+			summary.Available = c.Count
+		case StatusClaimed:
+			summary.Claimed = c.Count
+		case StatusRevoked:
+			summary.Revoked = c.Count
+		}
+	}
+	return summary, nil
+}
+
+// Claim takes quantity keys from the product's pool for orderID, oldest first. It returns
+// ErrPoolEmpty when the pool runs out part way; the caller aborts its transaction, which puts
+// back the keys claimed so far.
+func (s *service) Claim(ctx context.Context, productID, orderID primitive.ObjectID, quantity int) ([]string, error) {
+	now := time.Now()
+	keys := make([]string, 0, quantity)
+	for i := 0; i < quantity; i++ {
+		var k Key
+		err := s.keysCollection.FindOneAndUpdate(ctx,
+			bson.M{"productID": productID, "status": StatusAvailable},
+			bson.M{"$set": bson.M{"status": StatusClaimed, "orderID": orderID, "claimedAt": now}},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "_id", Value: 1}}),
+		).Decode(&k)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, ErrPoolEmpty
+			}
+			log.Printf("Error claiming license key for product %s: %v", productID.Hex(), err)
+			return nil, errors.New("failed to claim license key")
+		}
+		keys = append(keys, k.Key)
+	}
+	return keys, nil
+}
+
+// Release puts the keys claimed by orderID back in their pools.
+func (s *service) Release(ctx context.Context, orderID primitive.ObjectID) error {
+	_, err := s.keysCollection.UpdateMany(ctx,
+		bson.M{"orderID": orderID, "status": StatusClaimed},
+		bson.M{"$set": bson.M{"status": StatusAvailable}, "$unset": bson.M{"orderID": "", "claimedAt": ""}},
+	)
+	if err != nil {
+		log.Printf("Error releasing license keys of order %s: %v", orderID.Hex(), err)
+		return errors.New("failed to release license keys")
+	}
+	return nil
+}
+
+// Revoke marks the keys claimed by orderID as revoked. Revoked keys stay out of the pool.
+func (s *service) Revoke(ctx context.Context, orderID primitive.ObjectID) error {
+	_, err := s.keysCollection.UpdateMany(ctx,
+		bson.M{"orderID": orderID, "status": StatusClaimed},
+		bson.M{"$set": bson.M{"status": StatusRevoked, "revokedAt": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Error revoking license keys of order %s: %v", orderID.Hex(), err)
+		return errors.New("failed to revoke license keys")
+	}
+	return nil
+}
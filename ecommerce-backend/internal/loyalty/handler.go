@@ -0,0 +1,48 @@
+// internal/loyalty/handler.go
+package loyalty
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// LoyaltyHandler handles HTTP requests related to loyalty points.
+type LoyaltyHandler struct {
+	Service LoyaltyService
+}
+
+// NewLoyaltyHandler creates a new LoyaltyHandler instance.
+func NewLoyaltyHandler(s LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{Service: s}
+}
+
+// GetMyPoints godoc
+// @Summary Get my loyalty points
+// @Description Retrieve the authenticated user's points balance, what it is worth, the next points to expire and the latest ledger entries
+// @Tags Loyalty
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Balance and ledger"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/points [get]
+func (h *LoyaltyHandler) GetMyPoints(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	statement, err := h.Service.GetStatement(ctx, c.GetString("userID"))
+	if err != nil {
+		if err.Error() == "invalid user ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"points": statement})
+}
@@ -0,0 +1,72 @@
+// internal/loyalty/model.go
+package loyalty
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// Ledger entry types.
+const (
+	EntryEarn    = "earn"    // Earned on a delivered order
+	EntryRedeem  = "redeem"  // Spent as a discount on an order
+	EntryRestore = "restore" // Given back after the order they were spent on was cancelled or returned
+	EntryReverse = "reverse" // Taken back after the order they were earned on was returned or refunded
+	EntryExpire  = "expire"  // Not spent in time
+)
+
+// statementLimit is how many ledger entries a statement shows.
+const statementLimit = 100
+
+// Account holds a user's points balance. It always equals the remaining points of their
+// unexpired and expired-but-not-yet-swept earn entries.
+type Account struct {
+	UserID    primitive.ObjectID `bson:"_id"`
+	Balance   int64              `bson:"balance"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+// Entry is one change to a user's points. Earn entries are also the lots points are spent
+// from, soonest to expire first.
+type Entry struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID  `bson:"userID" json:"userId"`
+	Type      string              `bson:"type" json:"type"`     // EntryEarn, EntryRedeem, EntryRestore, EntryReverse or EntryExpire
+	Points    int64               `bson:"points" json:"points"` // Negative when points are spent, taken back or expire
+	OrderID   *primitive.ObjectID `bson:"orderID,omitempty" json:"orderId,omitempty"`
+	Remaining int64               `bson:"remaining,omitempty" json:"remaining,omitempty"` // Earn entries: points not yet spent, taken back or expired
+	ExpiresAt *time.Time          `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"` // Earn entries only
+	Lots      []LotUse            `bson:"lots,omitempty" json:"-"`                        // Earn entries a redemption or reversal took points from
+	Reversed  bool                `bson:"reversed,omitempty" json:"reversed,omitempty"`   // Set on earn and redeem entries once their order was reversed
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// LotUse is how many points were taken from one earn entry.
+type LotUse struct {
+	EntryID primitive.ObjectID `bson:"entryID"`
+	Points  int64              `bson:"points"`
+}
+
+// Line is an order line points are earned on.
+type Line struct {
+	CategoryID primitive.ObjectID
+	Amount     money.Money // What the customer paid for the line, in the base currency
+}
+
+// Statement is a user's balance with their latest ledger entries, newest first.
+type Statement struct {
+	UserID     string      `json:"userId"`
+	Balance    int64       `json:"balance"`
+	Value      money.Money `json:"value"`                // What the balance takes off an order
+	NextExpiry *Expiry     `json:"nextExpiry,omitempty"` // The next points to expire, if any
+	Entries    []Entry     `json:"entries"`
+}
+
+// Expiry is a number of points that expire at the same time.
+type Expiry struct {
+	Points int64     `json:"points"`
+	At     time.Time `json:"at"`
+}
@@ -0,0 +1,396 @@
+// internal/loyalty/service.go
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// LoyaltyService defines the interface for loyalty points.
+type LoyaltyService interface {
+	GetStatement(ctx context.Context, userID string) (*Statement, error)
+	ExpirePoints(ctx context.Context) (int, error)
+	StartExpiry(ctx context.Context, interval time.Duration) // Runs ExpirePoints until ctx is cancelled
+
+	// Value returns what a number of points takes off an order.
+	Value(points int64) money.Money
+	// PointsFor returns the points earned on lines, after the earn rate and category multipliers.
+	PointsFor(lines []Line) int64
+
+	// The methods below are called by the order service with its transaction's session
+	// context, so they commit or roll back together with the order.
+
+	// Redeem spends points on orderID, soonest to expire first.
+	Redeem(ctx context.Context, userID, orderID primitive.ObjectID, points int64) error
+	// Earn credits points for orderID, expiring after LOYALTY_POINTS_VALIDITY.
+	Earn(ctx context.Context, userID, orderID primitive.ObjectID, points int64) error
+	// ReverseForOrder gives back the points spent on orderID and takes back the points earned
+	// on it, if not done yet.
+	ReverseForOrder(ctx context.Context, orderID primitive.ObjectID) error
+}
+
+// service implements LoyaltyService.
+type service struct {
+	accountsCollection *mongo.Collection
+	entriesCollection  *mongo.Collection
+	earnRate           float64
+	multipliers        map[string]float64 // By category ID
+	pointValue         money.Money
+	validity           time.Duration
+}
+
+// NewLoyaltyService creates a new loyalty service.
+func NewLoyaltyService(cfg *config.Config) LoyaltyService {
+	return &service{
+		accountsCollection: database.GetCollection("loyalty_accounts"),
+		entriesCollection:  database.GetCollection("loyalty_entries"),
+		earnRate:           cfg.LoyaltyEarnRate,
+		multipliers:        cfg.LoyaltyCategoryMultipliers,
+		pointValue:         money.FromMajor(cfg.LoyaltyPointValue, cfg.BaseCurrency),
+		validity:           cfg.LoyaltyPointsValidity,
+	}
+}
+
+// Value returns what points take off an order.
+func (s *service) Value(points int64) money.Money {
+	return s.pointValue.Mul(int(points))
+}
+
+// PointsFor returns the points earned on lines, rounded down.
+func (s *service) PointsFor(lines []Line) int64 {
+	var points float64
+	for _, line := range lines {
+		multiplier, ok := s.multipliers[line.CategoryID.Hex()]
+		if !ok {
+			multiplier = 1
+		}
+		major := float64(line.Amount.Amount) / math.Pow10(money.Exponent(line.Amount.Currency))
+		points += major * s.earnRate * multiplier
+	}
+	return int64(math.Floor(points))
+}
+
+// balance returns the user's current points; users without an account have none.
+func (s *service) balance(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	var account Account
+	err := s.accountsCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&account)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		log.Printf("Error finding loyalty account: %v", err)
+		return 0, errors.New("database error retrieving loyalty points")
+	}
+	return account.Balance, nil
+}
+
+// change moves the user's balance by entry.Points and records the entry in the ledger.
+// Decreases only apply when the balance covers them.
+func (s *service) change(ctx context.Context, userID primitive.ObjectID, entry Entry) error {
+	filter := bson.M{"_id": userID}
+	opts := options.Update()
+	if entry.Points < 0 {
+		filter["balance"] = bson.M{"$gte": -entry.Points}
+	} else {
+		opts.SetUpsert(true)
+	}
+	res, err := s.accountsCollection.UpdateOne(ctx, filter, bson.M{
+		"$inc": bson.M{"balance": entry.Points},
+		"$set": bson.M{"updatedAt": entry.CreatedAt},
+	}, opts)
+	if err != nil {
+		log.Printf("Error updating loyalty points of user %s: %v", userID.Hex(), err)
+		return errors.New("failed to update loyalty points")
+	}
+	if res.MatchedCount == 0 && res.UpsertedCount == 0 {
+		return errors.New("insufficient loyalty points")
+	}
+
+	entry.UserID = userID
+	if _, err := s.entriesCollection.InsertOne(ctx, entry); err != nil {
+		log.Printf("Error recording loyalty entry: %v", err)
+		return errors.New("failed to update loyalty points")
+	}
+	return nil
+}
+
+// take removes points from the user's earn entries, starting with first if set and then
+// soonest to expire, and returns what it took from each.
+func (s *service) take(ctx context.Context, userID primitive.ObjectID, points int64, first *primitive.ObjectID) ([]LotUse, error) {
+	var lots []Entry
+	if first != nil {
+		var lot Entry
+		err := s.entriesCollection.FindOne(ctx, bson.M{"_id": *first, "remaining": bson.M{"$gt": 0}}).Decode(&lot)
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Printf("Error finding loyalty lot %s: %v", first.Hex(), err)
+			return nil, errors.New("failed to update loyalty points")
+		}
+		if err == nil {
+			lots = append(lots, lot)
+		}
+	}
+	cursor, err := s.entriesCollection.Find(ctx,
+		bson.M{"userID": userID, "type": EntryEarn, "remaining": bson.M{"$gt": 0}},
+		options.Find().SetSort(bson.D{{Key: "expiresAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding loyalty lots of user %s: %v", userID.Hex(), err)
+		return nil, errors.New("failed to update loyalty points")
+	}
+	var rest []Entry
+	if err := cursor.All(ctx, &rest); err != nil {
+		log.Printf("Error decoding loyalty lots: %v", err)
+		return nil, errors.New("failed to update loyalty points")
+	}
+	for _, lot := range rest {
+		if first == nil || lot.ID != *first {
+			lots = append(lots, lot)
+		}
+	}
+
+	var used []LotUse
+	for _, lot := range lots {
+		if points == 0 {
+			break
+		}
+		n := min(points, lot.Remaining)
+		res, err := s.entriesCollection.UpdateOne(ctx,
+			bson.M{"_id": lot.ID, "remaining": bson.M{"$gte": n}},
+			bson.M{"$inc": bson.M{"remaining": -n}})
+		if err != nil {
+			log.Printf("Error taking points from loyalty lot %s: %v", lot.ID.Hex(), err)
+			return nil, errors.New("failed to update loyalty points")
+		}
+		if res.ModifiedCount == 0 {
+			return nil, errors.New("loyalty points were used at the same time, try again")
+		}
+		used = append(used, LotUse{EntryID: lot.ID, Points: n})
+		points -= n
+	}
+	if points > 0 {
+		return nil, errors.New("insufficient loyalty points")
+	}
+	return used, nil
+}
+
+// GetStatement returns the user's balance, their next expiry and latest ledger entries.
+func (s *service) GetStatement(ctx context.Context, userID string) (*Statement, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	balance, err := s.balance(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.entriesCollection.Find(ctx, bson.M{"userID": userObjID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(statementLimit))
+	if err != nil {
+		log.Printf("Error finding loyalty entries: %v", err)
+		return nil, errors.New("failed to retrieve loyalty points")
+	}
+	defer cursor.Close(ctx)
+
+	entries := []Entry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		log.Printf("Error decoding loyalty entries: %v", err)
+		return nil, errors.New("failed to process loyalty points data")
+	}
+
+	statement := &Statement{UserID: userID, Balance: balance, Value: s.Value(balance), Entries: entries}
+	var next Entry
+	err = s.entriesCollection.FindOne(ctx,
+		bson.M{"userID": userObjID, "type": EntryEarn, "remaining": bson.M{"$gt": 0}},
+		options.FindOne().SetSort(bson.D{{Key: "expiresAt", Value: 1}})).Decode(&next)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error finding next loyalty expiry: %v", err)
+		return nil, errors.New("failed to retrieve loyalty points")
+	}
+	if err == nil && next.ExpiresAt != nil {
+		statement.NextExpiry = &Expiry{Points: next.Remaining, At: *next.ExpiresAt}
+	}
+	return statement, nil
+}
+
+// Redeem spends points on an order, soonest to expire first.
+func (s *service) Redeem(ctx context.Context, userID, orderID primitive.ObjectID, points int64) error {
+	balance, err := s.balance(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if balance < points {
+		return fmt.Errorf("insufficient loyalty points. Available: %d, Requested: %d", balance, points)
+	}
+
+	lots, err := s.take(ctx, userID, points, nil)
+	if err != nil {
+		return err
+	}
+	return s.change(ctx, userID, Entry{
+		Type:      EntryRedeem,
+		Points:    -points,
+		OrderID:   &orderID,
+		Lots:      lots,
+		CreatedAt: time.Now(),
+	})
+}
+
+// Earn credits points for an order.
+func (s *service) Earn(ctx context.Context, userID, orderID primitive.ObjectID, points int64) error {
+	if points <= 0 {
+		return nil
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.validity)
+	return s.change(ctx, userID, Entry{
+		Type:      EntryEarn,
+		Points:    points,
+		OrderID:   &orderID,
+		Remaining: points,
+		ExpiresAt: &expiresAt,
+		CreatedAt: now,
+	})
+}
+
+// ReverseForOrder first gives back the points spent on an order, to the lots they came from,
+// then takes back the points earned on it. Earned points that were already spent are taken
+// from the rest of the balance, as far as it goes.
+func (s *service) ReverseForOrder(ctx context.Context, orderID primitive.ObjectID) error {
+	cursor, err := s.entriesCollection.Find(ctx,
+		bson.M{"orderID": orderID, "type": bson.M{"$in": bson.A{EntryRedeem, EntryEarn}}, "reversed": bson.M{"$ne": true}},
+		options.Find().SetSort(bson.D{{Key: "type", Value: -1}})) // "redeem" before "earn"
+	if err != nil {
+		log.Printf("Error finding loyalty entries of order %s: %v", orderID.Hex(), err)
+		return errors.New("failed to reverse loyalty points")
+	}
+	var entries []Entry
+	if err := cursor.All(ctx, &entries); err != nil {
+		log.Printf("Error decoding loyalty entries: %v", err)
+		return errors.New("failed to reverse loyalty points")
+	}
+
+	for _, e := range entries {
+		if _, err := s.entriesCollection.UpdateOne(ctx, bson.M{"_id": e.ID}, bson.M{"$set": bson.M{"reversed": true}}); err != nil {
+			log.Printf("Error marking loyalty entry %s reversed: %v", e.ID.Hex(), err)
+			return errors.New("failed to reverse loyalty points")
+		}
+
+		if e.Type == EntryRedeem {
+			// Points restored to a lot that has expired meanwhile expire on the next run.
+			for _, lot := range e.Lots {
+				if _, err := s.entriesCollection.UpdateOne(ctx, bson.M{"_id": lot.EntryID}, bson.M{"$inc": bson.M{"remaining": lot.Points}}); err != nil {
+					log.Printf("Error restoring loyalty lot %s: %v", lot.EntryID.Hex(), err)
+					return errors.New("failed to reverse loyalty points")
+				}
+			}
+			err := s.change(ctx, e.UserID, Entry{Type: EntryRestore, Points: -e.Points, OrderID: &orderID, Lots: e.Lots, CreatedAt: time.Now()})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		balance, err := s.balance(ctx, e.UserID)
+		if err != nil {
+			return err
+		}
+		points := min(e.Points, balance)
+		if points <= 0 {
+			continue
+		}
+		lots, err := s.take(ctx, e.UserID, points, &e.ID)
+		if err != nil {
+			return err
+		}
+		err = s.change(ctx, e.UserID, Entry{Type: EntryReverse, Points: -points, OrderID: &orderID, Lots: lots, CreatedAt: time.Now()})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpirePoints takes every earn entry's remaining points off its balance once the entry has
+// expired. It returns the number of entries expired.
+func (s *service) ExpirePoints(ctx context.Context) (int, error) {
+	cursor, err := s.entriesCollection.Find(ctx, bson.M{"type": EntryEarn, "remaining": bson.M{"$gt": 0}, "expiresAt": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		log.Printf("Error finding expired loyalty points: %v", err)
+		return 0, errors.New("failed to retrieve loyalty points")
+	}
+	var lots []Entry
+	if err := cursor.All(ctx, &lots); err != nil {
+		log.Printf("Error decoding expired loyalty points: %v", err)
+		return 0, errors.New("failed to process loyalty points data")
+	}
+
+	expired := 0
+	for _, lot := range lots {
+		changed := false
+		err := database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+			// The remaining points may have been spent or restored since they were read.
+			var current Entry
+			if err := s.entriesCollection.FindOne(sessionContext, bson.M{"_id": lot.ID}).Decode(&current); err != nil {
+				return err
+			}
+			if current.Remaining <= 0 {
+				return nil
+			}
+			if _, err := s.entriesCollection.UpdateOne(sessionContext, bson.M{"_id": lot.ID}, bson.M{"$set": bson.M{"remaining": 0}}); err != nil {
+				return err
+			}
+			changed = true
+			return s.change(sessionContext, lot.UserID, Entry{
+				Type:      EntryExpire,
+				Points:    -current.Remaining,
+				Lots:      []LotUse{{EntryID: lot.ID, Points: current.Remaining}},
+				CreatedAt: time.Now(),
+			})
+		})
+		if err != nil {
+			// Usually a concurrent checkout; the next run picks it up.
+			log.Printf("Skipping expiry of loyalty points %s: %v", lot.ID.Hex(), err)
+			continue
+		}
+		if changed {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// StartExpiry runs ExpirePoints on every tick of interval, in a background goroutine.
+func (s *service) StartExpiry(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			runCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			if n, err := s.ExpirePoints(runCtx); err != nil {
+				log.Printf("Loyalty points expiry failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Expired the loyalty points of %d earn entries", n)
+			}
+			cancel()
+		}
+	}()
+}
@@ -39,11 +39,28 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		// Store user information in Gin's context for later use by handlers
 		c.Set("userID", claims.UserID)
 		c.Set("userRole", claims.Role) // This will be used for authorization
+		c.Set("userSegment", claims.Segment)
 
 		c.Next() // Proceed to the next handler/middleware in the chain
 	}
 }
 
+// OptionalAuth identifies the user on public routes when a valid token is sent, so responses
+// can be personalised (e.g. segment prices). Missing or invalid tokens are treated as anonymous.
+func OptionalAuth(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := utils.ValidateJWT(parts[1], cfg.JWTSecret); err == nil {
+				c.Set("userID", claims.UserID)
+				c.Set("userRole", claims.Role)
+				c.Set("userSegment", claims.Segment)
+			}
+		}
+		c.Next()
+	}
+}
+
 // AuthorizeRole checks if the authenticated user has the required role.
 func AuthorizeRole(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,30 @@
+// internal/migration/backorder.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// backorderIndexes finds the products with units waiting for stock and their orders, oldest
+// first. Both only cover documents with something backordered.
+func backorderIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("products").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "backordered", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"backordered": bson.M{"$gt": 0}}),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = database.GetCollection("orders").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "items.productID", Value: 1}, {Key: "createdAt", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"items.backordered": bson.M{"$gt": 0}}),
+	})
+	return err
+}
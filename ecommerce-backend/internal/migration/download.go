@@ -0,0 +1,23 @@
+// internal/migration/download.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// downloadGrantIndexes allows one download grant per digital item of an order, so concurrent
+// requests for download links cannot create two.
+func downloadGrantIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("download_grants").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orderID", Value: 1}, {Key: "productID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
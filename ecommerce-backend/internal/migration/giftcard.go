@@ -0,0 +1,40 @@
+// internal/migration/giftcard.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// giftCardIndexes keeps gift card codes unique and finds the gift card and store credit
+// entries of an order when it is cancelled or refunded. Store credit statements are listed
+// newest first per user.
+func giftCardIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("gift_cards").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "orderID", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = database.GetCollection("gift_card_transactions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orderID", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = database.GetCollection("store_credit_entries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "orderID", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,23 @@
+// internal/migration/guest.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// guestOrderIndexes finds the guest orders placed with an email address when its owner
+// verifies it. Only guest orders are indexed.
+func guestOrderIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("orders").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "guestEmail", Value: 1}, {Key: "userID", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"guestEmail": bson.M{"$exists": true}}),
+	})
+	return err
+}
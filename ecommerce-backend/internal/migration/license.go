@@ -0,0 +1,27 @@
+// internal/migration/license.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// licenseKeyIndexes keeps each key once per product pool, serves claims of the oldest available
+// key, and finds the keys of an order when it is cancelled or refunded.
+func licenseKeyIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("license_keys").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "productID", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "productID", Value: 1}, {Key: "status", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "orderID", Value: 1}}},
+	})
+	return err
+}
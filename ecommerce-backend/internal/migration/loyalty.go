@@ -0,0 +1,26 @@
+// internal/migration/loyalty.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// loyaltyIndexes serves the points ledger newest first, finds an order's entries when it is
+// reversed, and finds unspent earned points by expiry, per user and for the expiry job.
+func loyaltyIndexes(ctx context.Context, _ *config.Config) error {
+	unspent := options.Index().SetPartialFilterExpression(bson.M{"remaining": bson.M{"$gt": 0}})
+	_, err := database.GetCollection("loyalty_entries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "orderID", Value: 1}}},
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "expiresAt", Value: 1}}, Options: unspent},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: unspent},
+	})
+	return err
+}
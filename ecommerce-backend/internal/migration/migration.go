@@ -0,0 +1,74 @@
+// internal/migration/migration.go
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// Migration is a one-off, idempotent change to existing documents.
+type Migration struct {
+	Name string
+	Run  func(ctx context.Context, cfg *config.Config) error
+}
+
+// migrations are applied in order. Append new ones at the end and never rename old ones:
+// the name is what marks a migration as applied.
+var migrations = []Migration{
+	{Name: "0001_money_minor_units", Run: moneyMinorUnits},
+	{Name: "0002_warehouse_stock_levels", Run: warehouseStockLevels},
+	{Name: "0003_product_status", Run: productStatus},
+	{Name: "0004_wishlist_indexes", Run: wishlistIndexes},
+	{Name: "0005_download_grant_indexes", Run: downloadGrantIndexes},
+	{Name: "0006_license_key_indexes", Run: licenseKeyIndexes},
+	{Name: "0007_subscription_indexes", Run: subscriptionIndexes},
+	{Name: "0008_gift_card_indexes", Run: giftCardIndexes},
+	{Name: "0009_loyalty_indexes", Run: loyaltyIndexes},
+	{Name: "0010_backorder_indexes", Run: backorderIndexes},
+	{Name: "0011_guest_order_indexes", Run: guestOrderIndexes},
+	{Name: "0012_order_template_indexes", Run: orderTemplateIndexes},
+	{Name: "0013_outbox_indexes", Run: outboxIndexes},
+	{Name: "0014_webhook_delivery_indexes", Run: webhookDeliveryIndexes},
+	{Name: "0015_outbox_subject_index", Run: outboxSubjectIndex},
+}
+
+// appliedMigration records a migration in the "migrations" collection.
+type appliedMigration struct {
+	Name      string    `bson:"_id"`
+	AppliedAt time.Time `bson:"appliedAt"`
+}
+
+// Run applies every migration that has not been recorded yet.
+func Run(ctx context.Context, cfg *config.Config) error {
+	applied := database.GetCollection("migrations")
+
+	for _, m := range migrations {
+		err := applied.FindOne(ctx, bson.M{"_id": m.Name}).Err()
+		if err == nil {
+			continue // Already applied
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("checking migration %s: %w", m.Name, err)
+		}
+
+		log.Printf("Applying migration %s...", m.Name)
+		started := time.Now()
+		if err := m.Run(ctx, cfg); err != nil {
+			return fmt.Errorf("migration %s failed: %w", m.Name, err)
+		}
+		if _, err := applied.InsertOne(ctx, appliedMigration{Name: m.Name, AppliedAt: time.Now()}); err != nil {
+			return fmt.Errorf("recording migration %s: %w", m.Name, err)
+		}
+		log.Printf("Migration %s applied in %s", m.Name, time.Since(started).Round(time.Millisecond))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// internal/migration/money.go
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// moneyMinorUnits converts float prices and totals into money documents
+// ({amount, currency}) in the store base currency.
+//
+// Order subtotals and totals are recomputed from the converted unit prices rather than
+// converted themselves, which also repairs totals like 59.970000000000006.
+func moneyMinorUnits(ctx context.Context, cfg *config.Config) error {
+	base := cfg.BaseCurrency
+
+	products := database.GetCollection("products")
+	cursor, err := products.Find(ctx, bson.M{"price": bson.M{"$type": "number"}})
+	if err != nil {
+		return err
+	}
+	var legacyProducts []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Price float64            `bson:"price"`
+	}
+	if err := cursor.All(ctx, &legacyProducts); err != nil {
+		return err
+	}
+	for _, p := range legacyProducts {
+		_, err := products.UpdateOne(ctx,
+			bson.M{"_id": p.ID, "price": bson.M{"$type": "number"}},
+			bson.M{"$set": bson.M{"price": money.FromMajor(p.Price, base)}},
+		)
+		if err != nil {
+			return fmt.Errorf("product %s: %w", p.ID.Hex(), err)
+		}
+	}
+
+	orders := database.GetCollection("orders")
+	cursor, err = orders.Find(ctx, bson.M{"totalAmount": bson.M{"$type": "number"}})
+	if err != nil {
+		return err
+	}
+	var legacyOrders []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Items []struct {
+			Price    float64 `bson:"price"`
+			Quantity int     `bson:"quantity"`
+		} `bson:"items"`
+	}
+	if err := cursor.All(ctx, &legacyOrders); err != nil {
+		return err
+	}
+	for _, o := range legacyOrders {
+		set := bson.M{}
+		total := money.Zero(base)
+		for i, item := range o.Items {
+			price := money.FromMajor(item.Price, base)
+			subtotal := price.Mul(item.Quantity)
+			total = total.Add(subtotal)
+			prefix := "items." + strconv.Itoa(i) + "."
+			set[prefix+"price"] = price
+			set[prefix+"subtotal"] = subtotal
+		}
+		set["totalAmount"] = total
+
+		_, err := orders.UpdateOne(ctx,
+			bson.M{"_id": o.ID, "totalAmount": bson.M{"$type": "number"}},
+			bson.M{"$set": set},
+		)
+		if err != nil {
+			return fmt.Errorf("order %s: %w", o.ID.Hex(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// internal/migration/outbox.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// outboxDeliveredRetention is how long delivered events stay in the outbox. Failed events are
+// kept until someone removes them.
+const outboxDeliveredRetention = 7 * 24 * 60 * 60 // seconds
+
+// outboxIndexes finds due events for the dispatcher and expires delivered ones.
+func outboxIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("outbox").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "completedAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(outboxDeliveredRetention).
+				SetPartialFilterExpression(bson.M{"status": "delivered"}),
+		},
+	})
+	return err
+}
+
+// outboxSubjectIndex finds the events of one order when an order stream resumes.
+func outboxSubjectIndex(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("outbox").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "subjectID", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	return err
+}
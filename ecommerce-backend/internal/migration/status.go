@@ -0,0 +1,31 @@
+// internal/migration/status.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// productStatus marks every product created before the publishing workflow as published,
+// so the catalog looks the same as before, and indexes the public listing query.
+func productStatus(ctx context.Context, _ *config.Config) error {
+	products := database.GetCollection("products")
+	_, err := products.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status": "published"}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Supports both the public listing and the scheduled publisher's lookup of due drafts.
+	_, err = products.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "publishAt", Value: 1}},
+	})
+	return err
+}
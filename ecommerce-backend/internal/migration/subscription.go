@@ -0,0 +1,23 @@
+// internal/migration/subscription.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// subscriptionIndexes indexes subscriptions by owner and serves the scheduler's search for
+// due renewals and payment retries.
+func subscriptionIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("subscriptions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextRunAt", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "retryAt", Value: 1}}},
+	})
+	return err
+}
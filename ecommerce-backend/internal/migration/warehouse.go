@@ -0,0 +1,83 @@
+// internal/migration/warehouse.go
+package migration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// warehouseStockLevels creates the default warehouse and moves every product's single
+// stock figure (and its reserved units) into a stock level there. Active reservations made
+// before warehouses existed are attributed to the default warehouse too.
+func warehouseStockLevels(ctx context.Context, cfg *config.Config) error {
+	warehouses := database.GetCollection("warehouses")
+	now := time.Now()
+	err := warehouses.FindOneAndUpdate(ctx,
+		bson.M{"code": cfg.DefaultWarehouse},
+		bson.M{"$setOnInsert": bson.M{
+			"code":      cfg.DefaultWarehouse,
+			"name":      "Main warehouse",
+			"region":    "default",
+			"priority":  0,
+			"createdAt": now,
+			"updatedAt": now,
+		}},
+		options.FindOneAndUpdate().SetUpsert(true),
+	).Err()
+	if err != nil && err != mongo.ErrNoDocuments { // No document is returned when the upsert inserts
+		return err
+	}
+	var main struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := warehouses.FindOne(ctx, bson.M{"code": cfg.DefaultWarehouse}).Decode(&main); err != nil {
+		return err
+	}
+
+	_, err = database.GetCollection("stock_levels").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "warehouseID", Value: 1}, {Key: "productID", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	cursor, err := database.GetCollection("products").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var products []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Stock    int                `bson:"stock"`
+		Reserved int                `bson:"reserved"`
+	}
+	if err := cursor.All(ctx, &products); err != nil {
+		return err
+	}
+	levels := database.GetCollection("stock_levels")
+	for _, p := range products {
+		_, err := levels.UpdateOne(ctx,
+			bson.M{"warehouseID": main.ID, "productID": p.ID},
+			bson.M{"$setOnInsert": bson.M{"stock": p.Stock, "reserved": p.Reserved, "updatedAt": now}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("product %s: %w", p.ID.Hex(), err)
+		}
+	}
+
+	_, err = database.GetCollection("stock_reservations").UpdateMany(ctx,
+		bson.M{"warehouseID": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"warehouseID": main.ID}},
+	)
+	return err
+}
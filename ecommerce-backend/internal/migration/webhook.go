@@ -0,0 +1,27 @@
+// internal/migration/webhook.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// webhookDeliveryIndexes queues an event at most once per endpoint, finds due deliveries for
+// the dispatcher and lists an endpoint's delivery log newest first.
+func webhookDeliveryIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("webhook_deliveries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "endpointID", Value: 1}, {Key: "eventID", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.D{{Key: "endpointID", Value: 1}, {Key: "createdAt", Value: -1}}},
+	})
+	return err
+}
@@ -0,0 +1,25 @@
+// internal/migration/wishlist.go
+package migration
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+)
+
+// wishlistIndexes indexes wishlists by owner and makes share IDs unique among shared lists.
+func wishlistIndexes(ctx context.Context, _ *config.Config) error {
+	_, err := database.GetCollection("wishlists").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userID", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "shareId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"shareId": bson.M{"$exists": true}}),
+		},
+	})
+	return err
+}
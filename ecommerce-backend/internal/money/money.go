@@ -0,0 +1,212 @@
+// internal/money/money.go
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Money is an amount in integer minor units (e.g. cents) of an ISO 4217 currency.
+// Storing minor units avoids the rounding drift of float64 arithmetic (59.970000000000006).
+type Money struct {
+	Amount   int64  `bson:"amount" json:"amount"`     // Minor units, e.g. 5997 for 59.97 USD
+	Currency string `bson:"currency" json:"currency"` // ISO 4217 code, e.g. "USD"
+}
+
+// exponents lists currencies whose minor unit is not 1/100 of the major unit.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent returns the number of minor-unit digits for a currency (2 for most).
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 code (three upper-case letters).
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// New creates a Money value from minor units.
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero returns a zero amount in the given currency.
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// FromMajor converts a major-unit amount (e.g. 59.97) to Money, rounding to the nearest minor unit.
+// It is meant for request boundaries; all arithmetic afterwards happens on integers.
+func FromMajor(value float64, currency string) Money {
+	scale := math.Pow10(Exponent(currency))
+	return Money{Amount: int64(math.Round(value * scale)), Currency: currency}
+}
+
+// ParseMajor parses a decimal string in major units (e.g. "59.97") exactly.
+// More fractional digits than the currency allows is an error rather than a silent rounding.
+func ParseMajor(value, currency string) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(Exponent(currency))))
+	if !r.IsInt() {
+		return Money{}, fmt.Errorf("amount %q has more decimal places than %s allows", value, currency)
+	}
+	if !r.Num().IsInt64() {
+		return Money{}, fmt.Errorf("amount %q is too large", value)
+	}
+	return Money{Amount: r.Num().Int64(), Currency: currency}, nil
+}
+
+// Mul multiplies the amount by an integer quantity.
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// Add returns m + other. Both amounts must share a currency; mixing currencies is a
+// programming error, so it panics instead of silently producing a wrong total.
+func (m Money) Add(other Money) Money {
+	m.mustMatch(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+// Sub returns m - other. Both amounts must share a currency.
+func (m Money) Sub(other Money) Money {
+	m.mustMatch(other)
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// mustMatch panics if the currencies differ. A zero-valued Money adopts the other currency.
+func (m *Money) mustMatch(other Money) {
+	if m.Currency == "" && m.Amount == 0 {
+		m.Currency = other.Currency
+		return
+	}
+	if other.Currency == "" && other.Amount == 0 {
+		return
+	}
+	if m.Currency != other.Currency {
+		panic(fmt.Sprintf("money: currency mismatch %s vs %s", m.Currency, other.Currency))
+	}
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Min returns the smaller of two amounts in the same currency.
+func Min(a, b Money) Money {
+	if a.Sub(b).IsNegative() {
+		return a
+	}
+	return b
+}
+
+// Major formats the amount in major units without a currency, e.g. "59.97".
+func (m Money) Major() string {
+	exp := Exponent(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+	scale := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exp, amount%scale)
+}
+
+// String formats the amount with its currency, e.g. "59.97 USD".
+func (m Money) String() string {
+	return m.Major() + " " + m.Currency
+}
+
+// Convert converts the amount into another currency at rate, where one major unit of m's
+// currency equals rate major units of the target. The result is rounded half away from zero.
+func (m Money) Convert(currency string, rate *big.Rat) Money {
+	if currency == m.Currency {
+		return m
+	}
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Mul(value, rate)
+	value.Mul(value, new(big.Rat).SetInt(pow10(Exponent(currency))))
+	value.Quo(value, new(big.Rat).SetInt(pow10(Exponent(m.Currency))))
+	return Money{Amount: roundRat(value), Currency: currency}
+}
+
+// ParseRate parses a positive decimal exchange rate such as "0.9215".
+func ParseRate(rate string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(rate))
+	if !ok || r.Sign() <= 0 {
+		return nil, errors.New("rate must be a positive decimal number")
+	}
+	return r, nil
+}
+
+// MarshalJSON adds a human-readable "formatted" field next to the raw minor units.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount    int64  `json:"amount"`
+		Currency  string `json:"currency"`
+		Formatted string `json:"formatted"`
+	}{m.Amount, m.Currency, m.String()})
+}
+
+// UnmarshalJSON accepts the object form produced by MarshalJSON; "formatted" is ignored.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Amount, m.Currency = raw.Amount, raw.Currency
+	return nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// roundRat rounds a rational to the nearest integer, halves away from zero.
+func roundRat(r *big.Rat) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if negative {
+		quo.Neg(quo)
+	}
+	return quo.Int64()
+}
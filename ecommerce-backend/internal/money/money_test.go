@@ -0,0 +1,138 @@
+// internal/money/money_test.go
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name string
+		from Money
+		to   string
+		rate string
+		want Money
+	}{
+		{"same currency is unchanged", New(1234, "USD"), "USD", "2", New(1234, "USD")},
+		{"two-decimal to two-decimal", New(10000, "USD"), "EUR", "0.9215", New(9215, "EUR")},
+		{"into a zero-decimal currency", New(1999, "USD"), "JPY", "151.37", New(3026, "JPY")},
+		{"from a zero-decimal currency", New(1000, "JPY"), "USD", "0.0066", New(660, "USD")},
+		{"into a three-decimal currency", New(1000, "USD"), "KWD", "0.3075", New(3075, "KWD")},
+		{"from a three-decimal currency", New(1000, "KWD"), "USD", "3.25", New(325, "USD")},
+		{"below half rounds down", New(1, "USD"), "EUR", "0.4999", New(0, "EUR")},
+		{"half rounds up", New(1, "USD"), "EUR", "0.5", New(1, "EUR")},
+		{"odd half rounds up", New(3, "USD"), "EUR", "0.5", New(2, "EUR")},
+		{"negative half rounds away from zero", New(-3, "USD"), "EUR", "0.5", New(-2, "EUR")},
+		{"negative below half rounds toward zero", New(-1, "USD"), "EUR", "0.4999", New(0, "EUR")},
+		{"tiny amount rounds to zero", New(1, "KWD"), "USD", "3.25", New(0, "USD")},
+		{"zero stays zero", New(0, "USD"), "EUR", "0.9215", New(0, "EUR")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ParseRate(tt.rate)
+			if err != nil {
+				t.Fatalf("ParseRate(%q) error = %v", tt.rate, err)
+			}
+			if got := tt.from.Convert(tt.to, rate); got != tt.want {
+				t.Errorf("%v.Convert(%s, %s) = %v, want %v", tt.from, tt.to, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		rate    string
+		want    *big.Rat
+		wantErr bool
+	}{
+		{"0.9215", big.NewRat(9215, 10000), false},
+		{" 151.37 ", big.NewRat(15137, 100), false},
+		{"3/4", big.NewRat(3, 4), false},
+		{"0", nil, true},
+		{"-1.2", nil, true},
+		{"abc", nil, true},
+		{"", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.rate)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRate(%q) error = %v, wantErr %v", tt.rate, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.Cmp(tt.want) != 0 {
+			t.Errorf("ParseRate(%q) = %s, want %s", tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestFromMajor(t *testing.T) {
+	tests := []struct {
+		value    float64
+		currency string
+		want     Money
+	}{
+		{59.97, "USD", New(5997, "USD")},
+		{0.1 + 0.2, "USD", New(30, "USD")},
+		{19.99 * 3, "USD", New(5997, "USD")},
+		{19.5, "JPY", New(20, "JPY")},
+		{-19.5, "JPY", New(-20, "JPY")},
+		{1.2346, "KWD", New(1235, "KWD")},
+		{0.004, "USD", New(0, "USD")},
+	}
+	for _, tt := range tests {
+		if got := FromMajor(tt.value, tt.currency); got != tt.want {
+			t.Errorf("FromMajor(%v, %s) = %v, want %v", tt.value, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestParseMajor(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{"59.97", "USD", New(5997, "USD"), false},
+		{" 2.50 ", "USD", New(250, "USD"), false},
+		{"-3.10", "USD", New(-310, "USD"), false},
+		{"1200", "JPY", New(1200, "JPY"), false},
+		{"1.234", "KWD", New(1234, "KWD"), false},
+		{"0.001", "USD", Money{}, true},
+		{"1.5", "JPY", Money{}, true},
+		{"99999999999999999999", "USD", Money{}, true},
+		{"abc", "USD", Money{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMajor(tt.value, tt.currency)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMajor(%q, %s) error = %v, wantErr %v", tt.value, tt.currency, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMajor(%q, %s) = %v, want %v", tt.value, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMajor(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{New(5997, "USD"), "59.97"},
+		{New(5, "USD"), "0.05"},
+		{New(-5, "USD"), "-0.05"},
+		{New(0, "USD"), "0.00"},
+		{New(1234, "JPY"), "1234"},
+		{New(1234, "KWD"), "1.234"},
+		{New(-1001, "KWD"), "-1.001"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Major(); got != tt.want {
+			t.Errorf("Money{%d, %s}.Major() = %q, want %q", tt.m.Amount, tt.m.Currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// internal/notification/notification.go
+package notification
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+)
+
+// Notification is a message about something that happened in the shop.
+// Mail-based notifiers deliver it to To; webhook notifiers post it whole.
+type Notification struct {
+	Event   string                 `json:"event"`        // e.g. "stock.low", "stock.back_in_stock"
+	To      []string               `json:"to,omitempty"` // Email recipients; webhook-only notifications leave it empty
+	Subject string                 `json:"subject"`
+	Body    string                 `json:"body"`
+	Data    map[string]interface{} `json:"data,omitempty"` // Machine-readable details for webhook receivers
+}
+
+// Notifier delivers notifications.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NewNotifier builds the notifier configured for this deployment: email through SMTP when
+// SMTP_HOST is set (otherwise emails are only logged), plus a webhook when NOTIFY_WEBHOOK_URL is set.
+func NewNotifier(cfg *config.Config) Notifier {
+	var notifiers multiNotifier
+	if cfg.SMTPHost != "" {
+		notifiers = append(notifiers, NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom))
+	} else {
+		notifiers = append(notifiers, LogMailer{})
+	}
+	if cfg.NotifyWebhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(cfg.NotifyWebhookURL))
+	}
+	return notifiers
+}
+
+// LogMailer writes emails to the log instead of sending them. Used when SMTP is not configured.
+type LogMailer struct{}
+
+// Notify logs the notification.
+func (LogMailer) Notify(_ context.Context, n Notification) error {
+	if len(n.To) == 0 {
+		return nil
+	}
+	log.Printf("Email to %s: %s\n%s", strings.Join(n.To, ", "), n.Subject, n.Body)
+	return nil
+}
+
+// multiNotifier fans a notification out to several notifiers. Every notifier is tried,
+// so one failing channel does not stop the others.
+type multiNotifier []Notifier
+
+// Notify delivers n through every notifier and joins their errors.
+func (m multiNotifier) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
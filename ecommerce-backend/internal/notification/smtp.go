@@ -0,0 +1,47 @@
+// internal/notification/smtp.go
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPMailer sends notifications as plain-text emails.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth // nil when no username is configured
+	from string
+}
+
+// NewSMTPMailer creates a mailer for the given SMTP server.
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	m := &SMTPMailer{addr: host + ":" + port, from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+// Notify emails n to its recipients. Notifications without recipients are skipped.
+func (m *SMTPMailer) Notify(ctx context.Context, n Notification) error {
+	if len(n.To) == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", n.Subject)
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(n.Body)
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, n.To, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -9,20 +9,27 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"  // For guest order access token settings
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product" // For sale pricing by customer segment
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils"   // For standardized responses
 )
 
 // OrderHandler handles HTTP requests related to orders.
 type OrderHandler struct {
 	Service   OrderService
 	Validator *validator.Validate
+	// Guest order access tokens are signed with the JWT secret and expire after GuestTokenTTL.
+	JWTSecret     string
+	GuestTokenTTL time.Duration
 }
 
 // NewOrderHandler creates a new OrderHandler instance.
-func NewOrderHandler(s OrderService) *OrderHandler {
+func NewOrderHandler(s OrderService, cfg *config.Config) *OrderHandler {
 	return &OrderHandler{
-		Service:   s,
-		Validator: validator.New(),
+		Service:       s,
+		Validator:     validator.New(),
+		JWTSecret:     cfg.JWTSecret,
+		GuestTokenTTL: cfg.GuestOrderTokenTTL,
 	}
 }
 
@@ -35,7 +42,7 @@ func NewOrderHandler(s OrderService) *OrderHandler {
 // @Param   request body CreateOrderRequest true "Order Creation Info"
 // @Security ApiKeyAuth
 // @Success 201 {object} map[string]interface{} "Order created successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error, insufficient stock"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error, insufficient stock, unusable gift card"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /orders [post]
@@ -63,12 +70,10 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // Longer timeout for transactions
 	defer cancel()
 
-	orderResp, err := h.Service.CreateOrder(ctx, userIDStr, &req)
+	orderResp, err := h.Service.CreateOrder(product.WithSegment(ctx, c.GetString("userSegment")), userIDStr, &req)
 	if err != nil {
 		// Differentiate between user-facing errors (like insufficient stock) and internal errors
-		if err.Error() == "invalid user ID format" ||
-			(len(err.Error()) > 0 && (strings.Contains(err.Error(), "product not found") || strings.Contains(err.Error(), "insufficient stock"))) ||
-			strings.Contains(err.Error(), "invalid product ID format") {
+		if err.Error() == "invalid user ID format" || isCheckoutError(err) {
 			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -79,6 +84,75 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Order created successfully", "order": orderResp})
 }
 
+// CreateGuestOrder godoc
+// @Summary Place an order without an account
+// @Description Place an order as a guest with an email address. The response carries an access token for viewing the order with GET /orders/{id}?token=...; registering and verifying the same email later claims the order. Guests cannot use store credit or loyalty points.
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param   request body GuestOrderRequest true "Order Creation Info with the guest's email"
+// @Success 201 {object} map[string]interface{} "Order created successfully, with its access token"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error, insufficient stock, unusable gift card"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/guest [post]
+func (h *OrderHandler) CreateGuestOrder(c *gin.Context) {
+	var req GuestOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // Longer timeout for transactions
+	defer cancel()
+
+	orderResp, err := h.Service.CreateGuestOrder(ctx, &req)
+	if err != nil {
+		if isCheckoutError(err) {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	token, expiresAt, err := utils.GenerateOrderAccessToken(orderResp.ID, h.JWTSecret, h.GuestTokenTTL)
+	if err != nil {
+		// The order is placed; the guest can still get to it by registering with the same email.
+		utils.RespondWithError(c, http.StatusInternalServerError, "Order was placed but its access token could not be created")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{
+		"message":              "Order created successfully",
+		"order":                orderResp,
+		"accessToken":          token,
+		"accessTokenExpiresAt": expiresAt,
+	})
+}
+
+// isCheckoutError reports whether err from placing an order is the customer's to fix, such as
+// an unsupported currency, a product out of stock or an unusable gift card.
+func isCheckoutError(err error) bool {
+	return err.Error() == "unsupported currency" || err.Error() == "invalid currency code" ||
+		isItemError(err) ||
+		strings.HasPrefix(err.Error(), "gift card ") || strings.HasPrefix(err.Error(), "store credit ") ||
+		strings.HasPrefix(err.Error(), "insufficient loyalty points") || strings.HasPrefix(err.Error(), "loyalty points ")
+}
+
+// isItemError reports whether err is about the requested order lines rather than an
+// internal failure: an unknown product or not enough stock for it.
+func isItemError(err error) bool {
+	return strings.Contains(err.Error(), "product not found") || strings.Contains(err.Error(), "insufficient stock") ||
+		strings.Contains(err.Error(), "not available for download") ||
+		strings.Contains(err.Error(), "invalid product ID format")
+}
+
 // GetUserOrders godoc
 // @Summary Get orders for the authenticated user
 // @Description Retrieve a list of all orders placed by the authenticated user
@@ -111,22 +185,37 @@ func (h *OrderHandler) GetUserOrders(c *gin.Context) {
 
 // GetOrderByID godoc
 // @Summary Get order by ID
-// @Description Retrieve a single order by its ID (accessible to user who placed it or admin)
+// @Description Retrieve a single order by its ID (accessible to user who placed it, admin, or anyone with the order's guest access token until the order is claimed into an account)
 // @Tags Orders
 // @Produce  json
 // @Param   id path string true "Order ID"
+// @Param   token query string false "Guest order access token, instead of logging in"
+// @Param   If-None-Match header string false "ETag from a previous response"
 // @Security ApiKeyAuth
 // @Success 200 {object} map[string]interface{} "Order data"
+// @Success 304 "Not modified (ETag matches If-None-Match)"
 // @Failure 400 {object} map[string]interface{} "Invalid order ID format"
-// @Failure 401 {object} map[string]interface{} "Unauthorized"
-// @Failure 403 {object} map[string]interface{} "Forbidden: Access denied (if not owner or admin)"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: neither logged in nor given an access token"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Access denied (if not owner or admin, or the access token is invalid)"
 // @Failure 404 {object} map[string]interface{} "Order not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /orders/{id} [get]
 func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 	orderID := c.Param("id")
-	userID := c.MustGet("userID").(string)     // User ID from authenticated context
-	userRole := c.MustGet("userRole").(string) // User role from authenticated context
+	userID := c.GetString("userID")     // Set by OptionalAuth when a valid JWT was sent
+	userRole := c.GetString("userRole") // Likewise
+	token := c.Query("token")           // Guest order access token
+	tokenValid := token != "" && utils.ValidateOrderAccessToken(token, orderID, h.JWTSecret) == nil
+	if userID == "" {
+		if token == "" {
+			utils.RespondWithError(c, http.StatusUnauthorized, "Authorization header or order access token required")
+			return
+		}
+		if !tokenValid {
+			utils.RespondWithError(c, http.StatusForbidden, "Invalid or expired order access token")
+			return
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
@@ -145,15 +234,215 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 		return
 	}
 
-	// Authorization check: Only owner or admin can view this specific order
-	if orderResp.UserID != userID && userRole != "admin" {
+	// Claiming moves a guest order into an account, which ends access through its token.
+	if orderResp.ClaimedAt != nil {
+		tokenValid = false
+	}
+
+	// Authorization check: Only owner, admin or the holder of the order's access token can view this specific order
+	if (userID == "" || orderResp.UserID != userID) && userRole != "admin" && !tokenValid {
 		utils.RespondWithError(c, http.StatusForbidden, "Access denied: You can only view your own orders or if you are an admin.")
 		return
 	}
 
+	etag := utils.FormatETag(orderResp.Version)
+	c.Header("ETag", etag)
+	if utils.NotModified(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"order": orderResp})
 }
 
+// AmendOrder godoc
+// @Summary Amend a pending order
+// @Description Change the items or shipping address of one of the authenticated user's orders while it is still pending. Items replace every line of the order: stock is held or released to match and the total is recalculated, with products already in the order kept at the price they were ordered at. Each amendment is recorded in the order history.
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Order ID"
+// @Param   request body AmendOrderRequest true "New items and/or shipping address"
+// @Param   If-Match header string false "ETag the amendment is based on"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Order amended successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error, nothing to amend, insufficient stock"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: not your order"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order is no longer pending, its reservation has expired, or redemptions were applied to it"
+// @Failure 412 {object} map[string]interface{} "Order was modified since the given ETag"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id} [patch]
+func (h *OrderHandler) AmendOrder(c *gin.Context) {
+	orderID := c.Param("id")
+	expectedVersion, err := utils.ExpectedVersion(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req AmendOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // Longer timeout for transactions
+	defer cancel()
+
+	orderResp, err := h.Service.AmendOrder(product.WithSegment(ctx, c.GetString("userSegment")), orderID, c.GetString("userID"), &req, expectedVersion)
+	if err != nil {
+		switch {
+		case err.Error() == "order not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case err.Error() == "access denied":
+			utils.RespondWithError(c, http.StatusForbidden, "Access denied: You can only amend your own orders.")
+		case err.Error() == "version mismatch":
+			utils.RespondWithError(c, http.StatusPreconditionFailed, "Order was modified by someone else (version mismatch); reload and retry")
+		case err.Error() == "invalid order ID format" || err.Error() == "invalid user ID format" ||
+			strings.HasPrefix(err.Error(), "nothing to amend") || isItemError(err):
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		case err.Error() == "order can no longer be amended" || err.Error() == "order reservation has expired" ||
+			strings.HasPrefix(err.Error(), "order cannot be amended"):
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	c.Header("ETag", utils.FormatETag(orderResp.Version))
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Order amended successfully", "order": orderResp})
+}
+
+// CancelOrder godoc
+// @Summary Cancel my order
+// @Description Cancel one of the authenticated user's orders while it is pending or processing, within ORDER_CANCELLATION_WINDOW of placing it. A pending order's stock holds are released; a paid order is restocked and any gift cards, store credit and loyalty points used are given back. A payment taken through the payment provider is refunded shortly after the cancellation; one confirmed by hand is refunded by an admin.
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Order ID"
+// @Param   request body CancelOrderRequest true "Why the order is cancelled"
+// @Param   If-Match header string false "ETag the cancellation is based on"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Order cancelled successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: not your order"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order has shipped or the cancellation window has closed"
+// @Failure 412 {object} map[string]interface{} "Order was modified since the given ETag"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	orderID := c.Param("id")
+	expectedVersion, err := utils.ExpectedVersion(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // Longer timeout for transactions
+	defer cancel()
+
+	orderResp, err := h.Service.CancelOrder(ctx, orderID, c.GetString("userID"), req.Reason, expectedVersion)
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "access denied":
+			utils.RespondWithError(c, http.StatusForbidden, "Access denied: You can only cancel your own orders.")
+		case "version mismatch":
+			utils.RespondWithError(c, http.StatusPreconditionFailed, "Order was modified by someone else (version mismatch); reload and retry")
+		case "invalid order ID format", "invalid user ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		case "order can no longer be cancelled", "cancellation window has closed", "stock reservation was settled concurrently":
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	c.Header("ETag", utils.FormatETag(orderResp.Version))
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Order cancelled successfully", "order": orderResp})
+}
+
+// Reorder godoc
+// @Summary Order a past order again
+// @Description Place a new order for the items of one of the authenticated user's past orders, at current prices and shipped like the past order unless the body says otherwise. Items that can no longer be ordered or are out of stock are left out and listed under "skipped"; the body is optional.
+// @Tags Orders
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Past order ID"
+// @Param   request body ReorderRequest false "Currency, shipping region or address to use instead of the past order's"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Order created, with the skipped items"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error, unsupported currency"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: not your order"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "None of the items can be ordered right now"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id}/reorder [post]
+func (h *OrderHandler) Reorder(c *gin.Context) {
+	orderID := c.Param("id")
+
+	var req ReorderRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+			return
+		}
+		if err := h.Validator.Struct(req); err != nil {
+			validationErrors := err.(validator.ValidationErrors)
+			utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second) // Longer timeout for transactions
+	defer cancel()
+
+	result, err := h.Service.Reorder(product.WithSegment(ctx, c.GetString("userSegment")), orderID, c.GetString("userID"), &req)
+	if err != nil {
+		switch {
+		case err.Error() == "order not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case err.Error() == "access denied":
+			utils.RespondWithError(c, http.StatusForbidden, "Access denied: You can only reorder your own orders.")
+		case err.Error() == "none of the items can be ordered right now":
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		case err.Error() == "invalid order ID format", err.Error() == "invalid user ID format", isCheckoutError(err):
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Order created successfully", "order": result.Order, "skipped": result.Skipped})
+}
+
 // GetAllOrders godoc
 // @Summary Get all orders
 // @Description Retrieve a list of all orders (admin only)
@@ -186,16 +475,24 @@ func (h *OrderHandler) GetAllOrders(c *gin.Context) {
 // @Produce  json
 // @Param   id path string true "Order ID"
 // @Param   request body UpdateOrderStatusRequest true "Order Status Update Info"
+// @Param   If-Match header string false "ETag the update is based on"
 // @Security ApiKeyAuth
 // @Success 200 {object} map[string]interface{} "Order status updated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or invalid ID/status"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
 // @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 412 {object} map[string]interface{} "Order was modified since the given ETag"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /admin/orders/{id}/status [patch]
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	orderID := c.Param("id")
+	expectedVersion, err := utils.ExpectedVersion(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var req UpdateOrderStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
@@ -211,19 +508,77 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	orderResp, err := h.Service.UpdateOrderStatus(ctx, orderID, &req)
+	orderResp, err := h.Service.UpdateOrderStatus(ctx, orderID, &req, expectedVersion)
 	if err != nil {
 		if err.Error() == "order not found" {
 			utils.RespondWithError(c, http.StatusNotFound, err.Error())
 			return
 		}
+		if err.Error() == "version mismatch" {
+			utils.RespondWithError(c, http.StatusPreconditionFailed, "Order was modified by someone else (version mismatch); reload and retry")
+			return
+		}
 		if err.Error() == "invalid order ID format" || strings.Contains(err.Error(), "invalid status") {
 			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
 			return
 		}
+		if err.Error() == "stock reservation was settled concurrently" {
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+			return
+		}
 		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	c.Header("ETag", utils.FormatETag(orderResp.Version))
 	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Order status updated successfully", "order": orderResp})
 }
+
+// ConfirmPayment godoc
+// @Summary Confirm payment for an order (Admin only)
+// @Description Mark a pending order as paid: its stock holds become deductions and it moves to "processing"
+// @Tags Orders
+// @Produce  json
+// @Param   id path string true "Order ID"
+// @Param   If-Match header string false "ETag the confirmation is based on"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Payment confirmed"
+// @Failure 400 {object} map[string]interface{} "Invalid order ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 409 {object} map[string]interface{} "Order is not pending or its reservation has expired"
+// @Failure 412 {object} map[string]interface{} "Order was modified since the given ETag"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/orders/{id}/confirm-payment [post]
+func (h *OrderHandler) ConfirmPayment(c *gin.Context) {
+	orderID := c.Param("id")
+	expectedVersion, err := utils.ExpectedVersion(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	orderResp, err := h.Service.ConfirmPayment(ctx, orderID, nil, expectedVersion)
+	if err != nil {
+		switch err.Error() {
+		case "order not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "version mismatch":
+			utils.RespondWithError(c, http.StatusPreconditionFailed, "Order was modified by someone else (version mismatch); reload and retry")
+		case "invalid order ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		case "order is not awaiting payment", "order reservation has expired", "stock reservation was settled concurrently":
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	c.Header("ETag", utils.FormatETag(orderResp.Version))
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Payment confirmed", "order": orderResp})
+}
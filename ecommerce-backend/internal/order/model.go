@@ -5,16 +5,38 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/payment"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/warehouse"
 )
 
 // OrderItem represents a single product within an order.
 type OrderItem struct {
+	ProductID     primitive.ObjectID   `bson:"productID" json:"productId"`
+	Name          string               `bson:"name" json:"name"` // Denormalized product name
+	SKU           string               `bson:"sku" json:"sku"`   // Denormalized product SKU
+	Quantity      int                  `bson:"quantity" json:"quantity"`
+	Price         money.Money          `bson:"price" json:"price"` // Price at time of order, in the base currency
+	Subtotal      money.Money          `bson:"subtotal" json:"subtotal"`
+	RegularPrice  *money.Money         `bson:"regularPrice,omitempty" json:"regularPrice,omitempty"` // Set when a sale price was charged
+	Components    []OrderItemComponent `bson:"components,omitempty" json:"components,omitempty"`     // Set for bundles: the products actually shipped
+	Digital       bool                 `bson:"digital,omitempty" json:"digital,omitempty"`           // Delivered through GET /orders/:id/downloads, not shipped
+	LicenseKeys   []string             `bson:"licenseKeys,omitempty" json:"licenseKeys,omitempty"`   // One per unit, claimed at checkout; shown once the order is paid
+	GiftCard      bool                 `bson:"giftCard,omitempty" json:"giftCard,omitempty"`
+	GiftCardCodes []string             `bson:"giftCardCodes,omitempty" json:"giftCardCodes,omitempty"` // One per unit, issued when the order is paid
+	CategoryID    primitive.ObjectID   `bson:"categoryID,omitempty" json:"categoryId"`                 // Denormalized; sets the loyalty points multiplier
+	Backordered   int                  `bson:"backordered,omitempty" json:"backordered,omitempty"`     // Units still waiting for stock; filled oldest order first
+	Preorder      bool                 `bson:"preorder,omitempty" json:"preorder,omitempty"`           // Ordered before the product's release date
+}
+
+// OrderItemComponent is a product shipped as part of a bundle line. Stock is held and
+// allocated for components, never for the bundle itself.
+type OrderItemComponent struct {
 	ProductID primitive.ObjectID `bson:"productID" json:"productId"`
-	Name      string             `bson:"name" json:"name"` // Denormalized product name
-	SKU       string             `bson:"sku" json:"sku"`   // Denormalized product SKU
-	Quantity  int                `bson:"quantity" json:"quantity"`
-	Price     float64            `bson:"price" json:"price"` // Price at time of order
-	Subtotal  float64            `bson:"subtotal" json:"subtotal"`
+	Name      string             `bson:"name" json:"name"`
+	SKU       string             `bson:"sku" json:"sku"`
+	Quantity  int                `bson:"quantity" json:"quantity"` // Units for the whole line, not per bundle
 }
 
 // Order represents a customer order.
@@ -22,10 +44,140 @@ type Order struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	UserID      primitive.ObjectID `bson:"userID" json:"userId"`
 	Items       []OrderItem        `bson:"items" json:"items"`
-	TotalAmount float64            `bson:"totalAmount" json:"totalAmount"`
-	Status      string             `bson:"status" json:"status"` // e.g., "pending", "processing", "shipped", "delivered", "cancelled"
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	TotalAmount money.Money        `bson:"totalAmount" json:"totalAmount"` // In the base currency
+	// Redemptions are the parts of the total paid with gift cards and store credit;
+	// AmountDue is what is left to pay and is only set when there are any.
+	Redemptions []Redemption `bson:"redemptions,omitempty" json:"redemptions,omitempty"`
+	AmountDue   *money.Money `bson:"amountDue,omitempty" json:"amountDue,omitempty"`
+	// PointsRedeemed loyalty points took PointsDiscount off the total; PointsEarned were
+	// credited when the order was delivered.
+	PointsRedeemed int64        `bson:"pointsRedeemed,omitempty" json:"pointsRedeemed,omitempty"`
+	PointsDiscount *money.Money `bson:"pointsDiscount,omitempty" json:"pointsDiscount,omitempty"`
+	PointsEarned   int64        `bson:"pointsEarned,omitempty" json:"pointsEarned,omitempty"`
+	Status         string       `bson:"status" json:"status"` // e.g., "pending", "processing", "shipped", "delivered", "cancelled", "returned", "refunded"
+	// CurrencyLock is set when the customer checked out in a currency other than the base one.
+	CurrencyLock *CurrencyLock `bson:"currencyLock,omitempty" json:"currencyLock,omitempty"`
+	// Allocations record which warehouse ships how many units of each item.
+	Allocations     []warehouse.Allocation `bson:"allocations,omitempty" json:"allocations,omitempty"`
+	ShippingRegion  string                 `bson:"shippingRegion,omitempty" json:"shippingRegion,omitempty"`
+	ShippingAddress *Address               `bson:"shippingAddress,omitempty" json:"shippingAddress,omitempty"`
+	// ReservationExpiresAt is when the stock held for an unpaid order is released and the order auto-cancelled.
+	ReservationExpiresAt *time.Time `bson:"reservationExpiresAt,omitempty" json:"reservationExpiresAt,omitempty"`
+	PaidAt               *time.Time `bson:"paidAt,omitempty" json:"paidAt,omitempty"`
+	// Payment is the provider charge that paid the order; unset when payment was confirmed by hand.
+	Payment      *payment.Charge `bson:"payment,omitempty" json:"payment,omitempty"`
+	CancelReason string          `bson:"cancelReason,omitempty" json:"cancelReason,omitempty"`
+	RefundedAt   *time.Time      `bson:"refundedAt,omitempty" json:"refundedAt,omitempty"`
+	CreatedAt    time.Time       `bson:"createdAt" json:"createdAt"`
+	UpdatedAt    time.Time       `bson:"updatedAt" json:"updatedAt"`
+	Version      int64           `bson:"version" json:"version"` // Incremented on every write, exposed as the ETag
+	// History records every status change and amendment, oldest first.
+	History []HistoryEntry `bson:"history,omitempty" json:"history,omitempty"`
+	// GuestEmail is set on orders placed without an account. Their UserID stays unset until a
+	// user who verified that email claims them.
+	GuestEmail string     `bson:"guestEmail,omitempty" json:"guestEmail,omitempty"`
+	ClaimedAt  *time.Time `bson:"claimedAt,omitempty" json:"claimedAt,omitempty"`
+}
+
+// isGuest reports whether the order was placed without an account and not claimed yet.
+func (o *Order) isGuest() bool {
+	return o.UserID.IsZero()
+}
+
+// productIDs returns the ID of every product in the order, bundle components included.
+func (o *Order) productIDs() []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(o.Items))
+	for _, item := range o.Items {
+		ids = append(ids, item.ProductID)
+		for _, c := range item.Components {
+			ids = append(ids, c.ProductID)
+		}
+	}
+	return ids
+}
+
+// hasBackorders reports whether any of the order's units are still waiting for stock.
+func (o *Order) hasBackorders() bool {
+	for _, item := range o.Items {
+		if item.Backordered > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Redemption types.
+const (
+	RedemptionGiftCard    = "gift_card"
+	RedemptionStoreCredit = "store_credit"
+)
+
+// Redemption is part of an order's total paid with a gift card or store credit. Cancelling or
+// refunding the order puts the amount back.
+type Redemption struct {
+	Type       string              `bson:"type" json:"type"` // RedemptionGiftCard or RedemptionStoreCredit
+	GiftCardID *primitive.ObjectID `bson:"giftCardID,omitempty" json:"giftCardId,omitempty"`
+	Code       string              `bson:"code,omitempty" json:"code,omitempty"` // Masked gift card code
+	Amount     money.Money         `bson:"amount" json:"amount"`
+}
+
+// amountDue returns what is left to pay after gift cards and store credit.
+func (o *Order) amountDue() money.Money {
+	if o.AmountDue != nil {
+		return *o.AmountDue
+	}
+	return o.TotalAmount
+}
+
+// History entry types.
+const (
+	HistoryStatusChanged = "status_changed"
+	HistoryAmended       = "amended" // Lines or shipping address changed by the customer while pending
+	HistoryClaimed       = "claimed" // A guest order was claimed by the user who verified its email
+)
+
+// HistoryEntry is one change made to an order after it was placed.
+type HistoryEntry struct {
+	Type string              `bson:"type" json:"type"` // HistoryStatusChanged or HistoryAmended
+	At   time.Time           `bson:"at" json:"at"`
+	By   *primitive.ObjectID `bson:"by,omitempty" json:"by,omitempty"` // The user who amended or claimed the order
+	// Status changes only.
+	From string `bson:"from,omitempty" json:"from,omitempty"`
+	To   string `bson:"to,omitempty" json:"to,omitempty"`
+	// Amendments only: the lines whose quantity changed, the address that was replaced and
+	// the total before and after.
+	Items           []ItemChange `bson:"items,omitempty" json:"items,omitempty"`
+	PreviousAddress *Address     `bson:"previousAddress,omitempty" json:"previousAddress,omitempty"`
+	TotalBefore     *money.Money `bson:"totalBefore,omitempty" json:"totalBefore,omitempty"`
+	TotalAfter      *money.Money `bson:"totalAfter,omitempty" json:"totalAfter,omitempty"`
+}
+
+// ItemChange is a product whose quantity an amendment changed. From is 0 for added products
+// and To is 0 for removed ones.
+type ItemChange struct {
+	ProductID primitive.ObjectID `bson:"productID" json:"productId"`
+	Name      string             `bson:"name" json:"name"`
+	From      int                `bson:"from" json:"from"`
+	To        int                `bson:"to" json:"to"`
+}
+
+// Address is where an order is shipped.
+type Address struct {
+	Name       string `bson:"name" json:"name" validate:"required,max=100"`
+	Line1      string `bson:"line1" json:"line1" validate:"required,max=200"`
+	Line2      string `bson:"line2,omitempty" json:"line2,omitempty" validate:"max=200"`
+	City       string `bson:"city" json:"city" validate:"required,max=100"`
+	PostalCode string `bson:"postalCode" json:"postalCode" validate:"required,max=20"`
+	Country    string `bson:"country" json:"country" validate:"required,len=2"` // ISO 3166-1 alpha-2, e.g. "DE"
+}
+
+// CurrencyLock records the exchange rate an order was placed with, so later rate
+// changes never alter what the customer was charged.
+type CurrencyLock struct {
+	Currency string      `bson:"currency" json:"currency"`
+	Rate     string      `bson:"rate" json:"rate"`   // Base-to-currency rate at checkout
+	Total    money.Money `bson:"total" json:"total"` // TotalAmount converted at Rate
+	LockedAt time.Time   `bson:"lockedAt" json:"lockedAt"`
 }
 
 // OrderStatus defines possible statuses for an order.
@@ -35,28 +187,110 @@ const (
 	StatusShipped    = "shipped"
 	StatusDelivered  = "delivered"
 	StatusCancelled  = "cancelled"
+	StatusReturned   = "returned" // Only from delivered; can then only be refunded
+	StatusRefunded   = "refunded" // Final; only paid or returned orders can be refunded, and their license keys are revoked
 )
 
+// paidStatuses are the statuses of orders that have been paid for and not refunded.
+var paidStatuses = map[string]bool{
+	StatusProcessing: true,
+	StatusShipped:    true,
+	StatusDelivered:  true,
+}
+
+// OrderItemRequest is one product and quantity in a new order.
+type OrderItemRequest struct {
+	ProductID string `json:"productId" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0"`
+}
+
 // CreateOrderRequest defines the structure for a new order request body.
 type CreateOrderRequest struct {
-	Items []struct {
-		ProductID string `json:"productId" validate:"required"`
-		Quantity  int    `json:"quantity" validate:"required,gt=0"`
-	} `json:"items" validate:"required,min=1,dive"` // `dive` validates each item in the slice
+	Items           []OrderItemRequest `json:"items" validate:"required,min=1,dive"`                 // `dive` validates each item in the slice
+	Currency        string             `json:"currency,omitempty" validate:"omitempty,len=3"`        // Optional checkout currency; defaults to the base currency
+	ShippingRegion  string             `json:"shippingRegion,omitempty" validate:"omitempty,max=50"` // Used by the "nearest" allocation strategy
+	ShippingAddress *Address           `json:"shippingAddress,omitempty"`
+	GiftCardCodes   []string           `json:"giftCardCodes,omitempty" validate:"omitempty,max=5,dive,required,max=40"` // Applied in order until the total is covered
+	UseStoreCredit  bool               `json:"useStoreCredit,omitempty"`                                                // Applied after gift cards
+	RedeemPoints    int64              `json:"redeemPoints,omitempty" validate:"omitempty,min=1"`                       // Loyalty points to take off the total; only what the total needs is used
+}
+
+// GuestOrderRequest defines the structure for an order placed without an account. Guests
+// cannot use store credit or loyalty points.
+type GuestOrderRequest struct {
+	CreateOrderRequest
+	Email string `json:"email" validate:"required,email,max=254"` // Where the guest can be reached; claiming the order needs it verified
+}
+
+// ReorderRequest defines the structure for ordering a past order's items again. Fields left
+// out are taken from the past order.
+type ReorderRequest struct {
+	Currency        string   `json:"currency,omitempty" validate:"omitempty,len=3"`
+	ShippingRegion  string   `json:"shippingRegion,omitempty" validate:"omitempty,max=50"`
+	ShippingAddress *Address `json:"shippingAddress,omitempty"`
+}
+
+// SkippedItem is a requested line left out of an order because it cannot be filled right now.
+type SkippedItem struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name,omitempty"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"` // e.g. "out of stock" or "product is archived and can no longer be ordered"
+}
+
+// PartialOrderResponse is an order placed from the lines that could be filled, with the lines
+// that were left out.
+type PartialOrderResponse struct {
+	Order   *OrderResponse `json:"order"`
+	Skipped []SkippedItem  `json:"skipped"`
+}
+
+// AmendOrderRequest defines the structure for amending a pending order. At least one field
+// must be set.
+type AmendOrderRequest struct {
+	Items           []OrderItemRequest `json:"items,omitempty" validate:"omitempty,min=1,dive"` // Replaces every line; products left out are removed
+	ShippingAddress *Address           `json:"shippingAddress,omitempty"`
+}
+
+// CancelOrderRequest defines the structure for a customer cancelling their order.
+type CancelOrderRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
 }
 
 // UpdateOrderStatusRequest defines the structure for updating an order's status.
 type UpdateOrderStatusRequest struct {
-	Status string `json:"status" validate:"required,oneof=pending processing shipped delivered cancelled"`
+	Status string `json:"status" validate:"required,oneof=pending processing shipped delivered cancelled returned refunded"`
 }
 
 // OrderResponse defines the structure for order data in API responses.
 type OrderResponse struct {
-	ID          string      `json:"id"`
-	UserID      string      `json:"userId"`
-	Items       []OrderItem `json:"items"` // Items are typically fine to return as is
-	TotalAmount float64     `json:"totalAmount"`
-	Status      string      `json:"status"`
-	CreatedAt   time.Time   `json:"createdAt"`
-	UpdatedAt   time.Time   `json:"updatedAt"`
+	ID          string       `json:"id"`
+	UserID      string       `json:"userId"`
+	Items       []OrderItem  `json:"items"` // Items are typically fine to return as is
+	TotalAmount money.Money  `json:"totalAmount"`
+	Redemptions []Redemption `json:"redemptions,omitempty"`
+	AmountDue   *money.Money `json:"amountDue,omitempty"` // Left to pay after redemptions
+
+	PointsRedeemed int64        `json:"pointsRedeemed,omitempty"`
+	PointsDiscount *money.Money `json:"pointsDiscount,omitempty"`
+	PointsEarned   int64        `json:"pointsEarned,omitempty"`
+
+	CurrencyLock *CurrencyLock `json:"currencyLock,omitempty"`
+	Status       string        `json:"status"`
+	// Allocations tell fulfillment which warehouse ships each item.
+	Allocations     []warehouse.Allocation `json:"allocations,omitempty"`
+	ShippingRegion  string                 `json:"shippingRegion,omitempty"`
+	ShippingAddress *Address               `json:"shippingAddress,omitempty"`
+	// ReservationExpiresAt is set while the order is pending: pay before then or the order is cancelled.
+	ReservationExpiresAt *time.Time      `json:"reservationExpiresAt,omitempty"`
+	PaidAt               *time.Time      `json:"paidAt,omitempty"`
+	Payment              *payment.Charge `json:"payment,omitempty"`
+	CancelReason         string          `json:"cancelReason,omitempty"`
+	RefundedAt           *time.Time      `json:"refundedAt,omitempty"`
+	History              []HistoryEntry  `json:"history,omitempty"`
+	GuestEmail           string          `json:"guestEmail,omitempty"`
+	ClaimedAt            *time.Time      `json:"claimedAt,omitempty"`
+	CreatedAt            time.Time       `json:"createdAt"`
+	UpdatedAt            time.Time       `json:"updatedAt"`
+	Version              int64           `json:"version"`
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,44 +14,137 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/exchange"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/giftcard"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/inventory"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/license"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/loyalty"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/payment"
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product" // Import product service to get product details and update stock
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/stockalert"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/storecredit"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/warehouse"
 )
 
 // OrderService defines the interface for order operations.
 type OrderService interface {
 	CreateOrder(ctx context.Context, userID string, req *CreateOrderRequest) (*OrderResponse, error)
+	CreateGuestOrder(ctx context.Context, req *GuestOrderRequest) (*OrderResponse, error)
+	ClaimGuestOrders(ctx context.Context, email, userID string) (int, error) // email must be verified as the user's
+	// Repeat purchases: lines that cannot be filled right now are reported instead of failing the order.
+	CreateOrderFromAvailable(ctx context.Context, userID string, req *CreateOrderRequest) (*PartialOrderResponse, error)
+	Reorder(ctx context.Context, orderID, userID string, req *ReorderRequest) (*PartialOrderResponse, error) // Owner only
+	AmendOrder(ctx context.Context, orderID, userID string, req *AmendOrderRequest, expectedVersion *int64) (*OrderResponse, error)
 	GetUserOrders(ctx context.Context, userID string) ([]OrderResponse, error)
 	GetOrderByID(ctx context.Context, orderID string) (*OrderResponse, error)
-	GetAllOrders(ctx context.Context) ([]OrderResponse, error)                                                    // Admin only
-	UpdateOrderStatus(ctx context.Context, orderID string, req *UpdateOrderStatusRequest) (*OrderResponse, error) // Admin only
+	GetAllOrders(ctx context.Context) ([]OrderResponse, error)                                                                            // Admin only
+	UpdateOrderStatus(ctx context.Context, orderID string, req *UpdateOrderStatusRequest, expectedVersion *int64) (*OrderResponse, error) // Admin only
+	ConfirmPayment(ctx context.Context, orderID string, charge *payment.Charge, expectedVersion *int64) (*OrderResponse, error)           // charge is nil when an admin confirms by hand
+	CancelUnpaidOrder(ctx context.Context, orderID, reason string) (*OrderResponse, error)                                                // e.g. after a declined charge
+	CancelOrder(ctx context.Context, orderID, userID, reason string, expectedVersion *int64) (*OrderResponse, error)                      // Owner only, within the cancellation window
+	ExpireStaleOrders(ctx context.Context) (int, error)
+	StartReservationSweeper(ctx context.Context, interval time.Duration) // Runs ExpireStaleOrders until ctx is cancelled
+	FillBackorders(ctx context.Context) (int, error)
+	StartBackorderFiller(ctx context.Context, interval time.Duration) // Runs FillBackorders until ctx is cancelled
+	// RefundPayment is an event.Handler for OrderStatusChanged: it refunds the provider charge
+	// of a paid order once its cancellation or refund has committed.
+	RefundPayment(ctx context.Context, e *event.Event) error
 }
 
 // service implements OrderService.
 type service struct {
 	ordersCollection *mongo.Collection
-	productService   product.ProductService // Dependency on ProductService
+	productService   product.ProductService     // Dependency on ProductService
+	exchangeService  exchange.ExchangeService   // Locks the exchange rate for non-base checkouts
+	inventoryService inventory.InventoryService // Holds stock for unpaid orders
+	warehouseService warehouse.WarehouseService // Decides which warehouse ships each line
+	licenseService   license.LicenseService     // Claims license keys for software products
+	giftCards        giftcard.GiftCardService   // Redeemed at checkout and issued for gift card products
+	storeCredit      storecredit.StoreCreditService
+	loyalty          loyalty.LoyaltyService // Points are redeemed at checkout and earned on delivery
+	stockAlerts      stockalert.StockAlertService
+	payments         payment.Provider // Refunds paid orders that are cancelled or refunded, see RefundPayment
+	events           event.Recorder   // Order events are recorded in the transaction that makes the change
+	reservationTTL   time.Duration
+	cancelWindow     time.Duration
 }
 
 // NewOrderService creates a new order service.
-func NewOrderService(prodService product.ProductService) OrderService {
+func NewOrderService(prodService product.ProductService, exchangeService exchange.ExchangeService, inventoryService inventory.InventoryService, warehouseService warehouse.WarehouseService, licenseService license.LicenseService, giftCards giftcard.GiftCardService, storeCredit storecredit.StoreCreditService, loyaltyService loyalty.LoyaltyService, stockAlerts stockalert.StockAlertService, payments payment.Provider, events event.Recorder, cfg *config.Config) OrderService {
 	return &service{
 		ordersCollection: database.GetCollection("orders"), // Get the 'orders' collection
 		productService:   prodService,
+		exchangeService:  exchangeService,
+		inventoryService: inventoryService,
+		warehouseService: warehouseService,
+		licenseService:   licenseService,
+		giftCards:        giftCards,
+		storeCredit:      storeCredit,
+		loyalty:          loyaltyService,
+		stockAlerts:      stockAlerts,
+		payments:         payments,
+		events:           events,
+		reservationTTL:   cfg.ReservationTTL,
+		cancelWindow:     cfg.CancellationWindow,
 	}
 }
 
-// orderToResponse converts an Order model to an OrderResponse.
+// orderToResponse converts an Order model to an OrderResponse. Guest orders have no user ID.
 func orderToResponse(o *Order) *OrderResponse {
-	return &OrderResponse{
+	resp := &OrderResponse{
 		ID:          o.ID.Hex(),
 		UserID:      o.UserID.Hex(),
-		Items:       o.Items, // OrderItem already has json tags
+		Items:       responseItems(o),
 		TotalAmount: o.TotalAmount,
-		Status:      o.Status,
-		CreatedAt:   o.CreatedAt,
-		UpdatedAt:   o.UpdatedAt,
+		Redemptions: o.Redemptions,
+		AmountDue:   o.AmountDue,
+
+		PointsRedeemed: o.PointsRedeemed,
+		PointsDiscount: o.PointsDiscount,
+		PointsEarned:   o.PointsEarned,
+
+		CurrencyLock: o.CurrencyLock,
+		Status:       o.Status,
+
+		Allocations:     o.Allocations,
+		ShippingRegion:  o.ShippingRegion,
+		ShippingAddress: o.ShippingAddress,
+		CreatedAt:       o.CreatedAt,
+		UpdatedAt:       o.UpdatedAt,
+		Version:         o.Version,
+
+		ReservationExpiresAt: o.ReservationExpiresAt,
+		PaidAt:               o.PaidAt,
+		Payment:              o.Payment,
+		CancelReason:         o.CancelReason,
+		RefundedAt:           o.RefundedAt,
+		History:              o.History,
+		GuestEmail:           o.GuestEmail,
+		ClaimedAt:            o.ClaimedAt,
+	}
+	if o.isGuest() {
+		resp.UserID = ""
+	}
+	return resp
+}
+
+// responseItems returns the order's items, with license keys and gift card codes withheld
+// until the order is paid and after it is refunded.
+func responseItems(o *Order) []OrderItem {
+	if paidStatuses[o.Status] {
+		return o.Items // OrderItem already has json tags
 	}
+	items := make([]OrderItem, len(o.Items))
+	for i, item := range o.Items {
+		item.LicenseKeys = nil
+		item.GiftCardCodes = nil
+		items[i] = item
+	}
+	return items
 }
 
 // CreateOrder handles the creation of a new order.
@@ -59,14 +153,51 @@ func (s *service) CreateOrder(ctx context.Context, userID string, req *CreateOrd
 	if err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
+	return s.createOrder(ctx, userObjectID, "", req)
+}
+
+// CreateGuestOrder places an order without an account for the given email address. Only the
+// holder of the order's access token or a user who later verifies the email can see it.
+func (s *service) CreateGuestOrder(ctx context.Context, req *GuestOrderRequest) (*OrderResponse, error) {
+	if req.UseStoreCredit {
+		return nil, errors.New("store credit requires an account")
+	}
+	if req.RedeemPoints > 0 {
+		return nil, errors.New("loyalty points require an account")
+	}
+	return s.createOrder(ctx, primitive.NilObjectID, normalizeEmail(req.Email), &req.CreateOrderRequest)
+}
+
+// normalizeEmail lowercases and trims an email address so guest orders match the account
+// that claims them however either was typed.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// createOrder places an order for userObjectID, or for a guest when guestEmail is set.
+func (s *service) createOrder(ctx context.Context, userObjectID primitive.ObjectID, guestEmail string, req *CreateOrderRequest) (*OrderResponse, error) {
+	var err error
+
+	// Resolve the checkout currency up front so an unsupported one fails before touching stock.
+	var rate *exchange.ExchangeRate
+	if req.Currency != "" {
+		rate, err = s.exchangeService.GetRate(ctx, req.Currency)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var orderItems []OrderItem
-	var totalAmount float64
+	var totalAmount money.Money
 
 	// Declare 'order' outside the transaction closure
 	var order Order
 	var insertedID primitive.ObjectID // To hold the ID inserted by MongoDB
 
+	// The order ID is generated up front so stock reservations can reference it.
+	orderID := primitive.NewObjectID()
+	reservationExpiresAt := time.Now().Add(s.reservationTTL)
+
 	// Start a MongoDB session for transaction
 	session, err := database.MongoClient.StartSession()
 	if err != nil {
@@ -81,60 +212,113 @@ func (s *service) CreateOrder(ctx context.Context, userID string, req *CreateOrd
 			return err
 		}
 
-		for _, itemReq := range req.Items {
-			productObjID, err := primitive.ObjectIDFromHex(itemReq.ProductID)
-			if err != nil {
-				session.AbortTransaction(sessionContext)
-				return fmt.Errorf("invalid product ID format for item %s", itemReq.ProductID)
-			}
+		var lines []warehouse.Line
+		orderItems, lines, totalAmount, err = s.buildItems(sessionContext, orderID, req.Items, nil)
+		if err != nil {
+			session.AbortTransaction(sessionContext)
+			return err
+		}
 
-			productData, err := s.productService.GetProductForOrder(sessionContext, productObjID.Hex())
-			if err != nil {
+		allocations, err := s.warehouseService.Allocate(sessionContext, lines, req.ShippingRegion)
+		if err != nil {
+			session.AbortTransaction(sessionContext)
+			return err
+		}
+		// Hold the stock rather than deducting it; the holds are committed when the order is paid.
+		for _, a := range allocations {
+			if err := s.inventoryService.Reserve(sessionContext, orderID, a.ProductID, a.WarehouseID, a.Quantity, reservationExpiresAt); err != nil {
 				session.AbortTransaction(sessionContext)
-				return fmt.Errorf("product not found or error retrieving product %s: %v", itemReq.ProductID, err)
+				return fmt.Errorf("failed to reserve stock in warehouse %s: %v", a.WarehouseCode, err)
 			}
+		}
 
-			if productData.Stock < itemReq.Quantity {
+		// Loyalty points are a discount, so they come off the total before anything pays for it.
+		var pointsRedeemed int64
+		var pointsDiscount *money.Money
+		if req.RedeemPoints > 0 {
+			value := s.loyalty.Value(1)
+			if value.IsZero() {
 				session.AbortTransaction(sessionContext)
-				return fmt.Errorf("insufficient stock for product '%s'. Available: %d, Requested: %d",
-					productData.Name, productData.Stock, itemReq.Quantity)
+				return errors.New("loyalty points cannot be redeemed")
+			}
+			// Only the points the total needs are spent, rounded up to a whole point.
+			pointsRedeemed = min(req.RedeemPoints, (totalAmount.Amount+value.Amount-1)/value.Amount)
+			if pointsRedeemed > 0 {
+				if err := s.loyalty.Redeem(sessionContext, userObjectID, orderID, pointsRedeemed); err != nil {
+					session.AbortTransaction(sessionContext)
+					return err
+				}
+				discount := money.Min(s.loyalty.Value(pointsRedeemed), totalAmount)
+				pointsDiscount = &discount
+				totalAmount = totalAmount.Sub(discount)
 			}
+		}
 
-			updateResult, err := database.GetCollection("products").UpdateOne(
-				sessionContext,
-				bson.M{"_id": productObjID, "stock": bson.M{"$gte": itemReq.Quantity}},
-				bson.M{"$inc": bson.M{"stock": -itemReq.Quantity}, "$set": bson.M{"updatedAt": time.Now()}},
-			)
+		// Gift cards are applied first, then store credit, each covering what is still left to pay.
+		due := totalAmount
+		var redemptions []Redemption
+		applied := make(map[string]bool)
+		for _, code := range req.GiftCardCodes {
+			code = giftcard.NormalizeCode(code)
+			if applied[code] || due.IsZero() {
+				continue
+			}
+			applied[code] = true
+			r, err := s.giftCards.Redeem(sessionContext, code, orderID, due)
 			if err != nil {
 				session.AbortTransaction(sessionContext)
-				return fmt.Errorf("failed to deduct stock for product %s: %v", productData.Name, err)
+				return err
 			}
-			if updateResult.MatchedCount == 0 || updateResult.ModifiedCount == 0 {
+			redemptions = append(redemptions, Redemption{Type: RedemptionGiftCard, GiftCardID: &r.GiftCardID, Code: r.Code, Amount: r.Amount})
+			due = due.Sub(r.Amount)
+		}
+		if req.UseStoreCredit && !due.IsZero() {
+			credit, err := s.storeCredit.Redeem(sessionContext, userObjectID, orderID, due)
+			if err != nil {
 				session.AbortTransaction(sessionContext)
-				return fmt.Errorf("failed to deduct stock for product %s (concurrent modification or insufficient stock after check)", productData.Name)
+				return err
+			}
+			if !credit.IsZero() {
+				redemptions = append(redemptions, Redemption{Type: RedemptionStoreCredit, Amount: credit})
+				due = due.Sub(credit)
 			}
-
-			itemSubtotal := productData.Price * float64(itemReq.Quantity)
-			orderItems = append(orderItems, OrderItem{
-				ProductID: productData.ID,
-				Name:      productData.Name,
-				SKU:       productData.SKU,
-				Quantity:  itemReq.Quantity,
-				Price:     productData.Price,
-				Subtotal:  itemSubtotal,
-			})
-			totalAmount += itemSubtotal
 		}
 
 		now := time.Now()
 		// Assign to the 'order' variable declared outside
 		order = Order{ // Note: assignment using '=' not ':=', and it's a struct, not a pointer initially
+			ID:          orderID,
 			UserID:      userObjectID,
 			Items:       orderItems,
 			TotalAmount: totalAmount,
 			Status:      StatusPending,
 			CreatedAt:   now,
 			UpdatedAt:   now,
+			Version:     1,
+
+			Allocations:          allocations,
+			ShippingRegion:       req.ShippingRegion,
+			ShippingAddress:      req.ShippingAddress,
+			ReservationExpiresAt: &reservationExpiresAt,
+
+			PointsRedeemed: pointsRedeemed,
+			PointsDiscount: pointsDiscount,
+			GuestEmail:     guestEmail,
+		}
+		if len(allocations) == 0 && order.hasBackorders() {
+			order.ReservationExpiresAt = nil // Nothing is held yet, so there is no deadline to pay
+		}
+		if len(redemptions) > 0 {
+			order.Redemptions = redemptions
+			order.AmountDue = &due
+		}
+		if rate != nil && rate.Currency != totalAmount.Currency {
+			order.CurrencyLock = &CurrencyLock{
+				Currency: rate.Currency,
+				Rate:     rate.Rate,
+				Total:    exchange.Apply(due, rate), // What the customer still pays, in their currency
+				LockedAt: now,
+			}
 		}
 
 		result, err := s.ordersCollection.InsertOne(sessionContext, &order) // Pass pointer for insertion
@@ -146,6 +330,11 @@ func (s *service) CreateOrder(ctx context.Context, userID string, req *CreateOrd
 
 		insertedID = result.InsertedID.(primitive.ObjectID) // Store the inserted ID
 
+		if err = s.events.Record(sessionContext, event.OrderCreated, insertedID.Hex(), orderCreatedData(&order)); err != nil {
+			session.AbortTransaction(sessionContext)
+			return err
+		}
+
 		if err = session.CommitTransaction(sessionContext); err != nil {
 			log.Printf("Error committing transaction: %v", err)
 			return errors.New("failed to finalize order (transaction commit failed)")
@@ -164,10 +353,329 @@ func (s *service) CreateOrder(ctx context.Context, userID string, req *CreateOrd
 	// For simplicity and to avoid another DB call if the transaction was complex:
 	// Set the ID back to the order object.
 	order.ID = insertedID
+	s.stockAlerts.StockChanged(order.productIDs()...)
+
+	// Nothing is left to pay when gift cards and store credit covered the whole total.
+	if order.AmountDue != nil && order.AmountDue.IsZero() {
+		paid, err := s.ConfirmPayment(ctx, order.ID.Hex(), nil, nil)
+		if err != nil {
+			// The order stays pending and can still be confirmed by an admin.
+			log.Printf("Error confirming fully redeemed order %s: %v", order.ID.Hex(), err)
+			return orderToResponse(&order), nil
+		}
+		return paid, nil
+	}
 
 	return orderToResponse(&order), nil // Return the 'order' converted to response
 }
 
+// orderCreatedData describes a newly placed order for an OrderCreated event.
+func orderCreatedData(o *Order) event.OrderCreatedData {
+	data := event.OrderCreatedData{
+		OrderID:     o.ID.Hex(),
+		GuestEmail:  o.GuestEmail,
+		Status:      o.Status,
+		TotalAmount: o.TotalAmount,
+		Items:       make([]event.ItemData, 0, len(o.Items)),
+	}
+	if !o.isGuest() {
+		data.UserID = o.UserID.Hex()
+	}
+	for _, item := range o.Items {
+		data.Items = append(data.Items, event.ItemData{ProductID: item.ProductID.Hex(), Quantity: item.Quantity})
+	}
+	return data
+}
+
+// buildItems prices the requested lines in the base currency, claims license keys for them and
+// counts what stock cannot cover as backordered. It returns the order items, the stock lines
+// to allocate and the total. Lines for products in keep are charged the price they were
+// ordered at.
+func (s *service) buildItems(ctx context.Context, orderID primitive.ObjectID, reqItems []OrderItemRequest, keep map[primitive.ObjectID]OrderItem) ([]OrderItem, []warehouse.Line, money.Money, error) {
+	var orderItems []OrderItem
+	var lines []warehouse.Line
+	totalAmount := money.Zero(s.exchangeService.BaseCurrency())
+	for _, itemReq := range reqItems {
+		productObjID, err := primitive.ObjectIDFromHex(itemReq.ProductID)
+		if err != nil {
+			return nil, nil, totalAmount, fmt.Errorf("invalid product ID format for item %s", itemReq.ProductID)
+		}
+
+		productData, err := s.productService.GetProductForOrder(ctx, productObjID.Hex())
+		if err != nil {
+			return nil, nil, totalAmount, fmt.Errorf("product not found or error retrieving product %s: %v", itemReq.ProductID, err)
+		}
+
+		// A bundle ships its components, so stock is checked and held for those instead.
+		var components []OrderItemComponent
+		var licenseKeys []string
+		var backordered int
+		var preorder bool
+		if productData.Digital {
+			// Downloads need no stock or warehouse, only a file to deliver. Gift cards
+			// need neither; their codes are issued once the order is paid.
+			if productData.File == nil && !productData.GiftCard {
+				return nil, nil, totalAmount, fmt.Errorf("product '%s' is not available for download yet", productData.Name)
+			}
+			// Software sold with license keys is limited by its key pool instead.
+			if productData.LicenseKeys {
+				licenseKeys, err = s.licenseService.Claim(ctx, productData.ID, orderID, itemReq.Quantity)
+				if err != nil {
+					if errors.Is(err, license.ErrPoolEmpty) {
+						return nil, nil, totalAmount, fmt.Errorf("insufficient stock for product '%s': not enough license keys left. Requested: %d",
+							productData.Name, itemReq.Quantity)
+					}
+					return nil, nil, totalAmount, err
+				}
+			}
+		} else if productData.IsBundle() {
+			parts, err := s.productService.GetBundleComponents(ctx, productData)
+			if err != nil {
+				return nil, nil, totalAmount, err
+			}
+			for i, c := range productData.Components {
+				part, need := parts[i], c.Quantity*itemReq.Quantity
+				if part.Available() < need {
+					return nil, nil, totalAmount, fmt.Errorf("insufficient stock for product '%s' in bundle '%s'. Available: %d, Requested: %d",
+						part.Name, productData.Name, part.Available(), need)
+				}
+				lines = append(lines, warehouse.Line{ProductID: part.ID, Quantity: need})
+				components = append(components, OrderItemComponent{ProductID: part.ID, Name: part.Name, SKU: part.SKU, Quantity: need})
+			}
+		} else {
+			// What stock cannot cover is backordered, if the product allows it; before its
+			// release date everything is.
+			preorder = productData.IsPreorder(time.Now())
+			if preorder {
+				backordered = itemReq.Quantity
+			} else if available := productData.Available(); available < itemReq.Quantity {
+				backordered = itemReq.Quantity - available
+			}
+			if backordered > 0 {
+				if !productData.CanBackorder(backordered, time.Now()) {
+					return nil, nil, totalAmount, fmt.Errorf("insufficient stock for product '%s'. Available: %d, Requested: %d",
+						productData.Name, productData.Available(), itemReq.Quantity)
+				}
+				if err := s.inventoryService.AddBackorder(ctx, productData.ID, backordered, productData.BackorderLimit); err != nil {
+					return nil, nil, totalAmount, err
+				}
+			}
+			if inStock := itemReq.Quantity - backordered; inStock > 0 {
+				lines = append(lines, warehouse.Line{ProductID: productData.ID, Quantity: inStock})
+			}
+		}
+
+		if productData.Price.Currency != totalAmount.Currency {
+			return nil, nil, totalAmount, fmt.Errorf("product '%s' is priced in %s, not the store base currency %s",
+				productData.Name, productData.Price.Currency, totalAmount.Currency)
+		}
+		// Charge the sale price running for the customer's segment right now, if any.
+		price, sale := productData.EffectivePrice(time.Now(), product.SegmentFromContext(ctx))
+		var regularPrice *money.Money
+		if sale != nil {
+			regular := productData.Price
+			regularPrice = &regular
+		}
+		if kept, ok := keep[productData.ID]; ok {
+			price, regularPrice = kept.Price, kept.RegularPrice
+		}
+		itemSubtotal := price.Mul(itemReq.Quantity)
+		orderItems = append(orderItems, OrderItem{
+			ProductID:    productData.ID,
+			Name:         productData.Name,
+			SKU:          productData.SKU,
+			Quantity:     itemReq.Quantity,
+			CategoryID:   productData.CategoryID,
+			Price:        price,
+			Subtotal:     itemSubtotal,
+			RegularPrice: regularPrice,
+
+			Components:  components,
+			Digital:     productData.Digital,
+			LicenseKeys: licenseKeys,
+			GiftCard:    productData.GiftCard,
+			Backordered: backordered,
+			Preorder:    preorder,
+		})
+		totalAmount = totalAmount.Add(itemSubtotal)
+	}
+	return orderItems, lines, totalAmount, nil
+}
+
+// CreateOrderFromAvailable places an order, at current prices, for the lines of req that can be
+// filled right now and reports the others: products that can no longer be ordered and lines
+// that stock, backorder limits or license keys cannot cover. If no line can be filled,
+// nothing is ordered.
+func (s *service) CreateOrderFromAvailable(ctx context.Context, userID string, req *CreateOrderRequest) (*PartialOrderResponse, error) {
+	var items []OrderItemRequest
+	skipped := make([]SkippedItem, 0)
+	for _, item := range req.Items {
+		p, err := s.productService.GetProductForOrder(ctx, item.ProductID)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "database error") {
+				return nil, err
+			}
+			skipped = append(skipped, SkippedItem{ProductID: item.ProductID, Quantity: item.Quantity, Reason: err.Error()})
+			continue
+		}
+		reason, err := s.unavailableReason(ctx, p, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			skipped = append(skipped, SkippedItem{ProductID: item.ProductID, Name: p.Name, Quantity: item.Quantity, Reason: reason})
+			continue
+		}
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil, errors.New("none of the items can be ordered right now")
+	}
+
+	available := *req
+	available.Items = items
+	orderResp, err := s.CreateOrder(ctx, userID, &available)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialOrderResponse{Order: orderResp, Skipped: skipped}, nil
+}
+
+// unavailableReason returns why quantity units of p cannot be ordered right now, or "" if
+// they can. It checks what CreateOrder would, without holding anything.
+func (s *service) unavailableReason(ctx context.Context, p *product.Product, quantity int) (string, error) {
+	now := time.Now()
+	switch {
+	case p.Digital:
+		if p.File == nil && !p.GiftCard {
+			return "not available for download yet", nil
+		}
+		if p.LicenseKeys {
+			pool, err := s.licenseService.GetPoolSummary(ctx, p.ID.Hex())
+			if err != nil {
+				return "", err
+			}
+			if pool.Available < quantity {
+				return fmt.Sprintf("not enough license keys left (%d available)", pool.Available), nil
+			}
+		}
+	case p.IsBundle():
+		parts, err := s.productService.GetBundleComponents(ctx, p)
+		if err != nil {
+			return "", err
+		}
+		for i, c := range p.Components {
+			if parts[i].Available() < c.Quantity*quantity {
+				return fmt.Sprintf("'%s' in the bundle is out of stock", parts[i].Name), nil
+			}
+		}
+	default:
+		shortfall := quantity - p.Available()
+		if p.IsPreorder(now) {
+			shortfall = quantity
+		}
+		if shortfall > 0 && !p.CanBackorder(shortfall, now) {
+			if p.Available() <= 0 {
+				return "out of stock", nil
+			}
+			return fmt.Sprintf("only %d in stock", p.Available()), nil
+		}
+	}
+	return "", nil
+}
+
+// Reorder orders the items of one of the user's past orders again at current prices, shipped
+// like the past order unless req says otherwise. Lines that cannot be filled are reported
+// (see CreateOrderFromAvailable).
+func (s *service) Reorder(ctx context.Context, orderID, userID string, req *ReorderRequest) (*PartialOrderResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID format")
+	}
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+
+	var past Order
+	if err := s.ordersCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&past); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("order not found")
+		}
+		log.Printf("Error finding order to reorder: %v", err)
+		return nil, errors.New("database error retrieving order")
+	}
+	if past.UserID != userObjectID {
+		return nil, errors.New("access denied")
+	}
+
+	// The same product on several lines is ordered as one line.
+	var items []OrderItemRequest
+	names := make(map[string]string)
+	index := make(map[string]int)
+	for _, item := range past.Items {
+		id := item.ProductID.Hex()
+		if i, ok := index[id]; ok {
+			items[i].Quantity += item.Quantity
+			continue
+		}
+		index[id] = len(items)
+		names[id] = item.Name
+		items = append(items, OrderItemRequest{ProductID: id, Quantity: item.Quantity})
+	}
+
+	orderReq := &CreateOrderRequest{
+		Items:           items,
+		Currency:        req.Currency,
+		ShippingRegion:  req.ShippingRegion,
+		ShippingAddress: req.ShippingAddress,
+	}
+	if orderReq.Currency == "" && past.CurrencyLock != nil {
+		orderReq.Currency = past.CurrencyLock.Currency
+	}
+	if orderReq.ShippingRegion == "" {
+		orderReq.ShippingRegion = past.ShippingRegion
+	}
+	if orderReq.ShippingAddress == nil {
+		orderReq.ShippingAddress = past.ShippingAddress
+	}
+
+	result, err := s.CreateOrderFromAvailable(ctx, userID, orderReq)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Skipped {
+		if result.Skipped[i].Name == "" {
+			result.Skipped[i].Name = names[result.Skipped[i].ProductID] // Products gone from the catalog
+		}
+	}
+	return result, nil
+}
+
+// ClaimGuestOrders hands every unclaimed guest order placed with email to the user, once the
+// user has verified that they own it. It returns the number of orders claimed.
+func (s *service) ClaimGuestOrders(ctx context.Context, email, userID string) (int, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, errors.New("invalid user ID format")
+	}
+
+	now := time.Now()
+	res, err := s.ordersCollection.UpdateMany(ctx,
+		bson.M{"guestEmail": normalizeEmail(email), "userID": primitive.NilObjectID},
+		bson.M{
+			"$set":  bson.M{"userID": userObjectID, "claimedAt": now, "updatedAt": now},
+			"$inc":  bson.M{"version": 1},
+			"$push": bson.M{"history": HistoryEntry{Type: HistoryClaimed, At: now, By: &userObjectID}},
+		},
+	)
+	if err != nil {
+		log.Printf("Error claiming guest orders: %v", err)
+		return 0, errors.New("failed to claim guest orders")
+	}
+	return int(res.ModifiedCount), nil
+}
+
 // GetUserOrders retrieves all orders for a specific user.
 func (s *service) GetUserOrders(ctx context.Context, userID string) ([]OrderResponse, error) {
 	userObjID, err := primitive.ObjectIDFromHex(userID)
@@ -240,37 +748,759 @@ func (s *service) GetAllOrders(ctx context.Context) ([]OrderResponse, error) {
 }
 
 // UpdateOrderStatus updates the status of an order.
-func (s *service) UpdateOrderStatus(ctx context.Context, orderID string, req *UpdateOrderStatusRequest) (*OrderResponse, error) {
+// When expectedVersion is set, the update only applies if the order is still at that version.
+// Leaving "pending" settles the order's stock holds: cancelling releases them, any other
+// status commits them as if the order had been paid. Refunding revokes the order's license keys.
+// Delivering earns loyalty points; cancelling, returning or refunding reverses them.
+func (s *service) UpdateOrderStatus(ctx context.Context, orderID string, req *UpdateOrderStatusRequest, expectedVersion *int64) (*OrderResponse, error) {
 	objID, err := primitive.ObjectIDFromHex(orderID)
 	if err != nil {
 		return nil, errors.New("invalid order ID format")
 	}
 
-	update := bson.M{
-		"status":    req.Status,
-		"updatedAt": time.Now(),
+	updated, err := s.transition(ctx, objID, expectedVersion, req.Status, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return orderToResponse(updated), nil
+}
+
+// ConfirmPayment marks a pending order as paid, turning its stock holds into deductions
+// and moving it to "processing". charge, if set, is recorded on the order.
+func (s *service) ConfirmPayment(ctx context.Context, orderID string, charge *payment.Charge, expectedVersion *int64) (*OrderResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID format")
+	}
+
+	now := time.Now()
+	set := bson.M{"paidAt": now}
+	if charge != nil {
+		set["payment"] = charge
+	}
+	updated, err := s.transition(ctx, objID, expectedVersion, StatusProcessing, set, func(o *Order) error {
+		if o.Status != StatusPending {
+			return errors.New("order is not awaiting payment")
+		}
+		if o.ReservationExpiresAt != nil && now.After(*o.ReservationExpiresAt) {
+			return errors.New("order reservation has expired")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderToResponse(updated), nil
+}
+
+// CancelUnpaidOrder cancels a pending order with reason, releasing its stock holds.
+func (s *service) CancelUnpaidOrder(ctx context.Context, orderID, reason string) (*OrderResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID format")
+	}
+
+	updated, err := s.transition(ctx, objID, nil, StatusCancelled, bson.M{"cancelReason": reason}, func(o *Order) error {
+		if o.Status != StatusPending {
+			return errors.New("order is no longer pending")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderToResponse(updated), nil
+}
+
+// CancelOrder cancels one of the user's orders at their request, with reason, while it is
+// pending or processing and within the cancellation window. A pending order's stock holds are
+// released; a paid one is restocked and refunded (see transition and RefundPayment).
+func (s *service) CancelOrder(ctx context.Context, orderID, userID, reason string, expectedVersion *int64) (*OrderResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID format")
+	}
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+
+	updated, err := s.transition(ctx, objID, expectedVersion, StatusCancelled, bson.M{"cancelReason": reason}, func(o *Order) error {
+		if o.UserID != userObjectID {
+			return errors.New("access denied")
+		}
+		if o.Status != StatusPending && o.Status != StatusProcessing {
+			return errors.New("order can no longer be cancelled")
+		}
+		if time.Since(o.CreatedAt) > s.cancelWindow {
+			return errors.New("cancellation window has closed")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderToResponse(updated), nil
+}
+
+// RefundPayment refunds the provider charge of an order that was refunded, or paid
+// (processing) and then cancelled. It runs from the outbox, so the refund is only issued once
+// the status change has committed, and a failed refund is retried by the event bus. The charge
+// is marked refunded afterwards so a retry does not refund it twice. Orders paid by hand are
+// left to an admin.
+func (s *service) RefundPayment(ctx context.Context, e *event.Event) error {
+	var data event.OrderStatusChangedData
+	if err := e.Decode(&data); err != nil {
+		return fmt.Errorf("failed to decode event: %w", err)
+	}
+	if data.To != StatusRefunded && (data.From != StatusProcessing || data.To != StatusCancelled) {
+		return nil
+	}
+	objID, err := primitive.ObjectIDFromHex(data.OrderID)
+	if err != nil {
+		return fmt.Errorf("invalid order ID %q", data.OrderID)
+	}
+
+	var o Order
+	if err := s.ordersCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&o); err != nil {
+		return fmt.Errorf("failed to load order %s: %w", data.OrderID, err)
+	}
+	if o.Payment == nil {
+		if !o.amountDue().IsZero() {
+			log.Printf("Order %s was paid by hand; refund %s to the customer manually", data.OrderID, o.amountDue())
+		}
+		return nil
+	}
+	if o.Payment.RefundedAt != nil {
+		return nil // Refunded on an earlier attempt
 	}
 
-	result := s.ordersCollection.FindOneAndUpdate(
-		ctx,
+	if err := s.payments.Refund(ctx, o.Payment); err != nil {
+		log.Printf("Error refunding charge %s of order %s: %v", o.Payment.ID, data.OrderID, err)
+		return errors.New("failed to refund payment")
+	}
+	_, err = s.ordersCollection.UpdateOne(ctx,
 		bson.M{"_id": objID},
-		bson.M{"$set": update},
-		options.FindOneAndUpdate().SetReturnDocument(options.After),
+		bson.M{"$set": bson.M{"payment.refundedAt": time.Now()}},
 	)
+	if err != nil {
+		// The refund went through; a retry would refund again, so only log it.
+		log.Printf("Error marking charge %s of order %s refunded: %v", o.Payment.ID, data.OrderID, err)
+	}
+	return nil
+}
 
-	if result.Err() != nil {
-		if result.Err() == mongo.ErrNoDocuments {
-			return nil, errors.New("order not found")
+// ExpireStaleOrders releases every stock hold past its expiry and cancels the pending
+// orders they belonged to. It returns the number of orders cancelled.
+func (s *service) ExpireStaleOrders(ctx context.Context) (int, error) {
+	orderIDs, err := s.inventoryService.ExpiredOrderIDs(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, id := range orderIDs {
+		_, err := s.transition(ctx, id, nil, StatusCancelled, bson.M{"cancelReason": "reservation expired"}, func(o *Order) error {
+			if o.Status != StatusPending {
+				return errors.New("order is no longer pending")
+			}
+			return nil
+		})
+		if err != nil {
+			// A concurrent payment or admin update wins; the next sweep picks up anything left.
+			log.Printf("Skipping expiry of order %s: %v", id.Hex(), err)
+			continue
 		}
-		log.Printf("Error updating order status: %v", result.Err())
-		return nil, errors.New("failed to update order status")
+		cancelled++
 	}
+	return cancelled, nil
+}
+
+// StartReservationSweeper runs ExpireStaleOrders on every tick of interval, in a background goroutine.
+func (s *service) StartReservationSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			sweepCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			if n, err := s.ExpireStaleOrders(sweepCtx); err != nil {
+				log.Printf("Reservation sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Reservation sweep cancelled %d unpaid orders", n)
+			}
+			cancel()
+		}
+	}()
+}
 
-	var updatedOrder Order
-	if err := result.Decode(&updatedOrder); err != nil {
-		log.Printf("Error decoding updated order: %v", err)
-		return nil, errors.New("failed to decode updated order data")
+// AmendOrder changes the lines or shipping address of a pending order for its owner. New
+// lines replace the old ones in one transaction: the order's stock holds, license keys and
+// backorders are released and taken again for the new lines, so stock moves in whichever
+// direction the quantities did. Products already in the order keep the price they were
+// ordered at and the total is recalculated at the order's locked exchange rate. The
+// amendment is added to the order's history.
+func (s *service) AmendOrder(ctx context.Context, orderID, userID string, req *AmendOrderRequest, expectedVersion *int64) (*OrderResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID format")
+	}
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
 	}
+	if req.Items == nil && req.ShippingAddress == nil {
+		return nil, errors.New("nothing to amend: set items or shippingAddress")
+	}
+
+	var before, updated Order
+	err = database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		before = Order{} // The transaction may be retried
+		err := s.ordersCollection.FindOne(sessionContext, database.WithVersion(bson.M{"_id": objID}, expectedVersion)).Decode(&before)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				if expectedVersion != nil {
+					if count, err := s.ordersCollection.CountDocuments(sessionContext, bson.M{"_id": objID}); err == nil && count > 0 {
+						return errors.New("version mismatch")
+					}
+				}
+				return errors.New("order not found")
+			}
+			log.Printf("Error finding order for amendment: %v", err)
+			return errors.New("failed to amend order")
+		}
+
+		now := time.Now()
+		if before.UserID != userObjectID {
+			return errors.New("access denied")
+		}
+		if before.Status != StatusPending {
+			return errors.New("order can no longer be amended")
+		}
+		if before.ReservationExpiresAt != nil && now.After(*before.ReservationExpiresAt) {
+			return errors.New("order reservation has expired")
+		}
+
+		entry := HistoryEntry{Type: HistoryAmended, At: now, By: &userObjectID}
+		set := bson.M{"updatedAt": now}
+		unset := bson.M{}
+		if req.ShippingAddress != nil {
+			entry.PreviousAddress = before.ShippingAddress
+			set["shippingAddress"] = req.ShippingAddress
+		}
+		if req.Items != nil {
+			// What was paid with gift cards, store credit or points was worked out for the
+			// old total.
+			if len(before.Redemptions) > 0 || before.PointsRedeemed > 0 {
+				return errors.New("order cannot be amended: gift cards, store credit or loyalty points were applied to it")
+			}
 
-	return orderToResponse(&updatedOrder), nil
+			if err := s.inventoryService.Release(sessionContext, objID, "order amended"); err != nil {
+				return err
+			}
+			if err := s.licenseService.Release(sessionContext, objID); err != nil {
+				return err
+			}
+			if _, _, err := s.releaseBackorders(sessionContext, &before); err != nil {
+				return err
+			}
+
+			keep := make(map[primitive.ObjectID]OrderItem, len(before.Items))
+			for _, item := range before.Items {
+				keep[item.ProductID] = item
+			}
+			items, lines, totalAmount, err := s.buildItems(sessionContext, objID, req.Items, keep)
+			if err != nil {
+				return err
+			}
+			allocations, err := s.warehouseService.Allocate(sessionContext, lines, before.ShippingRegion)
+			if err != nil {
+				return err
+			}
+			// Amending does not buy more time to pay.
+			expiresAt := now.Add(s.reservationTTL)
+			if before.ReservationExpiresAt != nil {
+				expiresAt = *before.ReservationExpiresAt
+			}
+			for _, a := range allocations {
+				if err := s.inventoryService.Reserve(sessionContext, objID, a.ProductID, a.WarehouseID, a.Quantity, expiresAt); err != nil {
+					return fmt.Errorf("failed to reserve stock in warehouse %s: %v", a.WarehouseCode, err)
+				}
+			}
+
+			after := Order{Items: items}
+			set["items"] = items
+			set["totalAmount"] = totalAmount
+			set["allocations"] = allocations
+			if len(allocations) == 0 && after.hasBackorders() {
+				unset["reservationExpiresAt"] = ""
+			} else {
+				set["reservationExpiresAt"] = expiresAt
+			}
+			if lock := before.CurrencyLock; lock != nil {
+				set["currencyLock.total"] = exchange.Apply(totalAmount, &exchange.ExchangeRate{Currency: lock.Currency, Rate: lock.Rate})
+			}
+			entry.Items = itemChanges(&before, &after)
+			entry.TotalBefore = &before.TotalAmount
+			entry.TotalAfter = &totalAmount
+		}
+
+		change := bson.M{"$set": set, "$inc": bson.M{"version": 1}, "$push": bson.M{"history": entry}}
+		if len(unset) > 0 {
+			change["$unset"] = unset
+		}
+		err = s.ordersCollection.FindOneAndUpdate(
+			sessionContext,
+			database.WithVersion(bson.M{"_id": objID}, &before.Version),
+			change,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return errors.New("version mismatch")
+			}
+			log.Printf("Error amending order: %v", err)
+			return errors.New("failed to amend order")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.Items != nil {
+		s.stockAlerts.StockChanged(append(before.productIDs(), updated.productIDs()...)...)
+	}
+	return orderToResponse(&updated), nil
+}
+
+// itemChanges returns the products whose ordered quantity differs between before and after,
+// in the order they first appear.
+func itemChanges(before, after *Order) []ItemChange {
+	var changes []ItemChange
+	index := make(map[primitive.ObjectID]int)
+	for _, item := range before.Items {
+		if i, ok := index[item.ProductID]; ok {
+			changes[i].From += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(changes)
+		changes = append(changes, ItemChange{ProductID: item.ProductID, Name: item.Name, From: item.Quantity})
+	}
+	for _, item := range after.Items {
+		i, ok := index[item.ProductID]
+		if !ok {
+			i = len(changes)
+			index[item.ProductID] = i
+			changes = append(changes, ItemChange{ProductID: item.ProductID, Name: item.Name})
+		}
+		changes[i].To += item.Quantity
+	}
+	result := changes[:0]
+	for _, c := range changes {
+		if c.From != c.To {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// FillBackorders reserves newly available stock for backordered lines, oldest order first,
+// once their product is released. It returns the number of order lines it filled, wholly or
+// in part.
+func (s *service) FillBackorders(ctx context.Context) (int, error) {
+	products, err := s.productService.GetBackorderedProducts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	filled := 0
+	for i := range products {
+		n, err := s.fillBackorders(ctx, &products[i])
+		filled += n
+		if err != nil {
+			// Usually stock taken by a concurrent checkout; the next run continues from here.
+			log.Printf("Stopped filling backorders of product %s: %v", products[i].ID.Hex(), err)
+		}
+	}
+	return filled, nil
+}
+
+// fillBackorders hands a product's available stock to its backordered lines, oldest order first.
+func (s *service) fillBackorders(ctx context.Context, p *product.Product) (int, error) {
+	cursor, err := s.ordersCollection.Find(ctx, bson.M{
+		"items":  bson.M{"$elemMatch": bson.M{"productID": p.ID, "backordered": bson.M{"$gt": 0}}},
+		"status": bson.M{"$nin": bson.A{StatusCancelled, StatusReturned, StatusRefunded}},
+	}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding backordered orders: %v", err)
+		return 0, errors.New("failed to retrieve backordered orders")
+	}
+	var orders []Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		log.Printf("Error decoding backordered orders: %v", err)
+		return 0, errors.New("failed to process order data")
+	}
+
+	available, filled := p.Unreserved(), 0 // New orders leave the backordered units alone
+	for i := range orders {
+		if available == 0 {
+			break
+		}
+		n, err := s.fillOrderBackorders(ctx, &orders[i], p.ID, available)
+		if n > 0 {
+			filled++
+			available -= n
+		}
+		if err != nil {
+			return filled, err
+		}
+	}
+	if filled > 0 {
+		s.stockAlerts.StockChanged(p.ID)
+	}
+	return filled, nil
+}
+
+// fillOrderBackorders allocates and holds up to max units for an order's backordered lines of
+// a product and returns how many it filled. Holds for paid orders are committed straight away;
+// unpaid orders get until their reservation expiry (or RESERVATION_TTL) to pay.
+func (s *service) fillOrderBackorders(ctx context.Context, o *Order, productID primitive.ObjectID, max int) (int, error) {
+	items := make([]OrderItem, len(o.Items))
+	copy(items, o.Items)
+	quantity := 0
+	for i := range items {
+		if items[i].ProductID == productID && items[i].Backordered > 0 && quantity < max {
+			n := min(items[i].Backordered, max-quantity)
+			items[i].Backordered -= n
+			quantity += n
+		}
+	}
+	if quantity == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.reservationTTL)
+	if o.ReservationExpiresAt != nil && o.ReservationExpiresAt.After(now) {
+		expiresAt = *o.ReservationExpiresAt
+	}
+	err := database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		allocations, err := s.warehouseService.Allocate(sessionContext, []warehouse.Line{{ProductID: productID, Quantity: quantity}}, o.ShippingRegion)
+		if err != nil {
+			return err
+		}
+		for _, a := range allocations {
+			if err := s.inventoryService.Reserve(sessionContext, o.ID, a.ProductID, a.WarehouseID, a.Quantity, expiresAt); err != nil {
+				return err
+			}
+		}
+		if o.Status != StatusPending {
+			if err := s.inventoryService.Commit(sessionContext, o.ID); err != nil {
+				return err
+			}
+		}
+		if err := s.inventoryService.RemoveBackorder(sessionContext, productID, quantity); err != nil {
+			return err
+		}
+
+		set := bson.M{"items": items, "allocations": append(o.Allocations, allocations...), "updatedAt": now}
+		if o.Status == StatusPending {
+			set["reservationExpiresAt"] = expiresAt
+		}
+		res, err := s.ordersCollection.UpdateOne(sessionContext,
+			database.WithVersion(bson.M{"_id": o.ID}, &o.Version),
+			bson.M{"$set": set, "$inc": bson.M{"version": 1}},
+		)
+		if err != nil {
+			log.Printf("Error updating backordered order %s: %v", o.ID.Hex(), err)
+			return errors.New("failed to update order")
+		}
+		if res.MatchedCount == 0 {
+			return errors.New("version mismatch")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return quantity, nil
+}
+
+// StartBackorderFiller runs FillBackorders on every tick of interval, in a background goroutine.
+func (s *service) StartBackorderFiller(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			fillCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			if n, err := s.FillBackorders(fillCtx); err != nil {
+				log.Printf("Backorder fill failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Filled %d backordered order lines", n)
+			}
+			cancel()
+		}
+	}()
+}
+
+// transition moves an order to status inside a transaction, settling its stock holds, license
+// keys and gift card or store credit redemptions on the way out of "pending", and revoking the
+// keys, disabling bought gift cards and restoring redemptions on refund. Cancelling a paid order
+// that has not shipped does the same, puts its stock back and refunds its payment. Loyalty
+// points are earned on delivery and reversed on cancellation, return or refund. check, if set,
+// can veto the change after the current order is loaded; set holds extra fields to write
+// alongside the status.
+func (s *service) transition(ctx context.Context, objID primitive.ObjectID, expectedVersion *int64, status string, set bson.M, check func(*Order) error) (*Order, error) {
+	var updated Order
+	err := database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		var current Order
+		err := s.ordersCollection.FindOne(sessionContext, database.WithVersion(bson.M{"_id": objID}, expectedVersion)).Decode(&current)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				if expectedVersion != nil {
+					// Distinguish a missing order from one that moved past the expected version.
+					if count, err := s.ordersCollection.CountDocuments(sessionContext, bson.M{"_id": objID}); err == nil && count > 0 {
+						return errors.New("version mismatch")
+					}
+				}
+				return errors.New("order not found")
+			}
+			log.Printf("Error finding order for status update: %v", err)
+			return errors.New("failed to update order status")
+		}
+
+		if check != nil {
+			if err := check(&current); err != nil {
+				return err
+			}
+		}
+		if current.Status != status {
+			// Stock holds are gone once an order leaves pending, so it cannot go back; returns
+			// only apply to delivered orders and can then only be refunded; refunds are final and
+			// only apply to orders that were paid.
+			if current.Status == StatusCancelled || current.Status == StatusRefunded || status == StatusPending ||
+				(current.Status == StatusReturned && status != StatusRefunded) ||
+				(status == StatusReturned && current.Status != StatusDelivered) ||
+				(status == StatusRefunded && !paidStatuses[current.Status] && current.Status != StatusReturned) {
+				return fmt.Errorf("invalid status transition from %s to %s", current.Status, status)
+			}
+		}
+
+		if current.Status == StatusPending && status != StatusPending {
+			if status == StatusCancelled {
+				reason := "order cancelled"
+				if r, ok := set["cancelReason"].(string); ok {
+					reason = r
+				}
+				err = s.inventoryService.Release(sessionContext, objID, reason)
+				if err == nil {
+					err = s.licenseService.Release(sessionContext, objID) // Unpaid keys go back to the pool
+				}
+				if err == nil {
+					err = s.restoreRedemptions(sessionContext, objID)
+				}
+			} else {
+				err = s.inventoryService.Commit(sessionContext, objID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		update := bson.M{"status": status, "updatedAt": now}
+		switch {
+		case status == StatusCancelled || status == StatusReturned || status == StatusRefunded:
+			// Points spent on the order are given back and points earned on it taken back.
+			if err := s.loyalty.ReverseForOrder(sessionContext, objID); err != nil {
+				return err
+			}
+			// Units still waiting for stock will not be needed any more.
+			items, released, err := s.releaseBackorders(sessionContext, &current)
+			if err != nil {
+				return err
+			}
+			if released {
+				update["items"] = items
+			}
+		case status == StatusDelivered && current.PointsEarned == 0 && !current.isGuest():
+			points := s.loyalty.PointsFor(earnLines(&current))
+			if err := s.loyalty.Earn(sessionContext, current.UserID, objID, points); err != nil {
+				return err
+			}
+			if points > 0 {
+				update["pointsEarned"] = points
+			}
+		}
+		if current.Status == StatusPending && paidStatuses[status] {
+			items, issued, err := s.issueGiftCards(sessionContext, &current)
+			if err != nil {
+				return err
+			}
+			if issued {
+				update["items"] = items
+			}
+		}
+		if status == StatusCancelled && current.Status == StatusProcessing {
+			// Paid but not shipped: the stock goes back on the shelf and everything paid is
+			// given back, like a refund. The payment itself is refunded once this commits, by
+			// RefundPayment.
+			reason := "order cancelled"
+			if r, ok := set["cancelReason"].(string); ok {
+				reason = r
+			}
+			err := s.inventoryService.Restock(sessionContext, objID, reason)
+			if err == nil {
+				err = s.licenseService.Revoke(sessionContext, objID)
+			}
+			if err == nil {
+				err = s.giftCards.DisableForOrder(sessionContext, objID)
+			}
+			if err == nil {
+				err = s.restoreRedemptions(sessionContext, objID)
+			}
+			if err != nil {
+				return err
+			}
+			update["refundedAt"] = now
+		}
+		if status == StatusRefunded && current.Status != StatusRefunded {
+			// Shipped goods are not restocked here; license keys and bought gift cards are
+			// taken back and whatever was paid with gift cards or store credit is returned.
+			err := s.licenseService.Revoke(sessionContext, objID)
+			if err == nil {
+				err = s.giftCards.DisableForOrder(sessionContext, objID)
+			}
+			if err == nil {
+				err = s.restoreRedemptions(sessionContext, objID)
+			}
+			if err != nil {
+				return err
+			}
+			update["refundedAt"] = now
+		}
+		for k, v := range set {
+			update[k] = v
+		}
+		change := bson.M{"$set": update, "$inc": bson.M{"version": 1}}
+		if current.Status != status {
+			change["$push"] = bson.M{"history": HistoryEntry{Type: HistoryStatusChanged, At: now, From: current.Status, To: status}}
+		}
+		if current.Status != status {
+			data := event.OrderStatusChangedData{OrderID: objID.Hex(), From: current.Status, To: status}
+			if !current.isGuest() {
+				data.UserID = current.UserID.Hex()
+			}
+			if r, ok := set["cancelReason"].(string); ok {
+				data.Reason = r
+			}
+			if err := s.events.Record(sessionContext, event.OrderStatusChanged, objID.Hex(), data); err != nil {
+				return err
+			}
+		}
+		// The version guard catches writers that slipped in after the read above.
+		err = s.ordersCollection.FindOneAndUpdate(
+			sessionContext,
+			database.WithVersion(bson.M{"_id": objID}, &current.Version),
+			change,
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return errors.New("version mismatch")
+			}
+			log.Printf("Error updating order status: %v", err)
+			return errors.New("failed to update order status")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updated.Status != StatusPending {
+		s.stockAlerts.StockChanged(updated.productIDs()...) // Holds were committed or released
+	}
+	return &updated, nil
+}
+
+// earnLines returns the lines of an order that earn loyalty points, at what was paid for them
+// after the points discount. Gift cards earn nothing; their points are earned when they are spent.
+func earnLines(o *Order) []loyalty.Line {
+	paid, beforeDiscount := o.TotalAmount.Amount, o.TotalAmount.Amount
+	if o.PointsDiscount != nil {
+		beforeDiscount += o.PointsDiscount.Amount
+	}
+	var lines []loyalty.Line
+	for _, item := range o.Items {
+		if item.GiftCard {
+			continue
+		}
+		amount := item.Subtotal
+		if beforeDiscount > 0 && paid != beforeDiscount {
+			amount.Amount = amount.Amount * paid / beforeDiscount
+		}
+		lines = append(lines, loyalty.Line{CategoryID: item.CategoryID, Amount: amount})
+	}
+	return lines
+}
+
+// releaseBackorders stops counting the order's units still waiting for stock against their
+// products. It returns the items with nothing backordered and whether there were any.
+func (s *service) releaseBackorders(ctx context.Context, o *Order) ([]OrderItem, bool, error) {
+	items := make([]OrderItem, len(o.Items))
+	released := false
+	for i, item := range o.Items {
+		if item.Backordered > 0 {
+			if err := s.inventoryService.RemoveBackorder(ctx, item.ProductID, item.Backordered); err != nil {
+				return nil, false, err
+			}
+			item.Backordered = 0
+			released = true
+		}
+		items[i] = item
+	}
+	return items, released, nil
+}
+
+// restoreRedemptions returns what an order paid with gift cards and store credit.
+func (s *service) restoreRedemptions(ctx context.Context, orderID primitive.ObjectID) error {
+	if err := s.giftCards.RestoreForOrder(ctx, orderID); err != nil {
+		return err
+	}
+	return s.storeCredit.RestoreForOrder(ctx, orderID)
+}
+
+// issueGiftCards creates the gift cards bought with an order, one per unit, each worth the
+// regular price so a sale does not shrink the card. It returns the items with their codes
+// filled in and whether there were any.
+func (s *service) issueGiftCards(ctx context.Context, o *Order) ([]OrderItem, bool, error) {
+	items := make([]OrderItem, len(o.Items))
+	issued := false
+	for i, item := range o.Items {
+		if item.GiftCard && len(item.GiftCardCodes) == 0 {
+			value := item.Price
+			if item.RegularPrice != nil {
+				value = *item.RegularPrice
+			}
+			codes, err := s.giftCards.IssueForOrder(ctx, o.ID, o.UserID, value, item.Quantity)
+			if err != nil {
+				return nil, false, err
+			}
+			item.GiftCardCodes = codes
+			issued = true
+		}
+		items[i] = item
+	}
+	return items, issued, nil
 }
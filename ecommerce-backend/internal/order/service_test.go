@@ -0,0 +1,214 @@
+// internal/order/service_test.go
+package order
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/exchange"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/inventory"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/payment"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/warehouse"
+)
+
+// The fakes below implement what the tests call; everything else is left to the embedded
+// (nil) interfaces.
+
+type fakeProducts struct {
+	product.ProductService
+	products map[primitive.ObjectID]*product.Product
+}
+
+func (f *fakeProducts) GetProductForOrder(ctx context.Context, id string) (*product.Product, error) {
+	objID, _ := primitive.ObjectIDFromHex(id)
+	p := *f.products[objID]
+	return &p, nil
+}
+
+type fakeExchange struct{ exchange.ExchangeService }
+
+func (fakeExchange) BaseCurrency() string { return "USD" }
+
+type fakeInventory struct {
+	inventory.InventoryService
+	backordered map[primitive.ObjectID]int
+}
+
+func (f *fakeInventory) AddBackorder(ctx context.Context, productID primitive.ObjectID, quantity, limit int) error {
+	f.backordered[productID] += quantity
+	return nil
+}
+
+// TestBuildItemsKeepsRestockForBackorders restocks a product that has backordered lines
+// waiting and checks that a new order only gets what is left after them.
+func TestBuildItemsKeepsRestockForBackorders(t *testing.T) {
+	tests := []struct {
+		name            string
+		stock, reserved int // After the restock
+		waiting         int // Units already backordered by earlier orders
+		limit           int // Backorder limit
+		quantity        int // Ordered by the new order
+		wantInStock     int // Units the new order may take from stock
+		wantBackordered int // Units the new order waits for, behind the earlier ones
+		wantErr         string
+	}{
+		{"restock covers the waiting lines first", 5, 0, 3, 10, 4, 2, 2, ""},
+		{"restock exactly covers the waiting lines", 3, 0, 3, 10, 1, 0, 1, ""},
+		{"reservations count too", 6, 2, 3, 10, 2, 1, 1, ""},
+		{"plenty of stock", 10, 0, 3, 10, 4, 4, 0, ""},
+		{"no stock left over without backorders", 3, 0, 3, 3, 1, 0, 0, "insufficient stock"},
+		{"no backorder waiting", 5, 0, 0, 0, 4, 4, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &product.Product{
+				ID:             primitive.NewObjectID(),
+				Name:           "Widget",
+				Price:          money.New(1000, "USD"),
+				Stock:          tt.stock,
+				Reserved:       tt.reserved,
+				Backordered:    tt.waiting,
+				BackorderLimit: tt.limit,
+			}
+			inv := &fakeInventory{backordered: map[primitive.ObjectID]int{}}
+			s := &service{
+				productService:   &fakeProducts{products: map[primitive.ObjectID]*product.Product{p.ID: p}},
+				exchangeService:  fakeExchange{},
+				inventoryService: inv,
+			}
+
+			items, lines, total, err := s.buildItems(context.Background(), primitive.NewObjectID(),
+				[]OrderItemRequest{{ProductID: p.ID.Hex(), Quantity: tt.quantity}}, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildItems() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildItems() error = %v", err)
+			}
+
+			var wantLines []warehouse.Line
+			if tt.wantInStock > 0 {
+				wantLines = []warehouse.Line{{ProductID: p.ID, Quantity: tt.wantInStock}}
+			}
+			if !reflect.DeepEqual(lines, wantLines) {
+				t.Errorf("stock lines = %v, want %v", lines, wantLines)
+			}
+			if got := items[0].Backordered; got != tt.wantBackordered {
+				t.Errorf("backordered = %d, want %d", got, tt.wantBackordered)
+			}
+			if got := inv.backordered[p.ID]; got != tt.wantBackordered {
+				t.Errorf("backorders added = %d, want %d", got, tt.wantBackordered)
+			}
+			if want := money.New(1000*int64(tt.quantity), "USD"); total != want {
+				t.Errorf("total = %v, want %v", total, want)
+			}
+		})
+	}
+}
+
+type fakePayments struct {
+	payment.Provider
+	refunded []string // Charge IDs
+	err      error
+}
+
+func (f *fakePayments) Refund(ctx context.Context, charge *payment.Charge) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.refunded = append(f.refunded, charge.ID)
+	return nil
+}
+
+// statusChanged builds the outbox event of an order moving from one status to another.
+func statusChanged(t testing.TB, orderID primitive.ObjectID, from, to string) *event.Event {
+	t.Helper()
+	data, err := bson.Marshal(event.OrderStatusChangedData{OrderID: orderID.Hex(), From: from, To: to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &event.Event{ID: primitive.NewObjectID(), Type: event.OrderStatusChanged, SubjectID: orderID.Hex(), Data: data}
+}
+
+func TestRefundPayment(t *testing.T) {
+	refundedAt := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name         string
+		from, to     string
+		charge       *payment.Charge // Nil for orders paid by hand
+		providerErr  error
+		wantLoad     bool // Whether the order is loaded at all
+		wantRefunded bool
+		wantErr      bool
+	}{
+		{"cancelled after payment", StatusProcessing, StatusCancelled, &payment.Charge{ID: "ch_1"}, nil, true, true, false},
+		{"refunded after delivery", StatusDelivered, StatusRefunded, &payment.Charge{ID: "ch_1"}, nil, true, true, false},
+		{"refunded after shipping", StatusShipped, StatusRefunded, &payment.Charge{ID: "ch_1"}, nil, true, true, false},
+		{"refunded after a return", StatusReturned, StatusRefunded, &payment.Charge{ID: "ch_1"}, nil, true, true, false},
+		{"refunded on an earlier attempt", StatusDelivered, StatusRefunded, &payment.Charge{ID: "ch_1", RefundedAt: &refundedAt}, nil, true, false, false},
+		{"paid by hand", StatusDelivered, StatusRefunded, nil, nil, true, false, false},
+		{"provider refund fails", StatusDelivered, StatusRefunded, &payment.Charge{ID: "ch_1"}, errors.New("gateway down"), true, false, true},
+		{"unpaid order cancelled", StatusPending, StatusCancelled, &payment.Charge{ID: "ch_1"}, nil, false, false, false},
+		{"delivered", StatusShipped, StatusDelivered, &payment.Charge{ID: "ch_1"}, nil, false, false, false},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			orderID := primitive.NewObjectID()
+			payments := &fakePayments{err: tt.providerErr}
+			s := &service{ordersCollection: mt.DB.Collection("orders"), payments: payments}
+
+			o := Order{ID: orderID, Status: tt.to, TotalAmount: money.New(5000, "USD"), Payment: tt.charge}
+			raw, err := bson.Marshal(o)
+			if err != nil {
+				mt.Fatal(err)
+			}
+			var doc bson.D
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				mt.Fatal(err)
+			}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "test.orders", mtest.FirstBatch, doc),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			)
+
+			err = s.RefundPayment(context.Background(), statusChanged(mt, orderID, tt.from, tt.to))
+			if (err != nil) != tt.wantErr {
+				mt.Fatalf("RefundPayment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var commands []string
+			for _, ev := range mt.GetAllStartedEvents() {
+				commands = append(commands, ev.CommandName)
+			}
+			wantCommands := []string(nil)
+			if tt.wantLoad {
+				wantCommands = []string{"find"}
+			}
+			if tt.wantRefunded {
+				wantCommands = append(wantCommands, "update") // Marks the charge refunded
+			}
+			if !reflect.DeepEqual(commands, wantCommands) {
+				mt.Errorf("commands = %v, want %v", commands, wantCommands)
+			}
+			if got := len(payments.refunded) == 1; got != tt.wantRefunded {
+				mt.Errorf("refunded charges = %v, want a refund: %v", payments.refunded, tt.wantRefunded)
+			}
+		})
+	}
+}
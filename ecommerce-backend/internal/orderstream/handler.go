@@ -0,0 +1,189 @@
+// internal/orderstream/handler.go
+package orderstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config" // For guest order access tokens
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils"  // For standardized responses
+)
+
+// OrderStreamHandler handles the server-sent event streams of order updates.
+type OrderStreamHandler struct {
+	Service   OrderStreamService
+	JWTSecret string // Guest order access tokens are signed with the JWT secret
+}
+
+// NewOrderStreamHandler creates a new OrderStreamHandler instance.
+func NewOrderStreamHandler(s OrderStreamService, cfg *config.Config) *OrderStreamHandler {
+	return &OrderStreamHandler{
+		Service:   s,
+		JWTSecret: cfg.JWTSecret,
+	}
+}
+
+// StreamOrderEvents godoc
+// @Summary Stream updates of an order
+// @Description Server-sent events for one order: an "order.status_changed" event whenever its status changes. Accessible to the user who placed it, admins, or anyone with the order's guest access token until the order is claimed into an account. Send the ID of the last event received in Last-Event-ID to get the events missed since, in the order they committed; a long backlog comes in batches, the stream ending after each so the client reconnects for the next.
+// @Tags Orders
+// @Produce  text/event-stream
+// @Param   id path string true "Order ID"
+// @Param   token query string false "Guest order access token, instead of logging in"
+// @Param   Last-Event-ID header string false "ID of the last event received, to resume after it"
+// @Security ApiKeyAuth
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} map[string]interface{} "Invalid order ID format or Last-Event-ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized: neither logged in nor given an access token"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Access denied (if not owner or admin, or the access token is invalid)"
+// @Failure 404 {object} map[string]interface{} "Order not found"
+// @Failure 410 {object} map[string]interface{} "The events since Last-Event-ID are no longer available; reload the order and reconnect without it"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /orders/{id}/events [get]
+func (h *OrderStreamHandler) StreamOrderEvents(c *gin.Context) {
+	orderID := c.Param("id")
+	userID := c.GetString("userID") // Set by OptionalAuth when a valid JWT was sent
+	token := c.Query("token")       // Guest order access token
+	tokenValid := token != "" && utils.ValidateOrderAccessToken(token, orderID, h.JWTSecret) == nil
+	if userID == "" {
+		if token == "" {
+			utils.RespondWithError(c, http.StatusUnauthorized, "Authorization header or order access token required")
+			return
+		}
+		if !tokenValid {
+			utils.RespondWithError(c, http.StatusForbidden, "Invalid or expired order access token")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Service.CheckAccess(ctx, orderID, userID, c.GetString("userRole") == "admin", tokenValid); err != nil {
+		switch err.Error() {
+		case "order not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid order ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		case "access denied":
+			utils.RespondWithError(c, http.StatusForbidden, "Access denied: You can only follow your own orders or if you are an admin.")
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	h.stream(c, orderID)
+}
+
+// StreamAllOrders godoc
+// @Summary Stream updates of all orders (Admin only)
+// @Description Server-sent events for every order: "order.created" when an order is placed and "order.status_changed" when one changes status. Send the ID of the last event received in Last-Event-ID to get the events missed since, in the order they committed; a long backlog comes in batches, the stream ending after each so the client reconnects for the next.
+// @Tags Orders
+// @Produce  text/event-stream
+// @Param   Last-Event-ID header string false "ID of the last event received, to resume after it"
+// @Security ApiKeyAuth
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} map[string]interface{} "Invalid Last-Event-ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 410 {object} map[string]interface{} "The events since Last-Event-ID are no longer available; reconnect without it"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/orders/stream [get]
+func (h *OrderStreamHandler) StreamAllOrders(c *gin.Context) {
+	h.stream(c, "")
+}
+
+// stream sends the events missed since Last-Event-ID, if given, then live events until the
+// client disconnects. Subscribing comes first so nothing is lost between the two; events seen
+// in both are sent once. When more were missed than one replay sends, the stream ends after
+// them so the client reconnects and picks up where they stopped.
+func (h *OrderStreamHandler) stream(c *gin.Context, orderID string) {
+	sub := h.Service.Subscribe(orderID)
+	defer h.Service.Unsubscribe(sub)
+
+	var missed []Message
+	var more bool // The replay was cut short at replayLimit
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		var err error
+		missed, more, err = h.Service.Replay(ctx, lastEventID, orderID)
+		cancel()
+		if err != nil {
+			switch err.Error() {
+			case "invalid Last-Event-ID":
+				utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			case "events since Last-Event-ID are no longer available":
+				utils.RespondWithError(c, http.StatusGone, err.Error())
+			default:
+				utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+	}
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Keeps nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", clientRetry.Milliseconds()); err != nil {
+		return
+	}
+
+	sent := make(map[primitive.ObjectID]bool, len(missed))
+	for _, msg := range missed {
+		if err := writeMessage(w, msg); err != nil {
+			return
+		}
+		sent[msg.ID] = true
+	}
+	w.Flush()
+	if more {
+		return // The client reconnects from the last event replayed for the rest
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case msg, ok := <-sub.Messages:
+			if !ok {
+				return // Dropped for falling behind; the client reconnects with Last-Event-ID
+			}
+			if sent[msg.ID] {
+				continue
+			}
+			if err := writeMessage(w, msg); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}
+}
+
+// writeMessage writes a message as one server-sent event.
+func writeMessage(w io.Writer, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Error encoding order event %s: %v", msg.ID.Hex(), err)
+		return nil // Skip it rather than end the stream
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.Position, msg.Type, data)
+	return err
+}
@@ -0,0 +1,49 @@
+// internal/orderstream/model.go
+package orderstream
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+)
+
+// streamedTypes are the event types pushed to order streams.
+var streamedTypes = []string{event.OrderCreated, event.OrderStatusChanged}
+
+const (
+	heartbeatInterval = 15 * time.Second // A comment line is sent this often so proxies keep idle streams open
+	clientRetry       = 3 * time.Second  // How long clients wait before reconnecting (the SSE "retry" field)
+	watchRetryDelay   = 5 * time.Second  // Wait before reopening the change stream after it fails
+	subscriberBuffer  = 32               // Messages queued per client before it counts as too slow and is dropped
+	replayLimit       = 500              // Missed events sent per connection; the client reconnects for more
+)
+
+// Server error codes for a change stream that cannot resume from its position.
+const (
+	changeStreamFatalError  = 280 // The resume token is not in the oplog any more
+	changeStreamHistoryLost = 286 // The oplog no longer reaches back to the resume token
+)
+
+// Message is an order event as pushed to clients. The ID is the outbox event ID; the Position
+// is the change stream's resume token for it, sent as the SSE event ID for clients to send back
+// in Last-Event-ID. Unlike event IDs, positions follow the order in which events committed.
+type Message struct {
+	ID         primitive.ObjectID `json:"id"`
+	Position   string             `json:"-"`
+	Type       string             `json:"type"` // order.created or order.status_changed
+	OrderID    string             `json:"orderId"`
+	OccurredAt time.Time          `json:"occurredAt"`
+	Data       interface{}        `json:"data"` // event.OrderCreatedData or event.OrderStatusChangedData
+}
+
+// Subscription receives the messages of one open stream.
+type Subscription struct {
+	// Messages is closed when the subscription ends, including when the client fell too far
+	// behind; it can then reconnect and catch up with Last-Event-ID.
+	Messages <-chan Message
+
+	orderID string // Empty for every order
+	ch      chan Message
+}
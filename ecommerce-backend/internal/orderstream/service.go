@@ -0,0 +1,243 @@
+// internal/orderstream/service.go
+package orderstream
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+)
+
+// OrderStreamService pushes order events to connected clients as they are committed.
+type OrderStreamService interface {
+	// CheckAccess tells whether a user may stream an order: its owner, an admin, or the holder of
+	// a valid guest access token (tokenValid) for an order not claimed yet.
+	CheckAccess(ctx context.Context, orderID, userID string, isAdmin, tokenValid bool) error
+	// Subscribe starts receiving the events of one order, or of every order when orderID is empty.
+	Subscribe(orderID string) *Subscription
+	// Unsubscribe stops a subscription and closes its channel. It is safe to call more than once.
+	Unsubscribe(sub *Subscription)
+	// Replay returns the events committed after the position lastEventID, in commit order, up
+	// to replayLimit of them; more tells whether that limit cut the replay short.
+	Replay(ctx context.Context, lastEventID, orderID string) (messages []Message, more bool, err error)
+	// Start follows the outbox until ctx is cancelled, fanning new order events out to subscribers.
+	Start(ctx context.Context)
+}
+
+// service implements OrderStreamService.
+type service struct {
+	outboxCollection *mongo.Collection
+	orderService     order.OrderService
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// NewOrderStreamService creates a new order stream service.
+func NewOrderStreamService(orderService order.OrderService) OrderStreamService {
+	return &service{
+		outboxCollection: database.GetCollection("outbox"),
+		orderService:     orderService,
+		subscribers:      make(map[*Subscription]struct{}),
+	}
+}
+
+// CheckAccess returns "order not found", "invalid order ID format" or "access denied" when the
+// order cannot be streamed.
+func (s *service) CheckAccess(ctx context.Context, orderID, userID string, isAdmin, tokenValid bool) error {
+	o, err := s.orderService.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if isAdmin || (userID != "" && o.UserID == userID) {
+		return nil
+	}
+	if tokenValid && o.ClaimedAt == nil { // Claiming ends access through the token
+		return nil
+	}
+	return errors.New("access denied")
+}
+
+// Subscribe registers a new subscription.
+func (s *service) Subscribe(orderID string) *Subscription {
+	ch := make(chan Message, subscriberBuffer)
+	sub := &Subscription{Messages: ch, orderID: orderID, ch: ch}
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscription if it is still registered.
+func (s *service) Unsubscribe(sub *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// publish hands a message to every matching subscriber without blocking. A subscriber whose
+// buffer is full is dropped rather than holding up everyone else.
+func (s *service) publish(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		if sub.orderID != "" && sub.orderID != msg.OrderID {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Replay reads missed events back from the outbox's change stream, resuming after the change
+// the client last received. Events come in the order their transactions committed, so one
+// committed late with an earlier event ID is not skipped. The position has to still be in the
+// oplog; otherwise the client has to reload the order and start over.
+func (s *service) Replay(ctx context.Context, lastEventID, orderID string) ([]Message, bool, error) {
+	if _, err := hex.DecodeString(lastEventID); err != nil || lastEventID == "" {
+		return nil, false, errors.New("invalid Last-Event-ID")
+	}
+
+	opts := options.ChangeStream().SetStartAfter(bson.D{{Key: "_data", Value: lastEventID}})
+	stream, err := s.outboxCollection.Watch(ctx, changePipeline(orderID), opts)
+	if err != nil {
+		if positionLost(err) {
+			return nil, false, errors.New("events since Last-Event-ID are no longer available")
+		}
+		log.Printf("Error opening change stream to replay order events: %v", err)
+		return nil, false, errors.New("failed to retrieve missed events")
+	}
+	defer stream.Close(context.Background())
+
+	messages := []Message{}
+	for len(messages) < replayLimit {
+		if !stream.TryNext(ctx) {
+			if err := stream.Err(); err != nil {
+				if positionLost(err) {
+					return nil, false, errors.New("events since Last-Event-ID are no longer available")
+				}
+				log.Printf("Error reading order events to replay: %v", err)
+				return nil, false, errors.New("failed to retrieve missed events")
+			}
+			return messages, false, nil // Caught up with the outbox
+		}
+		msg, err := changeMessage(stream)
+		if err != nil {
+			log.Printf("Skipping order event: %v", err)
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages, true, nil
+}
+
+// positionLost tells whether a change stream could not resume because its position has
+// fallen out of the oplog.
+func positionLost(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) &&
+		(serverErr.HasErrorCode(changeStreamHistoryLost) || serverErr.HasErrorCode(changeStreamFatalError))
+}
+
+// changePipeline matches new order events in the outbox, of one order or of every order when
+// orderID is empty.
+func changePipeline(orderID string) mongo.Pipeline {
+	match := bson.M{"operationType": "insert", "fullDocument.type": bson.M{"$in": streamedTypes}}
+	if orderID != "" {
+		match["fullDocument.subjectID"] = orderID
+	}
+	return mongo.Pipeline{{{Key: "$match", Value: match}}}
+}
+
+// changeMessage turns the current change of a stream into a message positioned at it.
+func changeMessage(stream *mongo.ChangeStream) (Message, error) {
+	var change struct {
+		ResumeToken  bson.Raw    `bson:"_id"`
+		FullDocument event.Event `bson:"fullDocument"`
+	}
+	if err := stream.Decode(&change); err != nil {
+		return Message{}, fmt.Errorf("failed to decode outbox change: %w", err)
+	}
+	msg, err := newMessage(&change.FullDocument)
+	if err != nil {
+		return Message{}, fmt.Errorf("event %s: %w", change.FullDocument.ID.Hex(), err)
+	}
+	msg.Position, _ = change.ResumeToken.Lookup("_data").StringValueOK()
+	return msg, nil
+}
+
+// newMessage turns an outbox event into a message.
+func newMessage(e *event.Event) (Message, error) {
+	data, err := e.DecodeData()
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{ID: e.ID, Type: e.Type, OrderID: e.SubjectID, OccurredAt: e.OccurredAt, Data: data}, nil
+}
+
+// Start watches the outbox with a change stream, which sees events once their transaction
+// commits, on whichever instance wrote them. When the stream fails it is reopened after the
+// last change it delivered.
+func (s *service) Start(ctx context.Context) {
+	go func() {
+		var resumeToken bson.Raw
+		for {
+			var err error
+			resumeToken, err = s.watch(ctx, resumeToken)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Order stream watch failed, retrying in %s: %v", watchRetryDelay, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchRetryDelay):
+			}
+		}
+	}()
+}
+
+// watch publishes new order events until the change stream ends, and returns the token to
+// resume after. When the stream cannot be opened from resumeToken (e.g. it has fallen out of
+// the oplog), it returns nil so the next attempt starts from the present; clients pick up the
+// gap with Last-Event-ID when they reconnect.
+func (s *service) watch(ctx context.Context, resumeToken bson.Raw) (bson.Raw, error) {
+	opts := options.ChangeStream()
+	if resumeToken != nil {
+		opts.SetStartAfter(resumeToken)
+	}
+	stream, err := s.outboxCollection.Watch(ctx, changePipeline(""), opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		msg, err := changeMessage(stream)
+		if err != nil {
+			log.Printf("Skipping order event: %v", err)
+			continue
+		}
+		s.publish(msg)
+	}
+	return stream.ResumeToken(), stream.Err()
+}
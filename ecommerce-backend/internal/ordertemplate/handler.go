@@ -0,0 +1,248 @@
+// internal/ordertemplate/handler.go
+package ordertemplate
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product" // For sale pricing by customer segment
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils"   // For standardized responses
+)
+
+// OrderTemplateHandler handles HTTP requests related to order templates.
+type OrderTemplateHandler struct {
+	Service   OrderTemplateService
+	Validator *validator.Validate
+}
+
+// NewOrderTemplateHandler creates a new OrderTemplateHandler instance.
+func NewOrderTemplateHandler(s OrderTemplateService) *OrderTemplateHandler {
+	return &OrderTemplateHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// respondWithServiceError maps order template service errors to HTTP statuses.
+func respondWithServiceError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "order template not found" || msg == "order not found":
+		utils.RespondWithError(c, http.StatusNotFound, msg)
+	case strings.HasPrefix(msg, "invalid") || msg == "items or fromOrderId is required" || strings.HasPrefix(msg, "order template is full"):
+		utils.RespondWithError(c, http.StatusBadRequest, msg)
+	case strings.HasPrefix(msg, "order template limit reached") || msg == "an order template with this name already exists":
+		utils.RespondWithError(c, http.StatusConflict, msg)
+	default:
+		utils.RespondWithError(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// bindJSON binds and validates a request body. On failure it has already written the response.
+func (h *OrderTemplateHandler) bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return false
+	}
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return false
+	}
+	return true
+}
+
+// requestContext returns a timeout context that prices products for the caller's segment.
+func requestContext(c *gin.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	return product.WithSegment(ctx, c.GetString("userSegment")), cancel
+}
+
+// CreateTemplate godoc
+// @Summary Save an order template
+// @Description Save a named set of products and quantities for repeat purchases, optionally with a currency, shipping region and address. With fromOrderId, the items and shipping details are copied from one of the user's past orders; fields given alongside it take precedence.
+// @Tags Order Templates
+// @Accept  json
+// @Produce  json
+// @Param   request body TemplateRequest true "Template name, items or past order, and shipping details"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Order template created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Past order not found"
+// @Failure 409 {object} map[string]interface{} "Name already used or template limit reached"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/order-templates [post]
+func (h *OrderTemplateHandler) CreateTemplate(c *gin.Context) {
+	var req TemplateRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	template, err := h.Service.CreateTemplate(ctx, c.GetString("userID"), &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Order template created successfully", "template": template})
+}
+
+// GetUserTemplates godoc
+// @Summary List my order templates
+// @Description Retrieve the authenticated user's order templates, by name, with live product prices and stock
+// @Tags Order Templates
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of order templates"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/order-templates [get]
+func (h *OrderTemplateHandler) GetUserTemplates(c *gin.Context) {
+	ctx, cancel := requestContext(c, 10*time.Second) // Every template product is loaded
+	defer cancel()
+
+	templates, err := h.Service.GetUserTemplates(ctx, c.GetString("userID"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"templates": templates})
+}
+
+// GetTemplate godoc
+// @Summary Get one of my order templates
+// @Description Retrieve an order template of the authenticated user with live product prices and stock
+// @Tags Order Templates
+// @Produce  json
+// @Param   id path string true "Order template ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Order template data"
+// @Failure 400 {object} map[string]interface{} "Invalid order template ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order template not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/order-templates/{id} [get]
+func (h *OrderTemplateHandler) GetTemplate(c *gin.Context) {
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	template, err := h.Service.GetTemplate(ctx, c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"template": template})
+}
+
+// ReplaceTemplate godoc
+// @Summary Replace an order template
+// @Description Replace the name, items and shipping details of one of the authenticated user's order templates; shipping details left out are cleared
+// @Tags Order Templates
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Order template ID"
+// @Param   request body TemplateRequest true "Template name, items or past order, and shipping details"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Order template updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order template or past order not found"
+// @Failure 409 {object} map[string]interface{} "Name already used"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/order-templates/{id} [put]
+func (h *OrderTemplateHandler) ReplaceTemplate(c *gin.Context) {
+	var req TemplateRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	template, err := h.Service.ReplaceTemplate(ctx, c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Order template updated successfully", "template": template})
+}
+
+// DeleteTemplate godoc
+// @Summary Delete an order template
+// @Description Delete one of the authenticated user's order templates
+// @Tags Order Templates
+// @Produce  json
+// @Param   id path string true "Order template ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Order template deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid order template ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order template not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/order-templates/{id} [delete]
+func (h *OrderTemplateHandler) DeleteTemplate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Service.DeleteTemplate(ctx, c.GetString("userID"), c.Param("id")); err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Order template deleted successfully"})
+}
+
+// PlaceOrder godoc
+// @Summary Order from a template
+// @Description Place an order for a template's items at current prices, shipped as the template says unless the body overrides it. Items that can no longer be ordered or are out of stock are left out and listed under "skipped"; the body is optional.
+// @Tags Order Templates
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Order template ID"
+// @Param   request body TemplateOrderRequest false "Currency, shipping region or address to use instead of the template's"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Order created, with the skipped items"
+// @Failure 400 {object} map[string]interface{} "Invalid request or unsupported currency"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Order template not found"
+// @Failure 409 {object} map[string]interface{} "None of the items can be ordered right now"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/order-templates/{id}/order [post]
+func (h *OrderTemplateHandler) PlaceOrder(c *gin.Context) {
+	var req TemplateOrderRequest
+	if c.Request.ContentLength != 0 && !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second) // Longer timeout for the order transaction
+	defer cancel()
+
+	result, err := h.Service.PlaceOrder(ctx, c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		msg := err.Error()
+		// Order errors are classified like POST /orders does.
+		if msg == "unsupported currency" || msg == "invalid currency code" {
+			utils.RespondWithError(c, http.StatusBadRequest, msg)
+			return
+		}
+		if msg == "none of the items can be ordered right now" {
+			utils.RespondWithError(c, http.StatusConflict, msg)
+			return
+		}
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Order created successfully", "order": result.Order, "skipped": result.Skipped})
+}
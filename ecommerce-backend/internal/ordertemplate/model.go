@@ -0,0 +1,78 @@
+// internal/ordertemplate/model.go
+package ordertemplate
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+)
+
+// Limits that keep templates small enough to hydrate with live product data on every read.
+const (
+	maxTemplatesPerUser = 50
+	maxItemsPerTemplate = 100
+)
+
+// Template is a named set of products and quantities a customer orders again and again.
+type Template struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID `bson:"userID" json:"userId"`
+	Name            string             `bson:"name" json:"name"` // Unique per user
+	Items           []Item             `bson:"items" json:"items"`
+	Currency        string             `bson:"currency,omitempty" json:"currency,omitempty"`
+	ShippingRegion  string             `bson:"shippingRegion,omitempty" json:"shippingRegion,omitempty"`
+	ShippingAddress *order.Address     `bson:"shippingAddress,omitempty" json:"shippingAddress,omitempty"`
+	LastOrderedAt   *time.Time         `bson:"lastOrderedAt,omitempty" json:"lastOrderedAt,omitempty"`
+	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Item is a product and quantity on a template.
+type Item struct {
+	ProductID primitive.ObjectID `bson:"productID" json:"productId"`
+	Quantity  int                `bson:"quantity" json:"quantity"`
+}
+
+// TemplateResponse defines the structure for order template data in API responses.
+type TemplateResponse struct {
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	Items           []ItemResponse `json:"items"`
+	Currency        string         `json:"currency,omitempty"`
+	ShippingRegion  string         `json:"shippingRegion,omitempty"`
+	ShippingAddress *order.Address `json:"shippingAddress,omitempty"`
+	LastOrderedAt   *time.Time     `json:"lastOrderedAt,omitempty"`
+	CreatedAt       time.Time      `json:"createdAt"`
+	UpdatedAt       time.Time      `json:"updatedAt"`
+}
+
+// ItemResponse is a template line with its product's live price and stock.
+type ItemResponse struct {
+	ProductID string                   `json:"productId"`
+	Quantity  int                      `json:"quantity"`
+	Product   *product.ProductResponse `json:"product,omitempty"` // Missing once the product is archived or unpublished
+	InStock   bool                     `json:"inStock"`           // Enough stock for the whole quantity
+}
+
+// TemplateRequest defines the structure for creating or replacing an order template. Items
+// and the shipping details can be copied from one of the user's past orders with FromOrderID;
+// fields given alongside it take precedence.
+type TemplateRequest struct {
+	Name            string                   `json:"name" validate:"required,min=1,max=50"`
+	FromOrderID     string                   `json:"fromOrderId,omitempty"`
+	Items           []order.OrderItemRequest `json:"items,omitempty" validate:"omitempty,max=100,dive"`
+	Currency        string                   `json:"currency,omitempty" validate:"omitempty,len=3"`
+	ShippingRegion  string                   `json:"shippingRegion,omitempty" validate:"omitempty,max=50"`
+	ShippingAddress *order.Address           `json:"shippingAddress,omitempty"`
+}
+
+// TemplateOrderRequest defines the structure for ordering from a template. Fields left out
+// are taken from the template.
+type TemplateOrderRequest struct {
+	Currency        string         `json:"currency,omitempty" validate:"omitempty,len=3"`
+	ShippingRegion  string         `json:"shippingRegion,omitempty" validate:"omitempty,max=50"`
+	ShippingAddress *order.Address `json:"shippingAddress,omitempty"`
+}
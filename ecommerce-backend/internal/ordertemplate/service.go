@@ -0,0 +1,349 @@
+// internal/ordertemplate/service.go
+package ordertemplate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+)
+
+// OrderTemplateService defines the interface for order template operations.
+// Reads show live product data, priced for the segment set with product.WithSegment.
+type OrderTemplateService interface {
+	CreateTemplate(ctx context.Context, userID string, req *TemplateRequest) (*TemplateResponse, error)
+	GetUserTemplates(ctx context.Context, userID string) ([]TemplateResponse, error)
+	GetTemplate(ctx context.Context, userID, id string) (*TemplateResponse, error)
+	ReplaceTemplate(ctx context.Context, userID, id string, req *TemplateRequest) (*TemplateResponse, error)
+	DeleteTemplate(ctx context.Context, userID, id string) error
+
+	// PlaceOrder orders a template's items at current prices; lines that cannot be filled are skipped.
+	PlaceOrder(ctx context.Context, userID, id string, req *TemplateOrderRequest) (*order.PartialOrderResponse, error)
+}
+
+// service implements OrderTemplateService.
+type service struct {
+	templatesCollection *mongo.Collection
+	productService      product.ProductService // Live price and stock for template products
+	orderService        order.OrderService     // Copies past orders and places new ones
+}
+
+// NewOrderTemplateService creates a new order template service.
+func NewOrderTemplateService(productService product.ProductService, orderService order.OrderService) OrderTemplateService {
+	return &service{
+		templatesCollection: database.GetCollection("order_templates"),
+		productService:      productService,
+		orderService:        orderService,
+	}
+}
+
+// parseIDs converts the owner and template IDs from their hex form.
+func parseIDs(userID, id string) (primitive.ObjectID, primitive.ObjectID, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid user ID format")
+	}
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid order template ID format")
+	}
+	return userObjID, objID, nil
+}
+
+// toResponse converts a template for API responses, loading each product's current price and stock.
+func (s *service) toResponse(ctx context.Context, t *Template) *TemplateResponse {
+	resp := &TemplateResponse{
+		ID:              t.ID.Hex(),
+		Name:            t.Name,
+		Items:           make([]ItemResponse, 0, len(t.Items)),
+		Currency:        t.Currency,
+		ShippingRegion:  t.ShippingRegion,
+		ShippingAddress: t.ShippingAddress,
+		LastOrderedAt:   t.LastOrderedAt,
+		CreatedAt:       t.CreatedAt,
+		UpdatedAt:       t.UpdatedAt,
+	}
+
+	for _, item := range t.Items {
+		itemResp := ItemResponse{ProductID: item.ProductID.Hex(), Quantity: item.Quantity}
+		p, err := s.productService.GetProductByID(ctx, item.ProductID.Hex())
+		if err != nil && err.Error() != "product not found" {
+			log.Printf("Error loading order template product %s: %v", item.ProductID.Hex(), err)
+		}
+		// Archived and draft products stay on the template but show no details.
+		if err == nil && p.Status != product.StatusDraft {
+			itemResp.Product = p
+			itemResp.InStock = p.Available >= item.Quantity || p.Digital
+		}
+		resp.Items = append(resp.Items, itemResp)
+	}
+	return resp
+}
+
+// buildTemplate turns a request into the fields a template stores, copying from the past
+// order it names. The same product on several lines is stored as one line.
+func (s *service) buildTemplate(ctx context.Context, userObjID primitive.ObjectID, req *TemplateRequest) (*Template, error) {
+	t := &Template{
+		UserID:          userObjID,
+		Name:            req.Name,
+		Currency:        req.Currency,
+		ShippingRegion:  req.ShippingRegion,
+		ShippingAddress: req.ShippingAddress,
+	}
+
+	items := req.Items
+	if req.FromOrderID != "" {
+		past, err := s.orderService.GetOrderByID(ctx, req.FromOrderID)
+		if err != nil {
+			return nil, err
+		}
+		if past.UserID != userObjID.Hex() {
+			return nil, errors.New("order not found") // Other customers' orders are not revealed
+		}
+		if len(items) == 0 {
+			for _, item := range past.Items {
+				items = append(items, order.OrderItemRequest{ProductID: item.ProductID.Hex(), Quantity: item.Quantity})
+			}
+		}
+		if t.Currency == "" && past.CurrencyLock != nil {
+			t.Currency = past.CurrencyLock.Currency
+		}
+		if t.ShippingRegion == "" {
+			t.ShippingRegion = past.ShippingRegion
+		}
+		if t.ShippingAddress == nil {
+			t.ShippingAddress = past.ShippingAddress
+		}
+	}
+	if len(items) == 0 {
+		return nil, errors.New("items or fromOrderId is required")
+	}
+
+	index := make(map[primitive.ObjectID]int)
+	for _, item := range items {
+		productObjID, err := primitive.ObjectIDFromHex(item.ProductID)
+		if err != nil {
+			return nil, errors.New("invalid product ID format")
+		}
+		if i, ok := index[productObjID]; ok {
+			t.Items[i].Quantity += item.Quantity
+			continue
+		}
+		index[productObjID] = len(t.Items)
+		t.Items = append(t.Items, Item{ProductID: productObjID, Quantity: item.Quantity})
+	}
+	if len(t.Items) > maxItemsPerTemplate {
+		return nil, fmt.Errorf("order template is full: at most %d products", maxItemsPerTemplate)
+	}
+	return t, nil
+}
+
+// findOwned loads a template belonging to the user.
+func (s *service) findOwned(ctx context.Context, userObjID, objID primitive.ObjectID) (*Template, error) {
+	var t Template
+	err := s.templatesCollection.FindOne(ctx, bson.M{"_id": objID, "userID": userObjID}).Decode(&t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("order template not found")
+		}
+		log.Printf("Error finding order template: %v", err)
+		return nil, errors.New("database error retrieving order template")
+	}
+	return &t, nil
+}
+
+// CreateTemplate saves a new order template.
+func (s *service) CreateTemplate(ctx context.Context, userID string, req *TemplateRequest) (*TemplateResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+
+	count, err := s.templatesCollection.CountDocuments(ctx, bson.M{"userID": userObjID})
+	if err != nil {
+		log.Printf("Error counting order templates: %v", err)
+		return nil, errors.New("database error retrieving order templates")
+	}
+	if count >= maxTemplatesPerUser {
+		return nil, fmt.Errorf("order template limit reached: at most %d templates", maxTemplatesPerUser)
+	}
+
+	t, err := s.buildTemplate(ctx, userObjID, req)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	t.CreatedAt, t.UpdatedAt = now, now
+	result, err := s.templatesCollection.InsertOne(ctx, t)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("an order template with this name already exists")
+		}
+		log.Printf("Error inserting order template: %v", err)
+		return nil, errors.New("failed to create order template")
+	}
+	t.ID = result.InsertedID.(primitive.ObjectID)
+	return s.toResponse(ctx, t), nil
+}
+
+// GetUserTemplates lists the user's order templates by name.
+func (s *service) GetUserTemplates(ctx context.Context, userID string) ([]TemplateResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+
+	cursor, err := s.templatesCollection.Find(ctx, bson.M{"userID": userObjID},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding order templates: %v", err)
+		return nil, errors.New("failed to retrieve order templates")
+	}
+	defer cursor.Close(ctx)
+
+	var templates []Template
+	if err = cursor.All(ctx, &templates); err != nil {
+		log.Printf("Error decoding order templates: %v", err)
+		return nil, errors.New("failed to process order template data")
+	}
+
+	responses := make([]TemplateResponse, 0, len(templates))
+	for i := range templates {
+		responses = append(responses, *s.toResponse(ctx, &templates[i]))
+	}
+	return responses, nil
+}
+
+// GetTemplate retrieves one of the user's order templates.
+func (s *service) GetTemplate(ctx context.Context, userID, id string) (*TemplateResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findOwned(ctx, userObjID, objID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, t), nil
+}
+
+// ReplaceTemplate replaces a template's name, items and shipping details.
+func (s *service) ReplaceTemplate(ctx context.Context, userID, id string, req *TemplateRequest) (*TemplateResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.buildTemplate(ctx, userObjID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shipping details left out of the request are cleared, not kept.
+	set := bson.M{"name": t.Name, "items": t.Items, "updatedAt": time.Now()}
+	unset := bson.M{}
+	if t.Currency != "" {
+		set["currency"] = t.Currency
+	} else {
+		unset["currency"] = ""
+	}
+	if t.ShippingRegion != "" {
+		set["shippingRegion"] = t.ShippingRegion
+	} else {
+		unset["shippingRegion"] = ""
+	}
+	if t.ShippingAddress != nil {
+		set["shippingAddress"] = t.ShippingAddress
+	} else {
+		unset["shippingAddress"] = ""
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var updated Template
+	err = s.templatesCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID, "userID": userObjID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("order template not found")
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("an order template with this name already exists")
+		}
+		log.Printf("Error updating order template: %v", err)
+		return nil, errors.New("failed to update order template")
+	}
+	return s.toResponse(ctx, &updated), nil
+}
+
+// DeleteTemplate deletes an order template.
+func (s *service) DeleteTemplate(ctx context.Context, userID, id string) error {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return err
+	}
+	res, err := s.templatesCollection.DeleteOne(ctx, bson.M{"_id": objID, "userID": userObjID})
+	if err != nil {
+		log.Printf("Error deleting order template: %v", err)
+		return errors.New("failed to delete order template")
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("order template not found")
+	}
+	return nil
+}
+
+// PlaceOrder orders a template's items at current prices, shipped as the template says unless
+// req overrides it. Lines that cannot be filled are reported instead of failing the order.
+func (s *service) PlaceOrder(ctx context.Context, userID, id string, req *TemplateOrderRequest) (*order.PartialOrderResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findOwned(ctx, userObjID, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	orderReq := &order.CreateOrderRequest{
+		Items:           make([]order.OrderItemRequest, 0, len(t.Items)),
+		Currency:        req.Currency,
+		ShippingRegion:  req.ShippingRegion,
+		ShippingAddress: req.ShippingAddress,
+	}
+	for _, item := range t.Items {
+		orderReq.Items = append(orderReq.Items, order.OrderItemRequest{ProductID: item.ProductID.Hex(), Quantity: item.Quantity})
+	}
+	if orderReq.Currency == "" {
+		orderReq.Currency = t.Currency
+	}
+	if orderReq.ShippingRegion == "" {
+		orderReq.ShippingRegion = t.ShippingRegion
+	}
+	if orderReq.ShippingAddress == nil {
+		orderReq.ShippingAddress = t.ShippingAddress
+	}
+
+	result, err := s.orderService.CreateOrderFromAvailable(ctx, userID, orderReq)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.templatesCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"lastOrderedAt": time.Now()}})
+	if err != nil {
+		// The order stands; only the template's bookkeeping is behind.
+		log.Printf("Error recording order on template %s: %v", objID.Hex(), err)
+	}
+	return result, nil
+}
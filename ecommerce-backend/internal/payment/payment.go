@@ -0,0 +1,80 @@
+// internal/payment/payment.go
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// ErrDeclined is returned (possibly wrapped) when the provider refuses a payment method.
+// Any other error means the charge could not be attempted and may be retried as is.
+var ErrDeclined = errors.New("payment declined")
+
+// ChargeRequest asks a provider to take a payment from a saved payment method.
+type ChargeRequest struct {
+	Amount         money.Money
+	PaymentMethod  string // Provider token for the customer's saved card or account
+	Description    string
+	IdempotencyKey string // Repeating a request with the same key never charges twice
+}
+
+// Charge is a successful payment, kept on the order it paid for.
+type Charge struct {
+	Provider  string      `bson:"provider" json:"provider"`
+	ID        string      `bson:"id" json:"id"` // Provider's charge reference
+	Amount    money.Money `bson:"amount" json:"amount"`
+	CreatedAt time.Time   `bson:"createdAt" json:"createdAt"`
+	// RefundedAt is set once the whole charge has been refunded.
+	RefundedAt *time.Time `bson:"refundedAt,omitempty" json:"refundedAt,omitempty"`
+}
+
+// Provider takes and refunds payments.
+type Provider interface {
+	Charge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	Refund(ctx context.Context, charge *Charge) error // Refunds the whole charge
+}
+
+// NewProvider builds the provider selected with PAYMENT_PROVIDER. Only the test provider is
+// built in; a real gateway implements Provider and is selected here.
+func NewProvider(cfg *config.Config) Provider {
+	switch cfg.PaymentProvider {
+	case "test":
+		return TestProvider{}
+	}
+	log.Fatalf("Unsupported payment provider %q", cfg.PaymentProvider)
+	return nil
+}
+
+// DeclinedTestPaymentMethod is the payment method TestProvider declines.
+const DeclinedTestPaymentMethod = "pm_card_declined"
+
+// TestProvider approves every charge without moving money, except on DeclinedTestPaymentMethod.
+// It is meant for development and staging.
+type TestProvider struct{}
+
+// Charge pretends to take the payment.
+func (TestProvider) Charge(_ context.Context, req ChargeRequest) (*Charge, error) {
+	if req.PaymentMethod == DeclinedTestPaymentMethod {
+		return nil, fmt.Errorf("%w: card declined", ErrDeclined)
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate charge ID: %w", err)
+	}
+	log.Printf("Test payment of %s charged to %s (%s)", req.Amount, req.PaymentMethod, req.Description)
+	return &Charge{Provider: "test", ID: "ch_test_" + hex.EncodeToString(id), Amount: req.Amount, CreatedAt: time.Now()}, nil
+}
+
+// Refund pretends to refund the charge.
+func (TestProvider) Refund(_ context.Context, charge *Charge) error {
+	log.Printf("Test charge %s of %s refunded", charge.ID, charge.Amount)
+	return nil
+}
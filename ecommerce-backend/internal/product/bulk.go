@@ -0,0 +1,462 @@
+// internal/product/bulk.go
+package product
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// Supported bulk file formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// importColumns are the CSV columns an import file must contain. Any other column
+// (for example "id" or "createdAt" from an export) is ignored, so exports can be re-imported.
+var importColumns = []string{"name", "description", "price", "sku", "categoryid", "stock"}
+
+// exportColumns is the CSV header written by an export.
+var exportColumns = []string{"id", "name", "description", "price", "currency", "sku", "categoryID", "stock", "createdAt", "updatedAt", "deletedAt"}
+
+// jobProgressInterval is how many rows a background import processes between progress saves.
+const jobProgressInterval = 100
+
+// parseCSVImport reads a CSV import file with a header row.
+// Prices are major units of baseCurrency; an optional "currency" column must match it.
+// Rows with the wrong number of fields are returned with a ParseError rather than failing the file.
+func parseCSVImport(r io.Reader, baseCurrency string) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("import file is empty")
+		}
+		return nil, fmt.Errorf("invalid CSV header: %v", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, col := range header {
+		index[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	for _, col := range importColumns {
+		if _, ok := index[col]; !ok {
+			return nil, fmt.Errorf("CSV header is missing required column %q", col)
+		}
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+				rows = append(rows, ImportRow{Line: parseErr.StartLine, ParseError: "wrong number of fields"})
+				continue
+			}
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+
+		row := ImportRow{Line: line}
+		field := func(col string) string { return strings.TrimSpace(record[index[col]]) }
+
+		row.Request = ProductCreateRequest{
+			Name:        field("name"),
+			Description: field("description"),
+			SKU:         field("sku"),
+			CategoryID:  field("categoryid"),
+		}
+		currency := baseCurrency
+		if _, ok := index["currency"]; ok && field("currency") != "" {
+			currency = strings.ToUpper(field("currency"))
+		}
+		if currency != baseCurrency {
+			row.ParseError = fmt.Sprintf("price currency %s does not match the store base currency %s", currency, baseCurrency)
+		} else if row.Price, err = money.ParseMajor(field("price"), baseCurrency); err != nil {
+			row.ParseError = "price: " + err.Error()
+		} else if row.Request.Stock, err = strconv.Atoi(field("stock")); err != nil {
+			row.ParseError = "stock must be an integer"
+		}
+		row.Request.Price, _ = strconv.ParseFloat(row.Price.Major(), 64)
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseNDJSONImport reads a newline-delimited JSON import file, one product object per line.
+// "price" may be a number in major units of baseCurrency or a money object as produced by an export.
+// Blank lines are skipped; lines that are not valid JSON are returned with a ParseError.
+func parseNDJSONImport(r io.Reader, baseCurrency string) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // Allow long description fields
+
+	var rows []ImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		row := ImportRow{Line: line}
+		var raw struct {
+			ProductCreateRequest
+			Price json.RawMessage `json:"price"`
+		}
+		if err := json.Unmarshal([]byte(text), &raw); err != nil {
+			row.ParseError = "invalid JSON: " + err.Error()
+		} else {
+			row.Request = raw.ProductCreateRequest
+			row.Price, row.ParseError = parseJSONPrice(raw.Price, baseCurrency)
+			row.Request.Price, _ = strconv.ParseFloat(row.Price.Major(), 64)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid NDJSON: %v", err)
+	}
+
+	return rows, nil
+}
+
+// parseJSONPrice accepts either a plain number (major units) or a money object.
+// It returns a parse error message instead of an error so it can be reported per row.
+func parseJSONPrice(raw json.RawMessage, baseCurrency string) (money.Money, string) {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return money.Zero(baseCurrency), ""
+	}
+	if strings.HasPrefix(trimmed, "{") {
+		var m money.Money
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return money.Money{}, "invalid price object: " + err.Error()
+		}
+		if m.Currency != baseCurrency {
+			return money.Money{}, fmt.Sprintf("price currency %s does not match the store base currency %s", m.Currency, baseCurrency)
+		}
+		return m, ""
+	}
+	m, err := money.ParseMajor(trimmed, baseCurrency)
+	if err != nil {
+		return money.Money{}, "price: " + err.Error()
+	}
+	return m, ""
+}
+
+// ImportProducts validates and upserts rows by SKU, returning a per-row report.
+// In dry-run mode nothing is written; each row reports what would have happened.
+func (s *service) ImportProducts(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error) {
+	report := &ImportReport{DryRun: dryRun, TotalRows: len(rows), Rows: make([]ImportRowResult, 0, len(rows))}
+	seen := make(map[string]int) // SKU -> first line, to catch duplicates within one file
+
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.New("import timed out")
+		}
+		result := s.importRow(ctx, row, dryRun, seen)
+		report.Rows = append(report.Rows, result)
+		switch result.Action {
+		case ImportActionCreate:
+			report.Created++
+		case ImportActionUpdate:
+			report.Updated++
+		default:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+// StartImportJob records a new import job and processes it in a background goroutine.
+func (s *service) StartImportJob(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportJob, error) {
+	job := &ImportJob{
+		Status:    ImportJobQueued,
+		DryRun:    dryRun,
+		TotalRows: len(rows),
+		Errors:    []ImportRowResult{},
+		CreatedAt: time.Now(),
+	}
+
+	result, err := s.importJobsCollection.InsertOne(ctx, job)
+	if err != nil {
+		log.Printf("Error inserting import job: %v", err)
+		return nil, errors.New("failed to create import job")
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID)
+
+	// The request context ends with the HTTP response, so the job gets its own.
+	go s.runImportJob(job.ID, rows, dryRun)
+
+	return job, nil
+}
+
+// runImportJob processes rows for a background job, saving progress periodically.
+func (s *service) runImportJob(jobID primitive.ObjectID, rows []ImportRow, dryRun bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	started := time.Now()
+	s.updateImportJob(ctx, jobID, bson.M{"status": ImportJobRunning, "startedAt": started})
+
+	var created, updated, failed int
+	rowErrors := []ImportRowResult{}
+	seen := make(map[string]int)
+	progress := func(processed int) bson.M {
+		return bson.M{
+			"processed": processed,
+			"created":   created,
+			"updated":   updated,
+			"failed":    failed,
+			"errors":    rowErrors,
+		}
+	}
+
+	for i, row := range rows {
+		if ctx.Err() != nil {
+			// The job context is done, so record the failure with a fresh one.
+			final := progress(i)
+			final["status"] = ImportJobFailed
+			final["message"] = "import timed out"
+			final["finishedAt"] = time.Now()
+			saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			s.updateImportJob(saveCtx, jobID, final)
+			saveCancel()
+			return
+		}
+
+		result := s.importRow(ctx, row, dryRun, seen)
+		switch result.Action {
+		case ImportActionCreate:
+			created++
+		case ImportActionUpdate:
+			updated++
+		default:
+			failed++
+			rowErrors = append(rowErrors, result)
+		}
+
+		if (i+1)%jobProgressInterval == 0 {
+			s.updateImportJob(ctx, jobID, progress(i+1))
+		}
+	}
+
+	finished := time.Now()
+	final := progress(len(rows))
+	final["status"] = ImportJobCompleted
+	final["finishedAt"] = finished
+	s.updateImportJob(ctx, jobID, final)
+	log.Printf("Import job %s finished: %d created, %d updated, %d failed in %s",
+		jobID.Hex(), created, updated, failed, finished.Sub(started).Round(time.Millisecond))
+}
+
+// updateImportJob applies a $set to an import job, logging (not returning) failures.
+func (s *service) updateImportJob(ctx context.Context, jobID primitive.ObjectID, fields bson.M) {
+	if _, err := s.importJobsCollection.UpdateOne(ctx, bson.M{"_id": jobID}, bson.M{"$set": fields}); err != nil {
+		log.Printf("Error updating import job %s: %v", jobID.Hex(), err)
+	}
+}
+
+// GetImportJob retrieves a background import job by its ID.
+func (s *service) GetImportJob(ctx context.Context, id string) (*ImportJob, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid job ID format")
+	}
+
+	var job ImportJob
+	err = s.importJobsCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("import job not found")
+		}
+		log.Printf("Error finding import job: %v", err)
+		return nil, errors.New("database error retrieving import job")
+	}
+
+	return &job, nil
+}
+
+// importRow validates a single row and, unless dryRun is set, upserts it by SKU.
+func (s *service) importRow(ctx context.Context, row ImportRow, dryRun bool, seen map[string]int) ImportRowResult {
+	result := ImportRowResult{Line: row.Line, SKU: row.Request.SKU, Action: ImportActionError}
+
+	if row.ParseError != "" {
+		result.Error = row.ParseError
+		return result
+	}
+	if err := s.validator.Struct(row.Request); err != nil {
+		result.Error = "validation failed: " + err.Error()
+		return result
+	}
+	categoryObjectID, err := primitive.ObjectIDFromHex(row.Request.CategoryID)
+	if err != nil {
+		result.Error = "invalid category ID format"
+		return result
+	}
+	if firstLine, dup := seen[row.Request.SKU]; dup {
+		result.Error = fmt.Sprintf("duplicate SKU in file (first seen on line %d)", firstLine)
+		return result
+	}
+	seen[row.Request.SKU] = row.Line
+
+	var existing Product
+	err = s.productsCollection.FindOne(ctx, bson.M{"sku": row.Request.SKU}).Decode(&existing)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error checking SKU %s during import: %v", row.Request.SKU, err)
+		result.Error = "database error during SKU check"
+		return result
+	}
+	exists := err == nil
+	if exists && existing.DeletedAt != nil {
+		result.Error = "product with this SKU is archived; restore it before importing"
+		return result
+	}
+	if exists && existing.IsBundle() {
+		result.Error = "product with this SKU is a bundle; bundles can only be edited individually"
+		return result
+	}
+
+	if dryRun {
+		if exists {
+			result.Action = ImportActionUpdate
+		} else {
+			result.Action = ImportActionCreate
+		}
+		return result
+	}
+
+	now := time.Now()
+	productID := existing.ID
+	err = database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		if exists {
+			if existing.Price != row.Price {
+				entry := PriceHistoryEntry{ProductID: existing.ID, Event: PriceEventSet, OldPrice: &existing.Price, NewPrice: &row.Price}
+				if err := s.recordPriceChange(sessionContext, entry); err != nil {
+					return err
+				}
+			}
+			_, err := s.productsCollection.UpdateOne(sessionContext, bson.M{"_id": existing.ID}, bson.M{"$set": bson.M{
+				"name":        row.Request.Name,
+				"description": row.Request.Description,
+				"price":       row.Price,
+				"categoryID":  categoryObjectID,
+				"updatedAt":   now,
+			}, "$inc": bson.M{"version": 1}})
+			if err != nil {
+				log.Printf("Error updating product %s during import: %v", row.Request.SKU, err)
+				return errors.New("failed to update product")
+			}
+		} else {
+			res, err := s.productsCollection.InsertOne(sessionContext, &Product{
+				Name:        row.Request.Name,
+				Description: row.Request.Description,
+				Price:       row.Price,
+				SKU:         row.Request.SKU,
+				CategoryID:  categoryObjectID,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+				Version:     1,
+				Status:      StatusPublished, // Imported products go live like single creates without a status
+			})
+			if err != nil {
+				log.Printf("Error inserting product %s during import: %v", row.Request.SKU, err)
+				return errors.New("failed to create product")
+			}
+			productID = res.InsertedID.(primitive.ObjectID)
+			if err := s.recordPriceChange(sessionContext, PriceHistoryEntry{ProductID: productID, Event: PriceEventSet, NewPrice: &row.Price}); err != nil {
+				return err
+			}
+		}
+		// Like a single create/update, the row's stock is the default warehouse's stock.
+		return s.warehouseService.SetDefaultStock(sessionContext, productID, row.Request.Stock)
+	})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	s.stockAlerts.StockChanged(productID)
+	if exists {
+		result.Action = ImportActionUpdate
+		return result
+	}
+	result.Action = ImportActionCreate
+	return result
+}
+
+// ExportProducts streams the catalog to fn one product at a time, oldest first.
+// Archived products are included only when includeArchived is set.
+func (s *service) ExportProducts(ctx context.Context, includeArchived bool, fn func(*Product) error) error {
+	filter := activeFilter(nil)
+	if includeArchived {
+		filter = bson.M{}
+	}
+
+	cursor, err := s.productsCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding products for export: %v", err)
+		return errors.New("failed to retrieve products")
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var p Product
+		if err := cursor.Decode(&p); err != nil {
+			log.Printf("Error decoding product during export: %v", err)
+			return errors.New("failed to process product data")
+		}
+		if err := fn(&p); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Error iterating products for export: %v", err)
+		return errors.New("failed to retrieve products")
+	}
+	return nil
+}
+
+// exportCSVRecord formats a product as a CSV record matching exportColumns.
+func exportCSVRecord(p *Product) []string {
+	deletedAt := ""
+	if p.DeletedAt != nil {
+		deletedAt = p.DeletedAt.Format(time.RFC3339)
+	}
+	return []string{
+		p.ID.Hex(),
+		p.Name,
+		p.Description,
+		p.Price.Major(),
+		p.Price.Currency,
+		p.SKU,
+		p.CategoryID.Hex(),
+		strconv.Itoa(p.Stock),
+		p.CreatedAt.Format(time.RFC3339),
+		p.UpdatedAt.Format(time.RFC3339),
+		deletedAt,
+	}
+}
@@ -0,0 +1,142 @@
+// internal/product/bundle.go
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// buildComponents validates the components requested for a bundle. selfID is the bundle
+// being updated, or the zero ID when it is being created.
+func (s *service) buildComponents(ctx context.Context, selfID primitive.ObjectID, reqs []BundleComponentRequest) ([]BundleComponent, error) {
+	components := make([]BundleComponent, 0, len(reqs))
+	ids := make([]primitive.ObjectID, 0, len(reqs))
+	seen := make(map[primitive.ObjectID]bool, len(reqs))
+	for _, r := range reqs {
+		id, err := primitive.ObjectIDFromHex(r.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle: invalid component product ID %s", r.ProductID)
+		}
+		if id == selfID {
+			return nil, errors.New("invalid bundle: a bundle cannot contain itself")
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("invalid bundle: component %s is listed more than once", r.ProductID)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		components = append(components, BundleComponent{ProductID: id, Quantity: r.Quantity})
+	}
+
+	parts, err := s.findComponents(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		part, ok := parts[id]
+		if !ok {
+			return nil, fmt.Errorf("invalid bundle: component product %s not found", id.Hex())
+		}
+		if part.IsBundle() {
+			return nil, fmt.Errorf("invalid bundle: component '%s' is itself a bundle", part.Name)
+		}
+		if part.Digital {
+			return nil, fmt.Errorf("invalid bundle: component '%s' is a digital product", part.Name)
+		}
+	}
+	return components, nil
+}
+
+// findComponents loads the non-archived products with the given IDs, keyed by ID.
+func (s *service) findComponents(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*Product, error) {
+	parts := make(map[primitive.ObjectID]*Product, len(ids))
+	if len(ids) == 0 {
+		return parts, nil
+	}
+	cursor, err := s.productsCollection.Find(ctx, activeFilter(bson.M{"_id": bson.M{"$in": ids}}))
+	if err != nil {
+		log.Printf("Error finding bundle components: %v", err)
+		return nil, errors.New("failed to retrieve bundle components")
+	}
+	defer cursor.Close(ctx)
+
+	var found []Product
+	if err = cursor.All(ctx, &found); err != nil {
+		log.Printf("Error decoding bundle components: %v", err)
+		return nil, errors.New("failed to process bundle components")
+	}
+	for i := range found {
+		parts[found[i].ID] = &found[i]
+	}
+	return parts, nil
+}
+
+// bundleAvailable returns how many complete bundles the components' available stock can make.
+// A component that no longer exists makes the bundle unavailable.
+func bundleAvailable(p *Product, parts map[primitive.ObjectID]*Product) int {
+	available := -1
+	for _, c := range p.Components {
+		part, ok := parts[c.ProductID]
+		if !ok {
+			return 0
+		}
+		if n := part.Available() / c.Quantity; available < 0 || n < available {
+			available = n
+		}
+	}
+	return max(available, 0)
+}
+
+// deriveBundleStock fills in the stock of any bundles among products from their components.
+// Bundles hold no stock of their own, so this is only ever done for responses.
+func (s *service) deriveBundleStock(ctx context.Context, products ...*Product) error {
+	var ids []primitive.ObjectID
+	for _, p := range products {
+		if p.IsBundle() {
+			for _, c := range p.Components {
+				ids = append(ids, c.ProductID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	parts, err := s.findComponents(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if p.IsBundle() {
+			p.Stock, p.Reserved = bundleAvailable(p, parts), 0
+		}
+	}
+	return nil
+}
+
+// GetBundleComponents loads a bundle's component products, in the bundle's order, for order processing.
+func (s *service) GetBundleComponents(ctx context.Context, bundle *Product) ([]*Product, error) {
+	ids := make([]primitive.ObjectID, 0, len(bundle.Components))
+	for _, c := range bundle.Components {
+		ids = append(ids, c.ProductID)
+	}
+	parts, err := s.findComponents(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]*Product, 0, len(ids))
+	for _, id := range ids {
+		part, ok := parts[id]
+		if !ok {
+			return nil, fmt.Errorf("component %s of bundle '%s' is no longer available", id.Hex(), bundle.Name)
+		}
+		components = append(components, part)
+	}
+	return components, nil
+}
@@ -0,0 +1,94 @@
+// internal/product/digital.go
+package product
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AttachFile stores the file delivered for a digital product and points the product at it.
+// The previous file is removed once the product no longer references it.
+func (s *service) AttachFile(ctx context.Context, id, name, contentType string, r io.Reader) (*ProductResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+
+	var p Product
+	if err := s.productsCollection.FindOne(ctx, activeFilter(bson.M{"_id": objID})).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("product not found")
+		}
+		log.Printf("Error finding product for file upload: %v", err)
+		return nil, errors.New("database error retrieving product")
+	}
+	if !p.Digital {
+		return nil, errors.New("product is not digital")
+	}
+
+	// Every upload gets a fresh key, so links to the old file keep working until the swap.
+	file := DigitalFile{
+		Key:         "products/" + id + "/" + primitive.NewObjectID().Hex(),
+		Name:        name,
+		ContentType: contentType,
+		UploadedAt:  time.Now(),
+	}
+	if file.Size, err = s.files.Save(ctx, file.Key, r); err != nil {
+		return nil, err
+	}
+
+	var updated Product
+	err = s.productsCollection.FindOneAndUpdate(ctx,
+		activeFilter(bson.M{"_id": objID}),
+		bson.M{"$set": bson.M{"file": file, "updatedAt": file.UploadedAt}, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if delErr := s.files.Delete(ctx, file.Key); delErr != nil {
+			log.Printf("Error removing orphaned upload %s: %v", file.Key, delErr)
+		}
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("product not found")
+		}
+		log.Printf("Error attaching file to product: %v", err)
+		return nil, errors.New("failed to attach file")
+	}
+	if p.File != nil {
+		if err := s.files.Delete(ctx, p.File.Key); err != nil {
+			log.Printf("Error removing replaced file %s: %v", p.File.Key, err)
+		}
+	}
+	return pricedResponse(ctx, &updated), nil
+}
+
+// GetProductFile returns the file a digital product delivers. Archived products are included
+// so that past purchases can still be downloaded.
+func (s *service) GetProductFile(ctx context.Context, id string) (*DigitalFile, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+
+	var p Product
+	err = s.productsCollection.FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"file": 1})).Decode(&p)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("product not found")
+		}
+		log.Printf("Error finding product file: %v", err)
+		return nil, errors.New("database error retrieving product")
+	}
+	if p.File == nil {
+		return nil, errors.New("file not found")
+	}
+	return p.File, nil
+}
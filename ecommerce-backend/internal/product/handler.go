@@ -3,26 +3,112 @@ package product
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"                  // For request body validation
-	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
-	"go.mongodb.org/mongo-driver/bson/primitive"              // For converting ID strings
+	"github.com/go-playground/validator/v10"                     // For request body validation
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"   // For preview token settings
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/exchange" // For showing prices in other currencies
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils"    // For standardized responses
+	"go.mongodb.org/mongo-driver/bson/primitive"                 // For converting ID strings
+)
+
+const (
+	maxImportBytes       = 32 << 20 // Largest accepted import file (32 MiB)
+	importAsyncThreshold = 500      // Files with more rows than this run as a background job
+	maxDigitalFileBytes  = 1 << 30  // Largest accepted digital product file (1 GiB)
 )
 
 // ProductHandler handles HTTP requests related to products.
 type ProductHandler struct {
 	Service   ProductService
+	Rates     exchange.ExchangeService
 	Validator *validator.Validate
+
+	// Draft preview links are signed with the JWT secret and expire after PreviewTTL.
+	JWTSecret  string
+	PreviewTTL time.Duration
 }
 
 // NewProductHandler creates a new ProductHandler instance.
-func NewProductHandler(s ProductService) *ProductHandler {
+func NewProductHandler(s ProductService, rates exchange.ExchangeService, cfg *config.Config) *ProductHandler {
 	return &ProductHandler{
-		Service:   s,
-		Validator: validator.New(),
+		Service:    s,
+		Rates:      rates,
+		Validator:  validator.New(),
+		JWTSecret:  cfg.JWTSecret,
+		PreviewTTL: cfg.PreviewTokenTTL,
+	}
+}
+
+// canSeeUnpublished reports whether the caller may view the given draft: admins always can,
+// anyone else needs a valid preview token for it in the "preview" query parameter.
+func (h *ProductHandler) canSeeUnpublished(c *gin.Context, productID string) bool {
+	if c.GetString("userRole") == "admin" {
+		return true
+	}
+	token := c.Query("preview")
+	return token != "" && utils.ValidatePreviewToken(token, productID, h.JWTSecret) == nil
+}
+
+// requestedRate resolves the optional "currency" query parameter. It returns nil when
+// prices should stay in the base currency. On failure it has already written the response.
+func (h *ProductHandler) requestedRate(ctx context.Context, c *gin.Context) (*exchange.ExchangeRate, bool) {
+	currency := c.Query("currency")
+	if currency == "" {
+		return nil, true
+	}
+	rate, err := h.Rates.GetRate(ctx, currency)
+	if err != nil {
+		if err.Error() == "unsupported currency" || err.Error() == "invalid currency code" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	if rate.Currency == h.Rates.BaseCurrency() {
+		return nil, true
+	}
+	return rate, true
+}
+
+// convertPrice shows a product's price in the requested currency, keeping the base price alongside.
+func convertPrice(p *ProductResponse, rate *exchange.ExchangeRate) {
+	if rate == nil {
+		return
+	}
+	base := p.Price
+	p.BasePrice = &base
+	p.Price = exchange.Apply(base, rate)
+	if p.CompareAtPrice != nil {
+		compareAt := exchange.Apply(*p.CompareAtPrice, rate)
+		p.CompareAtPrice = &compareAt
+	}
+}
+
+// pricingContext resolves prices for the caller's customer segment, if they are logged in.
+func pricingContext(ctx context.Context, c *gin.Context) context.Context {
+	return WithSegment(ctx, c.GetString("userSegment"))
+}
+
+// setPricingCacheHeaders keeps shared caches from serving one caller's prices to another:
+// prices (and, for admins, drafts) depend on the optional Authorization header, and a segment's
+// sale price must only be cached by the caller's own client.
+func setPricingCacheHeaders(c *gin.Context, segmentSale bool) {
+	c.Header("Vary", "Authorization")
+	if segmentSale && c.Writer.Header().Get("Cache-Control") == "" {
+		c.Header("Cache-Control", "private")
 	}
 }
 
@@ -64,7 +150,9 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 			utils.RespondWithError(c, http.StatusConflict, err.Error())
 			return
 		}
-		if err.Error() == "invalid category ID format" {
+		if err.Error() == "invalid category ID format" || strings.HasPrefix(err.Error(), "invalid publication") ||
+			strings.HasPrefix(err.Error(), "invalid bundle") || strings.HasPrefix(err.Error(), "invalid digital product") ||
+			strings.HasPrefix(err.Error(), "invalid gift card product") || strings.HasPrefix(err.Error(), "invalid backorder settings") {
 			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -81,8 +169,12 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 // @Tags Products
 // @Produce  json
 // @Param   id path string true "Product ID"
+// @Param   currency query string false "Show prices in this currency (ISO 4217)"
+// @Param   preview query string false "Preview token for viewing a draft"
+// @Param   If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} map[string]interface{} "Product data"
-// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Success 304 "Not modified (ETag matches If-None-Match)"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format or unsupported currency"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products/{id} [get]
@@ -92,7 +184,12 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	productResp, err := h.Service.GetProductByID(ctx, productID)
+	rate, ok := h.requestedRate(ctx, c)
+	if !ok {
+		return
+	}
+
+	productResp, err := h.Service.GetProductByID(pricingContext(ctx, c), productID)
 	if err != nil {
 		if err.Error() == "product not found" {
 			utils.RespondWithError(c, http.StatusNotFound, err.Error())
@@ -105,27 +202,77 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if productResp.Status == StatusDraft {
+		// Drafts don't exist as far as the public is concerned.
+		if !h.canSeeUnpublished(c, productID) {
+			utils.RespondWithError(c, http.StatusNotFound, "product not found")
+			return
+		}
+		c.Header("Cache-Control", "private, no-store")
+	}
+
+	// Let caches revalidate cheaply: an unchanged version means an unchanged product.
+	// The running sale and a converted price's rate change the representation too, so they
+	// become part of the tag, as does a bundle's stock, which follows its components.
+	etag := utils.FormatETag(productResp.Version)
+	var variant []string
+	if len(productResp.Components) > 0 {
+		variant = append(variant, fmt.Sprintf("stock%d.%d", productResp.Stock, productResp.Available))
+	}
+	if segment := c.GetString("userSegment"); segment != "" {
+		variant = append(variant, "seg"+segment)
+	}
+	if productResp.SaleID != "" {
+		variant = append(variant, "sale"+productResp.SaleID)
+	}
+	if rate != nil {
+		variant = append(variant, rate.Currency+"@"+rate.Rate)
+		convertPrice(productResp, rate)
+	}
+	if len(variant) > 0 {
+		etag = utils.FormatVariantETag(productResp.Version, strings.Join(variant, "-"))
+	}
+	c.Header("ETag", etag)
+	setPricingCacheHeaders(c, productResp.SegmentSale)
+	if utils.NotModified(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 
 	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"product": productResp})
 }
 
 // GetAllProducts godoc
 // @Summary Get all products
-// @Description Retrieve a list of all products
+// @Description Retrieve a list of all published products; admins also see drafts and unlisted products
 // @Tags Products
 // @Produce  json
+// @Param   currency query string false "Show prices in this currency (ISO 4217)"
 // @Success 200 {object} map[string]interface{} "List of products"
+// @Failure 400 {object} map[string]interface{} "Unsupported currency"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products [get]
 func (h *ProductHandler) GetAllProducts(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	products, err := h.Service.GetAllProducts(ctx)
+	rate, ok := h.requestedRate(ctx, c)
+	if !ok {
+		return
+	}
+
+	// Admins see drafts and unlisted products in the listing too.
+	products, err := h.Service.GetAllProducts(pricingContext(ctx, c), c.GetString("userRole") == "admin")
 	if err != nil {
 		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	segmentSale := false
+	for i := range products {
+		convertPrice(&products[i], rate)
+		segmentSale = segmentSale || products[i].SegmentSale
+	}
+	setPricingCacheHeaders(c, segmentSale)
 
 	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"products": products})
 }
@@ -138,16 +285,24 @@ func (h *ProductHandler) GetAllProducts(c *gin.Context) {
 // @Produce  json
 // @Param   id path string true "Product ID"
 // @Param   request body ProductUpdateRequest true "Product Update Info"
+// @Param   If-Match header string false "ETag the update is based on"
 // @Security ApiKeyAuth
 // @Success 200 {object} map[string]interface{} "Product updated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or invalid ID"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
 // @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 412 {object} map[string]interface{} "Product was modified since the given ETag"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	productID := c.Param("id")
+	expectedVersion, err := utils.ExpectedVersion(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var req ProductUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
@@ -172,13 +327,20 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	productResp, err := h.Service.UpdateProduct(ctx, productID, &req)
+	productResp, err := h.Service.UpdateProduct(ctx, productID, &req, expectedVersion)
 	if err != nil {
 		if err.Error() == "product not found" {
 			utils.RespondWithError(c, http.StatusNotFound, err.Error())
 			return
 		}
-		if err.Error() == "invalid product ID format" || err.Error() == "no fields provided for update" {
+		if err.Error() == "version mismatch" {
+			utils.RespondWithError(c, http.StatusPreconditionFailed, "Product was modified by someone else (version mismatch); reload and retry")
+			return
+		}
+		if err.Error() == "invalid product ID format" || err.Error() == "no fields provided for update" ||
+			strings.HasPrefix(err.Error(), "invalid stock") || strings.HasPrefix(err.Error(), "invalid publication") ||
+			strings.HasPrefix(err.Error(), "invalid bundle") || strings.HasPrefix(err.Error(), "invalid digital product") ||
+			strings.HasPrefix(err.Error(), "invalid backorder settings") {
 			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -186,31 +348,597 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", utils.FormatETag(productResp.Version))
 	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Product updated successfully", "product": productResp})
 }
 
 // DeleteProduct godoc
 // @Summary Delete a product
-// @Description Delete a product by ID (admin only)
+// @Description Archive a product by ID (admin only). The product is hidden from listings and can no longer be ordered, but existing orders keep their reference to it.
 // @Tags Products
 // @Produce  json
 // @Param   id path string true "Product ID"
+// @Param   If-Match header string false "ETag the deletion is based on"
 // @Security ApiKeyAuth
-// @Success 200 {object} map[string]interface{} "Product deleted successfully"
+// @Success 200 {object} map[string]interface{} "Product archived successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid product ID format"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
 // @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
 // @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 412 {object} map[string]interface{} "Product was modified since the given ETag"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Router /products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	productID := c.Param("id")
+	expectedVersion, err := utils.ExpectedVersion(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err = h.Service.DeleteProduct(ctx, productID, expectedVersion)
+	if err != nil {
+		if err.Error() == "product not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "version mismatch" {
+			utils.RespondWithError(c, http.StatusPreconditionFailed, "Product was modified by someone else (version mismatch); reload and retry")
+			return
+		}
+		if err.Error() == "invalid product ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Product archived successfully"})
+}
+
+// GetArchivedProducts godoc
+// @Summary Get archived products
+// @Description Retrieve a list of all archived products (admin only)
+// @Tags Products
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of archived products"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/archived [get]
+func (h *ProductHandler) GetArchivedProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	products, err := h.Service.GetArchivedProducts(ctx)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"products": products})
+}
+
+// RestoreProduct godoc
+// @Summary Restore an archived product
+// @Description Un-archive a product by ID, making it visible and orderable again (admin only)
+// @Tags Products
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Product restored successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Archived product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	productID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	productResp, err := h.Service.RestoreProduct(ctx, productID)
+	if err != nil {
+		if err.Error() == "archived product not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "invalid product ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Product restored successfully", "product": productResp})
+}
+
+// PurgeProduct godoc
+// @Summary Permanently delete an archived product
+// @Description Purge an archived product once its retention period has passed and no open order or bundle references it (admin only)
+// @Tags Products
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Product purged successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 409 {object} map[string]interface{} "Product is not archived, within retention, or referenced by open orders or a bundle"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/purge [post]
+func (h *ProductHandler) PurgeProduct(c *gin.Context) {
+	productID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.Service.PurgeProduct(ctx, productID)
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid product ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		case "product is not archived", "product is still within the retention period", "product is referenced by open orders", "product is a component of a bundle":
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Product purged successfully"})
+}
+
+// PurgeArchivedProducts godoc
+// @Summary Purge all expired archived products
+// @Description Permanently delete every archived product past the retention period, skipping those referenced by open orders or bundles (admin only)
+// @Tags Products
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Purge report"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/purge [post]
+func (h *ProductHandler) PurgeArchivedProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second) // May touch many documents
+	defer cancel()
+
+	result, err := h.Service.PurgeArchivedProducts(ctx)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"result": result})
+}
+
+// importFormat works out the import file format from the "format" query parameter,
+// falling back to the file extension or the request Content-Type.
+func importFormat(c *gin.Context, filename string) string {
+	if format := strings.ToLower(c.Query("format")); format != "" {
+		if format == "jsonl" {
+			return FormatNDJSON
+		}
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	}
+	contentType := c.ContentType()
+	switch {
+	case contentType == "text/csv":
+		return FormatCSV
+	case contentType == "application/x-ndjson", contentType == "application/jsonl", contentType == "application/x-jsonlines":
+		return FormatNDJSON
+	}
+	return ""
+}
+
+// ImportProducts godoc
+// @Summary Bulk import products
+// @Description Upsert products by SKU from a CSV or NDJSON file, sent either as the raw request body or as a multipart "file" field (admin only).
+// @Description Every row is validated with the same rules as a single create. Files with more than 500 rows (or async=true) run as a background job.
+// @Tags Products
+// @Accept  text/csv
+// @Accept  application/x-ndjson
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param   format query string false "File format: csv or ndjson (defaults to file extension or Content-Type)"
+// @Param   dryRun query bool false "Validate and report without writing"
+// @Param   async query bool false "Force the import to run as a background job"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Per-row import report"
+// @Success 202 {object} map[string]interface{} "Import job started"
+// @Failure 400 {object} map[string]interface{} "Unsupported format or unreadable file"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 413 {object} map[string]interface{} "Import file too large"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/import [post]
+func (h *ProductHandler) ImportProducts(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "dryRun must be true or false")
+		return
+	}
+	async, err := strconv.ParseBool(c.DefaultQuery("async", "false"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "async must be true or false")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var body io.Reader = c.Request.Body
+	filename := ""
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Multipart import requires a \"file\" field: "+err.Error())
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Failed to read uploaded file: "+err.Error())
+			return
+		}
+		defer file.Close()
+		body = file
+		filename = fileHeader.Filename
+	}
+
+	var rows []ImportRow
+	switch importFormat(c, filename) {
+	case FormatCSV:
+		rows, err = parseCSVImport(body, h.Rates.BaseCurrency())
+	case FormatNDJSON:
+		rows, err = parseNDJSONImport(body, h.Rates.BaseCurrency())
+	default:
+		utils.RespondWithError(c, http.StatusBadRequest, "Unsupported import format: use format=csv or format=ndjson")
+		return
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || strings.Contains(err.Error(), "request body too large") {
+			utils.RespondWithError(c, http.StatusRequestEntityTooLarge, "Import file too large")
+			return
+		}
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "Import file contains no rows")
+		return
+	}
+
+	if async || len(rows) > importAsyncThreshold {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		job, err := h.Service.StartImportJob(ctx, rows, dryRun)
+		if err != nil {
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		utils.RespondWithSuccess(c, http.StatusAccepted, gin.H{"message": "Import job started", "job": job})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second) // One lookup and write per row
+	defer cancel()
+
+	report, err := h.Service.ImportProducts(ctx, rows, dryRun)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"report": report})
+}
+
+// GetImportJob godoc
+// @Summary Get a product import job
+// @Description Retrieve the status and error report of a background product import (admin only)
+// @Tags Products
+// @Produce  json
+// @Param   jobId path string true "Import job ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Import job"
+// @Failure 400 {object} map[string]interface{} "Invalid job ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Import job not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/import/jobs/{jobId} [get]
+func (h *ProductHandler) GetImportJob(c *gin.Context) {
+	jobID := c.Param("jobId")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	job, err := h.Service.GetImportJob(ctx, jobID)
+	if err != nil {
+		if err.Error() == "import job not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "invalid job ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"job": job})
+}
+
+// ExportProducts godoc
+// @Summary Export the product catalog
+// @Description Stream the full catalog as CSV or NDJSON (admin only). The CSV output can be re-imported as-is.
+// @Tags Products
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Param   format query string false "csv (default) or ndjson"
+// @Param   includeArchived query bool false "Include archived products"
+// @Security ApiKeyAuth
+// @Success 200 {file} file "Product catalog"
+// @Failure 400 {object} map[string]interface{} "Unsupported format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Router /admin/products/export [get]
+func (h *ProductHandler) ExportProducts(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", FormatCSV))
+	includeArchived, err := strconv.ParseBool(c.DefaultQuery("includeArchived", "false"))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "includeArchived must be true or false")
+		return
+	}
+
+	filename := "products-" + time.Now().UTC().Format("20060102-150405")
+	var write func(*Product) error
+	var flush func() error
+
+	switch format {
+	case FormatCSV:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		w := csv.NewWriter(c.Writer)
+		if err := w.Write(exportColumns); err != nil {
+			return
+		}
+		write = func(p *Product) error { return w.Write(exportCSVRecord(p)) }
+		flush = func() error { w.Flush(); return w.Error() }
+	case FormatNDJSON, "jsonl":
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.ndjson"`)
+		enc := json.NewEncoder(c.Writer) // Encode writes one JSON value per line
+		write = func(p *Product) error { return enc.Encode(productToResponse(p)) }
+		flush = func() error { return nil }
+	default:
+		utils.RespondWithError(c, http.StatusBadRequest, "Unsupported export format: use format=csv or format=ndjson")
+		return
+	}
+	c.Status(http.StatusOK)
+
+	// Flush periodically so large exports stream instead of buffering in memory.
+	written := 0
+	err = h.Service.ExportProducts(c.Request.Context(), includeArchived, func(p *Product) error {
+		if err := write(p); err != nil {
+			return err
+		}
+		written++
+		if written%jobProgressInterval == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	c.Writer.Flush()
+	if err != nil {
+		// Headers are already sent, so the client just sees a truncated file.
+		log.Printf("Product export aborted after %d rows: %v", written, err)
+	}
+}
+
+// AddPriceSchedule godoc
+// @Summary Schedule a sale price
+// @Description Schedule a sale price for a product between startsAt and endsAt, optionally limited to a customer segment (admin only)
+// @Tags Products
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   schedule body PriceScheduleRequest true "Sale price and period"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Price schedule added successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or invalid schedule"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/price-schedules [post]
+func (h *ProductHandler) AddPriceSchedule(c *gin.Context) {
+	productID := c.Param("id")
+
+	var req PriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	schedule, err := h.Service.AddPriceSchedule(ctx, productID, &req)
+	if err != nil {
+		if err.Error() == "product not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "invalid product ID format" || strings.HasPrefix(err.Error(), "invalid schedule") {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Price schedule added successfully", "schedule": schedule})
+}
+
+// GetPriceSchedules godoc
+// @Summary List a product's price schedules
+// @Description Retrieve the scheduled, running and recently ended sale prices of a product (admin only)
+// @Tags Products
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of price schedules"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/price-schedules [get]
+func (h *ProductHandler) GetPriceSchedules(c *gin.Context) {
+	productID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	schedules, err := h.Service.GetPriceSchedules(ctx, productID)
+	if err != nil {
+		if err.Error() == "product not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "invalid product ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"schedules": schedules})
+}
+
+// DeletePriceSchedule godoc
+// @Summary Cancel a price schedule
+// @Description Remove a scheduled or running sale price from a product (admin only)
+// @Tags Products
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   scheduleId path string true "Price schedule ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Price schedule removed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product or schedule ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Price schedule not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/price-schedules/{scheduleId} [delete]
+func (h *ProductHandler) DeletePriceSchedule(c *gin.Context) {
+	productID := c.Param("id")
+	scheduleID := c.Param("scheduleId")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Service.DeletePriceSchedule(ctx, productID, scheduleID); err != nil {
+		if err.Error() == "price schedule not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "invalid product ID format" || err.Error() == "invalid schedule ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Price schedule removed successfully"})
+}
+
+// GetPriceHistory godoc
+// @Summary Get a product's price history
+// @Description Retrieve every regular price change and sale schedule change of a product, newest first (admin only)
+// @Tags Products
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Price history"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/price-history [get]
+func (h *ProductHandler) GetPriceHistory(c *gin.Context) {
+	productID := c.Param("id")
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	err := h.Service.DeleteProduct(ctx, productID)
+	history, err := h.Service.GetPriceHistory(ctx, productID)
 	if err != nil {
+		if err.Error() == "invalid product ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"history": history})
+}
+
+// CreatePreviewToken godoc
+// @Summary Create a draft preview link
+// @Description Issue a token that lets anyone holding it view the product on the storefront before it is published (admin only)
+// @Tags Products
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Preview token, link and expiry"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/preview-token [post]
+func (h *ProductHandler) CreatePreviewToken(c *gin.Context) {
+	productID := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := h.Service.GetProductByID(ctx, productID); err != nil {
 		if err.Error() == "product not found" {
 			utils.RespondWithError(c, http.StatusNotFound, err.Error())
 			return
@@ -223,5 +951,78 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Product deleted successfully"})
+	token, expiresAt, err := utils.GeneratePreviewToken(productID, h.JWTSecret, h.PreviewTTL)
+	if err != nil {
+		log.Printf("Error generating preview token: %v", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "failed to generate preview token")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"preview": PreviewTokenResponse{
+		Token:      token,
+		PreviewURL: "/api/products/" + productID + "?preview=" + token,
+		ExpiresAt:  expiresAt,
+	}})
+}
+
+// UploadProductFile godoc
+// @Summary Upload a digital product's file
+// @Description Upload the file customers download after buying a digital product, as a multipart "file" field. Replaces any previous file (admin only).
+// @Tags Products
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   file formData file true "File to deliver"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "File uploaded successfully"
+// @Failure 400 {object} map[string]interface{} "Missing file, invalid product ID, or product is not digital"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/file [put]
+func (h *ProductHandler) UploadProductFile(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxDigitalFileBytes)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.RespondWithError(c, http.StatusRequestEntityTooLarge, "File too large")
+			return
+		}
+		utils.RespondWithError(c, http.StatusBadRequest, "Upload requires a multipart \"file\" field: "+err.Error())
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Failed to read uploaded file: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	contentType := fileHeader.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute) // Large files take a while to store
+	defer cancel()
+
+	productResp, err := h.Service.AttachFile(ctx, c.Param("id"), filepath.Base(fileHeader.Filename), contentType, file)
+	if err != nil {
+		if err.Error() == "product not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "invalid product ID format" || err.Error() == "product is not digital" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "File uploaded successfully", "product": productResp})
 }
@@ -0,0 +1,101 @@
+// internal/product/handler_test.go
+package product
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeProductService serves one product and records the versions writes were based on.
+// Methods the tests don't use are left to the embedded (nil) interface.
+type fakeProductService struct {
+	ProductService
+	product *ProductResponse
+
+	updatedVersion *int64
+	deletedVersion *int64
+}
+
+func (f *fakeProductService) GetProductByID(ctx context.Context, id string) (*ProductResponse, error) {
+	p := *f.product
+	return &p, nil
+}
+
+func (f *fakeProductService) UpdateProduct(ctx context.Context, id string, req *ProductUpdateRequest, expectedVersion *int64) (*ProductResponse, error) {
+	f.updatedVersion = expectedVersion
+	p := *f.product
+	p.Version++
+	return &p, nil
+}
+
+func (f *fakeProductService) DeleteProduct(ctx context.Context, id string, expectedVersion *int64) error {
+	f.deletedVersion = expectedVersion
+	return nil
+}
+
+// TestWriteWithVariantETag sends back the ETag of a read whose representation varies (a
+// bundle's stock, the caller's segment, a running sale) as If-Match on writes.
+func TestWriteWithVariantETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeProductService{product: &ProductResponse{
+		ID:         primitive.NewObjectID().Hex(),
+		Name:       "Starter kit",
+		Status:     StatusPublished,
+		Version:    5,
+		Stock:      3,
+		Available:  2,
+		SaleID:     primitive.NewObjectID().Hex(),
+		Components: []BundleComponent{{ProductID: primitive.NewObjectID(), Quantity: 2}},
+	}}
+	h := &ProductHandler{Service: fake, Validator: validator.New()}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userRole", "admin")
+		c.Set("userSegment", "vip")
+	})
+	router.GET("/products/:id", h.GetProductByID)
+	router.PUT("/products/:id", h.UpdateProduct)
+	router.DELETE("/products/:id", h.DeleteProduct)
+
+	path := "/products/" + fake.product.ID
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(etag, `"5-`) {
+		t.Fatalf("GET %s = %d with ETag %s, want 200 with a variant of version 5", path, rec.Code, etag)
+	}
+
+	tests := []struct {
+		method string
+		body   string
+		got    func() *int64
+	}{
+		{http.MethodPut, `{"name":"Starter kit v2"}`, func() *int64 { return fake.updatedVersion }},
+		{http.MethodDelete, "", func() *int64 { return fake.deletedVersion }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", etag)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s with If-Match %s = %d: %s", tt.method, etag, rec.Code, rec.Body)
+			}
+			if got := tt.got(); got == nil || *got != 5 {
+				t.Errorf("%s was based on version %v, want 5", tt.method, got)
+			}
+		})
+	}
+}
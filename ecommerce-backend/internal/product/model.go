@@ -5,52 +5,292 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
 )
 
 // Product represents a product in the system.
 type Product struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name        string             `bson:"name" json:"name" validate:"required,min=3,max=100"`
-	Description string             `bson:"description" json:"description" validate:"required,min=10,max=500"`
-	Price       float64            `bson:"price" json:"price" validate:"required,gt=0"`              // gt=0 means greater than 0
-	SKU         string             `bson:"sku" json:"sku" validate:"required,alphanum,min=5,max=20"` // Stock Keeping Unit
-	CategoryID  primitive.ObjectID `bson:"categoryID" json:"categoryID" validate:"required"`         // Reference to the Category
-	Stock       int                `bson:"stock" json:"stock" validate:"required,gte=0"`             // gte=0 means greater than or equal to 0
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name             string             `bson:"name" json:"name" validate:"required,min=3,max=100"`
+	Description      string             `bson:"description" json:"description" validate:"required,min=10,max=500"`
+	Price            money.Money        `bson:"price" json:"price"`                                           // In the store base currency
+	SKU              string             `bson:"sku" json:"sku" validate:"required,alphanum,min=5,max=20"`     // Stock Keeping Unit
+	CategoryID       primitive.ObjectID `bson:"categoryID" json:"categoryID" validate:"required"`             // Reference to the Category
+	Stock            int                `bson:"stock" json:"stock" validate:"required,gte=0"`                 // On-hand units; gte=0 means greater than or equal to 0
+	Reserved         int                `bson:"reserved" json:"reserved"`                                     // Units held by unpaid orders (see inventory package)
+	ReorderThreshold int                `bson:"reorderThreshold,omitempty" json:"reorderThreshold,omitempty"` // Low-stock alert level for available stock; 0 disables alerts
+	BackorderLimit   int                `bson:"backorderLimit,omitempty" json:"backorderLimit,omitempty"`     // Units that may be ordered beyond available stock; 0 disables backorders
+	Backordered      int                `bson:"backordered,omitempty" json:"backordered,omitempty"`           // Units ordered and still waiting for stock (see inventory package)
+	ReleaseAt        *time.Time         `bson:"releaseAt,omitempty" json:"releaseAt,omitempty"`               // Until then every order is a pre-order, filled once released
+	PriceSchedules   []PriceSchedule    `bson:"priceSchedules,omitempty" json:"priceSchedules,omitempty"`     // Scheduled sale prices, see EffectivePrice
+	Status           string             `bson:"status" json:"status"`                                         // StatusDraft, StatusPublished or StatusUnlisted
+	Type             string             `bson:"type,omitempty" json:"type,omitempty"`                         // TypeBundle for bundles; empty for regular products
+	Components       []BundleComponent  `bson:"components,omitempty" json:"components,omitempty"`             // What a bundle is made of
+	Digital          bool               `bson:"digital,omitempty" json:"digital,omitempty"`                   // Delivered as a download; has no stock and is not shipped
+	File             *DigitalFile       `bson:"file,omitempty" json:"-"`                                      // The file delivered for a digital product
+	LicenseKeys      bool               `bson:"licenseKeys,omitempty" json:"licenseKeys,omitempty"`           // Each unit sold claims a key from the product's pool (see license package)
+	GiftCard         bool               `bson:"giftCard,omitempty" json:"giftCard,omitempty"`                 // Each unit paid for issues a gift card worth the regular price
+	PublishAt        *time.Time         `bson:"publishAt,omitempty" json:"publishAt,omitempty"`               // When a draft is published automatically
+	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt        time.Time          `bson:"updatedAt" json:"updatedAt"`
+	DeletedAt        *time.Time         `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"` // Set when the product is archived (soft-deleted)
+	Version          int64              `bson:"version" json:"version"`                         // Incremented on every write, exposed as the ETag
+}
+
+// IsBundle reports whether the product is a bundle of other products.
+func (p *Product) IsBundle() bool {
+	return p.Type == TypeBundle
+}
+
+// IsPreorder reports whether the product is not released yet, so orders for it are pre-orders.
+func (p *Product) IsPreorder(now time.Time) bool {
+	return p.ReleaseAt != nil && now.Before(*p.ReleaseAt)
+}
+
+// CanBackorder reports whether quantity more units can be ordered without stock: up to
+// BackorderLimit units outstanding, or without limit for a pre-order when no limit is set.
+func (p *Product) CanBackorder(quantity int, now time.Time) bool {
+	if p.BackorderLimit == 0 {
+		return p.IsPreorder(now)
+	}
+	return p.Backordered+quantity <= p.BackorderLimit
+}
+
+// Available returns the units that can still be sold to new orders: on-hand stock minus active
+// reservations and minus the units owed to backordered lines, which are filled first.
+func (p *Product) Available() int {
+	if available := p.Unreserved() - p.Backordered; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// Unreserved returns the on-hand stock not held by any reservation. Backordered lines are
+// filled from it, oldest order first.
+func (p *Product) Unreserved() int {
+	if unreserved := p.Stock - p.Reserved; unreserved > 0 {
+		return unreserved
+	}
+	return 0
 }
 
 // ProductResponse defines the structure for product data in API responses.
 // It might be slightly different from the internal Product struct.
 type ProductResponse struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	SKU         string    `json:"sku"`
-	CategoryID  string    `json:"categoryID"`
-	Stock       int       `json:"stock"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	Price            money.Money       `json:"price"`
+	BasePrice        *money.Money      `json:"basePrice,omitempty"` // Set when Price was converted to a requested currency
+	SKU              string            `json:"sku"`
+	CategoryID       string            `json:"categoryID"`
+	Stock            int               `json:"stock"`     // On hand
+	Available        int               `json:"available"` // Available to sell: on hand minus reserved and backordered
+	ReorderThreshold int               `json:"reorderThreshold,omitempty"`
+	BackorderLimit   int               `json:"backorderLimit,omitempty"` // Units that can be ordered beyond available stock
+	Backordered      int               `json:"backordered,omitempty"`    // Units ordered and waiting for stock
+	ReleaseAt        *time.Time        `json:"releaseAt,omitempty"`
+	Preorder         bool              `json:"preorder,omitempty"`       // Not released yet; orders are filled on release
+	CompareAtPrice   *money.Money      `json:"compareAtPrice,omitempty"` // Regular price, set only while a sale price applies
+	SaleID           string            `json:"saleId,omitempty"`         // Price schedule the current price comes from
+	SaleEndsAt       *time.Time        `json:"saleEndsAt,omitempty"`
+	SegmentSale      bool              `json:"-"` // The sale is for the caller's customer segment, so the price is per caller
+	Status           string            `json:"status"`
+	PublishAt        *time.Time        `json:"publishAt,omitempty"`
+	Type             string            `json:"type"`                  // TypeSimple or TypeBundle
+	Components       []BundleComponent `json:"components,omitempty"`  // For bundles; stock and availability are derived from these
+	Digital          bool              `json:"digital"`               // Always available; stock does not apply
+	FileName         string            `json:"fileName,omitempty"`    // Name of the file a digital product delivers
+	LicenseKeys      bool              `json:"licenseKeys,omitempty"` // Each unit comes with a license key
+	GiftCard         bool              `json:"giftCard,omitempty"`    // Each unit is a gift card worth the price
+	CreatedAt        time.Time         `json:"createdAt"`
+	UpdatedAt        time.Time         `json:"updatedAt"`
+	DeletedAt        *time.Time        `json:"deletedAt,omitempty"` // Only present on archived products
+	Version          int64             `json:"version"`
 }
 
 // ProductCreateRequest defines the structure for creating a new product.
 type ProductCreateRequest struct {
-	Name        string  `json:"name" validate:"required,min=3,max=100"`
-	Description string  `json:"description" validate:"required,min=10,max=500"`
-	Price       float64 `json:"price" validate:"required,gt=0"`
-	SKU         string  `json:"sku" validate:"required,alphanum,min=5,max=20"`
-	CategoryID  string  `json:"categoryID" validate:"required"` // We expect the CategoryID as a string from the request
-	Stock       int     `json:"stock" validate:"required,gte=0"`
+	Name             string                   `json:"name" validate:"required,min=3,max=100"`
+	Description      string                   `json:"description" validate:"required,min=10,max=500"`
+	Price            float64                  `json:"price" validate:"required,gt=0"` // Major units of the base currency, e.g. 19.99
+	SKU              string                   `json:"sku" validate:"required,alphanum,min=5,max=20"`
+	CategoryID       string                   `json:"categoryID" validate:"required"`                                       // We expect the CategoryID as a string from the request
+	Stock            int                      `json:"stock" validate:"gte=0"`                                               // Must be 0 for bundles
+	ReorderThreshold int                      `json:"reorderThreshold,omitempty" validate:"gte=0"`                          // Optional; admins are alerted when available stock drops to it
+	BackorderLimit   int                      `json:"backorderLimit,omitempty" validate:"gte=0"`                            // Optional; allows ordering this many units beyond available stock
+	ReleaseAt        *time.Time               `json:"releaseAt,omitempty"`                                                  // Optional; takes pre-orders until then
+	Status           string                   `json:"status,omitempty" validate:"omitempty,oneof=draft published unlisted"` // Defaults to published, or draft when PublishAt is in the future
+	PublishAt        *time.Time               `json:"publishAt,omitempty"`                                                  // Schedules a draft to be published
+	Components       []BundleComponentRequest `json:"components,omitempty" validate:"omitempty,max=20,dive"`                // Makes the product a bundle
+	Digital          bool                     `json:"digital,omitempty"`                                                    // Sold as a download; upload the file separately
+	GiftCard         bool                     `json:"giftCard,omitempty"`                                                   // Sold as a gift card; must be digital and needs no file
 }
 
 // ProductUpdateRequest defines the structure for updating an existing product.
 // All fields are optional, so we can update only specific fields.
 type ProductUpdateRequest struct {
-	Name        *string  `json:"name,omitempty" validate:"omitempty,min=3,max=100"` // Pointers to allow optional fields
-	Description *string  `json:"description,omitempty" validate:"omitempty,min=10,max=500"`
-	Price       *float64 `json:"price,omitempty" validate:"omitempty,gt=0"`
-	SKU         *string  `json:"sku,omitempty" validate:"omitempty,alphanum,min=5,max=20"`
-	CategoryID  *string  `json:"categoryID,omitempty"` // Optional
-	Stock       *int     `json:"stock,omitempty" validate:"omitempty,gte=0"`
+	Name             *string                  `json:"name,omitempty" validate:"omitempty,min=3,max=100"` // Pointers to allow optional fields
+	Description      *string                  `json:"description,omitempty" validate:"omitempty,min=10,max=500"`
+	Price            *float64                 `json:"price,omitempty" validate:"omitempty,gt=0"` // Major units of the base currency
+	SKU              *string                  `json:"sku,omitempty" validate:"omitempty,alphanum,min=5,max=20"`
+	CategoryID       *string                  `json:"categoryID,omitempty"` // Optional
+	Stock            *int                     `json:"stock,omitempty" validate:"omitempty,gte=0"`
+	ReorderThreshold *int                     `json:"reorderThreshold,omitempty" validate:"omitempty,gte=0"`                // 0 turns low-stock alerts off
+	BackorderLimit   *int                     `json:"backorderLimit,omitempty" validate:"omitempty,gte=0"`                  // 0 turns backorders off; units already backordered are still filled
+	ReleaseAt        *time.Time               `json:"releaseAt,omitempty"`                                                  // A past time releases the product
+	Status           *string                  `json:"status,omitempty" validate:"omitempty,oneof=draft published unlisted"` // Without PublishAt this clears any publishing schedule
+	PublishAt        *time.Time               `json:"publishAt,omitempty"`                                                  // Schedules the product (as a draft) to be published
+	Components       []BundleComponentRequest `json:"components,omitempty" validate:"omitempty,max=20,dive"`                // Replaces a bundle's components
+}
+
+// Product types.
+const (
+	TypeSimple = "simple" // A product with its own stock
+	TypeBundle = "bundle" // A kit made of other products, sold at its own price
+)
+
+// BundleComponent is one product in a bundle and how many units of it the bundle contains.
+type BundleComponent struct {
+	ProductID primitive.ObjectID `bson:"productID" json:"productId"`
+	Quantity  int                `bson:"quantity" json:"quantity"`
+}
+
+// DigitalFile describes the file held in storage for a digital product.
+type DigitalFile struct {
+	Key         string    `bson:"key"` // Storage key
+	Name        string    `bson:"name"`
+	ContentType string    `bson:"contentType"`
+	Size        int64     `bson:"size"`
+	UploadedAt  time.Time `bson:"uploadedAt"`
+}
+
+// BundleComponentRequest defines a bundle component in create and update requests.
+type BundleComponentRequest struct {
+	ProductID string `json:"productId" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0"`
+}
+
+// Product statuses. Only published products are listed publicly; unlisted ones can still be
+// viewed and ordered by ID, and drafts are hidden from everyone but admins and preview links.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusUnlisted  = "unlisted"
+)
+
+// PreviewTokenResponse is returned when an admin requests a draft preview link.
+type PreviewTokenResponse struct {
+	Token      string    `json:"token"`
+	PreviewURL string    `json:"previewUrl"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// PriceSchedule is a sale price that applies between StartsAt and EndsAt,
+// optionally only to customers in one segment.
+type PriceSchedule struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	SalePrice money.Money        `bson:"salePrice" json:"salePrice"`
+	StartsAt  time.Time          `bson:"startsAt" json:"startsAt"`
+	EndsAt    time.Time          `bson:"endsAt" json:"endsAt"`                       // Exclusive
+	Segment   string             `bson:"segment,omitempty" json:"segment,omitempty"` // Empty applies to every customer
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// PriceScheduleRequest defines the structure for scheduling a sale price.
+type PriceScheduleRequest struct {
+	SalePrice float64   `json:"salePrice" validate:"required,gt=0"` // Major units of the base currency
+	StartsAt  time.Time `json:"startsAt" validate:"required"`
+	EndsAt    time.Time `json:"endsAt" validate:"required,gtfield=StartsAt"`
+	Segment   string    `json:"segment,omitempty" validate:"omitempty,alphanum,max=50"`
+}
+
+// Price history events.
+const (
+	PriceEventSet             = "price_set"        // Regular price set on create, update or import
+	PriceEventScheduleAdded   = "schedule_added"   // Sale price scheduled
+	PriceEventScheduleRemoved = "schedule_removed" // Sale price cancelled
+)
+
+// PriceHistoryEntry records one change to a product's pricing.
+type PriceHistoryEntry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID primitive.ObjectID `bson:"productID" json:"productId"`
+	Event     string             `bson:"event" json:"event"`
+	OldPrice  *money.Money       `bson:"oldPrice,omitempty" json:"oldPrice,omitempty"`
+	NewPrice  *money.Money       `bson:"newPrice,omitempty" json:"newPrice,omitempty"`
+	Schedule  *PriceSchedule     `bson:"schedule,omitempty" json:"schedule,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+}
+
+// PurgeResult reports the outcome of a bulk purge of archived products.
+type PurgeResult struct {
+	Purged  []string       `json:"purged"`
+	Skipped []PurgeSkipped `json:"skipped"`
+}
+
+// PurgeSkipped explains why an archived product was not purged.
+type PurgeSkipped struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// Import actions reported per row.
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionError  = "error"
+)
+
+// Import job statuses.
+const (
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// ImportRow is a single parsed row of an import file.
+type ImportRow struct {
+	Line       int                  // 1-based line number in the source file
+	Request    ProductCreateRequest // Row data, validated with the same rules as a single create
+	Price      money.Money          // Exact price parsed from the file; Request.Price is only used for validation
+	ParseError string               // Set when the row could not be parsed at all
+}
+
+// ImportRowResult is the per-row outcome of an import.
+type ImportRowResult struct {
+	Line   int    `bson:"line" json:"line"`
+	SKU    string `bson:"sku" json:"sku"`
+	Action string `bson:"action" json:"action"` // "create", "update" or "error"
+	Error  string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// ImportReport summarises an import run.
+type ImportReport struct {
+	DryRun    bool              `json:"dryRun"`
+	TotalRows int               `json:"totalRows"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// ImportJob tracks a large import running in the background.
+// Only failed rows are kept in Errors to bound the document size.
+type ImportJob struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Status     string             `bson:"status" json:"status"`
+	DryRun     bool               `bson:"dryRun" json:"dryRun"`
+	TotalRows  int                `bson:"totalRows" json:"totalRows"`
+	Processed  int                `bson:"processed" json:"processed"`
+	Created    int                `bson:"created" json:"created"`
+	Updated    int                `bson:"updated" json:"updated"`
+	Failed     int                `bson:"failed" json:"failed"`
+	Errors     []ImportRowResult  `bson:"errors" json:"errors"`
+	Message    string             `bson:"message,omitempty" json:"message,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	StartedAt  *time.Time         `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	FinishedAt *time.Time         `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
 }
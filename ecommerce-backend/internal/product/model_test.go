@@ -0,0 +1,29 @@
+// internal/product/model_test.go
+package product
+
+import "testing"
+
+func TestAvailable(t *testing.T) {
+	tests := []struct {
+		name                         string
+		stock, reserved, backordered int
+		wantAvailable                int // For new orders
+		wantUnreserved               int // For backordered lines
+	}{
+		{"plain stock", 5, 0, 0, 5, 5},
+		{"reservations", 5, 2, 0, 3, 3},
+		{"restock goes to waiting lines first", 5, 0, 3, 2, 5},
+		{"restock short of waiting lines", 2, 0, 3, 0, 2},
+		{"reservations and waiting lines", 6, 2, 3, 1, 4},
+		{"over-reserved", 2, 3, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		p := &Product{Stock: tt.stock, Reserved: tt.reserved, Backordered: tt.backordered}
+		if got := p.Available(); got != tt.wantAvailable {
+			t.Errorf("%s: Available() = %d, want %d", tt.name, got, tt.wantAvailable)
+		}
+		if got := p.Unreserved(); got != tt.wantUnreserved {
+			t.Errorf("%s: Unreserved() = %d, want %d", tt.name, got, tt.wantUnreserved)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// internal/product/pricing.go
+package product
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// segmentKey is the context key for the customer segment prices are resolved for.
+type segmentKey struct{}
+
+// WithSegment returns a context that resolves prices for the given customer segment.
+func WithSegment(ctx context.Context, segment string) context.Context {
+	return context.WithValue(ctx, segmentKey{}, segment)
+}
+
+// SegmentFromContext returns the customer segment set with WithSegment, or "".
+func SegmentFromContext(ctx context.Context) string {
+	segment, _ := ctx.Value(segmentKey{}).(string)
+	return segment
+}
+
+// appliesTo reports whether the schedule is running at the given time for a segment.
+func (ps *PriceSchedule) appliesTo(at time.Time, segment string) bool {
+	if at.Before(ps.StartsAt) || !at.Before(ps.EndsAt) {
+		return false
+	}
+	return ps.Segment == "" || ps.Segment == segment
+}
+
+// EffectivePrice returns the price a customer in segment pays at the given time, and the
+// schedule it comes from (nil for the regular price). When several sales overlap the lowest
+// price wins; a "sale" that is not below the regular price is ignored.
+func (p *Product) EffectivePrice(at time.Time, segment string) (money.Money, *PriceSchedule) {
+	price := p.Price
+	var applied *PriceSchedule
+	for i := range p.PriceSchedules {
+		ps := &p.PriceSchedules[i]
+		if !ps.appliesTo(at, segment) || ps.SalePrice.Currency != price.Currency {
+			continue
+		}
+		if ps.SalePrice.Amount < price.Amount {
+			price, applied = ps.SalePrice, ps
+		}
+	}
+	return price, applied
+}
+
+// pricedResponse converts a product for API responses, with the price resolved for the
+// segment in ctx at the current time. While a sale applies the regular price is shown as
+// the compare-at price.
+func pricedResponse(ctx context.Context, p *Product) *ProductResponse {
+	resp := productToResponse(p)
+	price, sale := p.EffectivePrice(time.Now(), SegmentFromContext(ctx))
+	if sale != nil {
+		regular := resp.Price
+		endsAt := sale.EndsAt
+		resp.CompareAtPrice = &regular
+		resp.Price = price
+		resp.SaleID = sale.ID.Hex()
+		resp.SaleEndsAt = &endsAt
+		resp.SegmentSale = sale.Segment != ""
+	}
+	return resp
+}
+
+// recordPriceChange appends an entry to the product's price history.
+func (s *service) recordPriceChange(ctx context.Context, entry PriceHistoryEntry) error {
+	entry.At = time.Now()
+	if _, err := s.priceHistoryCollection.InsertOne(ctx, entry); err != nil {
+		log.Printf("Error recording price history for product %s: %v", entry.ProductID.Hex(), err)
+		return errors.New("failed to record price history")
+	}
+	return nil
+}
+
+// AddPriceSchedule schedules a sale price for a product. Schedules that have already ended
+// are pruned at the same time.
+func (s *service) AddPriceSchedule(ctx context.Context, id string, req *PriceScheduleRequest) (*PriceSchedule, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+	now := time.Now()
+	if !req.EndsAt.After(now) {
+		return nil, errors.New("invalid schedule: endsAt must be in the future")
+	}
+
+	schedule := PriceSchedule{
+		ID:        primitive.NewObjectID(),
+		SalePrice: money.FromMajor(req.SalePrice, s.baseCurrency),
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Segment:   req.Segment,
+		CreatedAt: now,
+	}
+
+	err = database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		var p Product
+		if err := s.productsCollection.FindOne(sessionContext, activeFilter(bson.M{"_id": objID})).Decode(&p); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return errors.New("product not found")
+			}
+			log.Printf("Error finding product for price schedule: %v", err)
+			return errors.New("database error retrieving product")
+		}
+		if schedule.SalePrice.Amount >= p.Price.Amount {
+			return errors.New("invalid schedule: sale price must be lower than the regular price")
+		}
+
+		if _, err := s.productsCollection.UpdateOne(sessionContext, bson.M{"_id": objID}, bson.M{
+			"$pull": bson.M{"priceSchedules": bson.M{"endsAt": bson.M{"$lte": now}}},
+		}); err != nil {
+			log.Printf("Error pruning ended price schedules: %v", err)
+			return errors.New("failed to add price schedule")
+		}
+		if _, err := s.productsCollection.UpdateOne(sessionContext, bson.M{"_id": objID}, bson.M{
+			"$push": bson.M{"priceSchedules": schedule},
+			"$set":  bson.M{"updatedAt": now},
+			"$inc":  bson.M{"version": 1},
+		}); err != nil {
+			log.Printf("Error adding price schedule: %v", err)
+			return errors.New("failed to add price schedule")
+		}
+		return s.recordPriceChange(sessionContext, PriceHistoryEntry{ProductID: objID, Event: PriceEventScheduleAdded, Schedule: &schedule})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// GetPriceSchedules lists a product's price schedules, including ones not yet started.
+func (s *service) GetPriceSchedules(ctx context.Context, id string) ([]PriceSchedule, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+
+	var p Product
+	err = s.productsCollection.FindOne(ctx, bson.M{"_id": objID},
+		options.FindOne().SetProjection(bson.M{"priceSchedules": 1})).Decode(&p)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("product not found")
+		}
+		log.Printf("Error finding price schedules: %v", err)
+		return nil, errors.New("database error retrieving product")
+	}
+	if p.PriceSchedules == nil {
+		return []PriceSchedule{}, nil
+	}
+	return p.PriceSchedules, nil
+}
+
+// DeletePriceSchedule cancels a scheduled (or running) sale price.
+func (s *service) DeletePriceSchedule(ctx context.Context, id, scheduleID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid product ID format")
+	}
+	scheduleObjID, err := primitive.ObjectIDFromHex(scheduleID)
+	if err != nil {
+		return errors.New("invalid schedule ID format")
+	}
+
+	return database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		var p Product
+		err := s.productsCollection.FindOneAndUpdate(sessionContext,
+			bson.M{"_id": objID, "priceSchedules._id": scheduleObjID},
+			bson.M{
+				"$pull": bson.M{"priceSchedules": bson.M{"_id": scheduleObjID}},
+				"$set":  bson.M{"updatedAt": time.Now()},
+				"$inc":  bson.M{"version": 1},
+			},
+		).Decode(&p) // The document before the update still holds the removed schedule
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return errors.New("price schedule not found")
+			}
+			log.Printf("Error removing price schedule: %v", err)
+			return errors.New("failed to remove price schedule")
+		}
+
+		entry := PriceHistoryEntry{ProductID: objID, Event: PriceEventScheduleRemoved}
+		for i := range p.PriceSchedules {
+			if p.PriceSchedules[i].ID == scheduleObjID {
+				entry.Schedule = &p.PriceSchedules[i]
+			}
+		}
+		return s.recordPriceChange(sessionContext, entry)
+	})
+}
+
+// GetPriceHistory returns a product's pricing changes, newest first.
+func (s *service) GetPriceHistory(ctx context.Context, id string) ([]PriceHistoryEntry, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+
+	cursor, err := s.priceHistoryCollection.Find(ctx, bson.M{"productID": objID},
+		options.Find().SetSort(bson.D{{Key: "at", Value: -1}}))
+	if err != nil {
+		log.Printf("Error finding price history: %v", err)
+		return nil, errors.New("failed to retrieve price history")
+	}
+	defer cursor.Close(ctx)
+
+	history := []PriceHistoryEntry{}
+	if err = cursor.All(ctx, &history); err != nil {
+		log.Printf("Error decoding price history: %v", err)
+		return nil, errors.New("failed to process price history")
+	}
+	return history, nil
+}
@@ -0,0 +1,68 @@
+// internal/product/publishing.go
+package product
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// resolvePublication works out the status and publishing schedule requested for a product.
+// A future publishAt schedules a draft; one that has already passed publishes straight away.
+// An empty status means none was given and is returned as is when there is no publishAt.
+func resolvePublication(status string, publishAt *time.Time, now time.Time) (string, *time.Time, error) {
+	if publishAt == nil {
+		return status, nil, nil
+	}
+	if status != "" && status != StatusDraft {
+		return "", nil, errors.New("invalid publication: publishAt can only be set on drafts")
+	}
+	if !publishAt.After(now) {
+		return StatusPublished, nil, nil
+	}
+	return StatusDraft, publishAt, nil
+}
+
+// PublishDueProducts publishes every draft whose publishAt has passed and returns how many were published.
+func (s *service) PublishDueProducts(ctx context.Context) (int64, error) {
+	now := time.Now()
+	res, err := s.productsCollection.UpdateMany(ctx,
+		activeFilter(bson.M{"status": StatusDraft, "publishAt": bson.M{"$lte": now}}),
+		bson.M{
+			"$set":   bson.M{"status": StatusPublished, "updatedAt": now},
+			"$unset": bson.M{"publishAt": ""},
+			"$inc":   bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		log.Printf("Error publishing scheduled products: %v", err)
+		return 0, errors.New("failed to publish scheduled products")
+	}
+	return res.ModifiedCount, nil
+}
+
+// StartPublisher runs PublishDueProducts on every tick of interval, in a background goroutine.
+func (s *service) StartPublisher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			publishCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			if n, err := s.PublishDueProducts(publishCtx); err != nil {
+				log.Printf("Scheduled publishing failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Published %d scheduled products", n)
+			}
+			cancel()
+		}
+	}()
+}
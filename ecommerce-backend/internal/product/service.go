@@ -4,36 +4,104 @@ package product
 import (
 	"context"
 	"errors"
+	"io"
 	"log"
 	"time"
 
+	"github.com/go-playground/validator/v10" // Import rows are validated like single creates
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options" // For find options like limit, skip, sort
 
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
 	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database" // Import your database package
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/stockalert"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/storage"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/warehouse"
 )
 
+// openOrderStatuses are the order statuses that still need their products to exist.
+// Mirrors the order package constants; duplicated here to avoid an import cycle.
+var openOrderStatuses = []string{"pending", "processing", "shipped"}
+
+// activeFilter matches products that have not been archived.
+// A nil comparison matches both a missing and an explicitly null deletedAt field.
+func activeFilter(extra bson.M) bson.M {
+	filter := bson.M{"deletedAt": nil}
+	for k, v := range extra {
+		filter[k] = v
+	}
+	return filter
+}
+
 // ProductService defines the interface for product operations.
 type ProductService interface {
 	CreateProduct(ctx context.Context, req *ProductCreateRequest) (*ProductResponse, error)
-	GetProductByID(ctx context.Context, id string) (*ProductResponse, error)
-	GetAllProducts(ctx context.Context) ([]ProductResponse, error) // For now, no filters/pagination
-	UpdateProduct(ctx context.Context, id string, req *ProductUpdateRequest) (*ProductResponse, error)
-	DeleteProduct(ctx context.Context, id string) error
-	GetProductForOrder(ctx context.Context, id string) (*Product, error) // Internal use for order processing
+	GetProductByID(ctx context.Context, id string) (*ProductResponse, error)                // Any non-archived product; callers decide who may see drafts
+	GetAllProducts(ctx context.Context, includeUnpublished bool) ([]ProductResponse, error) // For now, no filters/pagination
+	// expectedVersion, when non-nil, makes the write conditional on the product's current version (If-Match).
+	UpdateProduct(ctx context.Context, id string, req *ProductUpdateRequest, expectedVersion *int64) (*ProductResponse, error)
+	DeleteProduct(ctx context.Context, id string, expectedVersion *int64) error   // Archives (soft-deletes) the product
+	GetProductForOrder(ctx context.Context, id string) (*Product, error)          // Internal use for order processing
+	GetBundleComponents(ctx context.Context, bundle *Product) ([]*Product, error) // Internal use for order processing
+	GetProductFile(ctx context.Context, id string) (*DigitalFile, error)          // Internal use for downloads; archived products included
+	GetBackorderedProducts(ctx context.Context) ([]Product, error)                // Internal use for filling backorders; archived products included
+
+	// Archive management (admin only)
+	GetArchivedProducts(ctx context.Context) ([]ProductResponse, error)
+	RestoreProduct(ctx context.Context, id string) (*ProductResponse, error)
+	PurgeProduct(ctx context.Context, id string) error
+	PurgeArchivedProducts(ctx context.Context) (*PurgeResult, error)
+
+	// Bulk import/export (admin only)
+	ImportProducts(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error)
+	StartImportJob(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportJob, error) // For large files; runs in the background
+	GetImportJob(ctx context.Context, id string) (*ImportJob, error)
+	ExportProducts(ctx context.Context, includeArchived bool, fn func(*Product) error) error
+
+	// Scheduled pricing (admin only). Reads resolve the sale price for the segment set with WithSegment.
+	AddPriceSchedule(ctx context.Context, id string, req *PriceScheduleRequest) (*PriceSchedule, error)
+	GetPriceSchedules(ctx context.Context, id string) ([]PriceSchedule, error)
+	DeletePriceSchedule(ctx context.Context, id, scheduleID string) error
+	GetPriceHistory(ctx context.Context, id string) ([]PriceHistoryEntry, error)
+
+	// AttachFile uploads the file delivered for a digital product, replacing any previous one (admin only).
+	AttachFile(ctx context.Context, id, name, contentType string, r io.Reader) (*ProductResponse, error)
+
+	// Scheduled publishing of drafts
+	PublishDueProducts(ctx context.Context) (int64, error)
+	StartPublisher(ctx context.Context, interval time.Duration) // Runs PublishDueProducts until ctx is cancelled
 }
 
 // service implements ProductService.
 type service struct {
-	productsCollection *mongo.Collection
+	productsCollection     *mongo.Collection
+	ordersCollection       *mongo.Collection // Read-only: used to check open order references before purging
+	importJobsCollection   *mongo.Collection
+	priceHistoryCollection *mongo.Collection
+	validator              *validator.Validate
+	purgeRetention         time.Duration
+	baseCurrency           string                     // Prices from requests (major units) are stored in this currency
+	warehouseService       warehouse.WarehouseService // Stock given on create/update goes to the default warehouse
+	stockAlerts            stockalert.StockAlertService
+	files                  storage.Storage // Holds digital product files
 }
 
 // NewProductService creates a new product service.
-func NewProductService() ProductService {
+func NewProductService(cfg *config.Config, warehouseService warehouse.WarehouseService, stockAlerts stockalert.StockAlertService, files storage.Storage) ProductService {
 	return &service{
-		productsCollection: database.GetCollection("products"), // Get the 'products' collection
+		productsCollection:     database.GetCollection("products"), // Get the 'products' collection
+		ordersCollection:       database.GetCollection("orders"),
+		importJobsCollection:   database.GetCollection("product_import_jobs"),
+		priceHistoryCollection: database.GetCollection("price_history"),
+		validator:              validator.New(),
+		purgeRetention:         cfg.ProductPurgeRetention,
+		baseCurrency:           cfg.BaseCurrency,
+		warehouseService:       warehouseService,
+		stockAlerts:            stockAlerts,
+		files:                  files,
 	}
 }
 
@@ -47,11 +115,44 @@ func productToResponse(p *Product) *ProductResponse {
 		SKU:         p.SKU,
 		CategoryID:  p.CategoryID.Hex(),
 		Stock:       p.Stock,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		Available:   p.Available(),
+
+		ReorderThreshold: p.ReorderThreshold,
+		BackorderLimit:   p.BackorderLimit,
+		Backordered:      p.Backordered,
+		ReleaseAt:        p.ReleaseAt,
+		Preorder:         p.IsPreorder(time.Now()),
+		Status:           p.Status,
+		PublishAt:        p.PublishAt,
+		Type:             productType(p),
+		Components:       p.Components,
+		Digital:          p.Digital,
+		FileName:         fileName(p),
+		LicenseKeys:      p.LicenseKeys,
+		GiftCard:         p.GiftCard,
+		CreatedAt:        p.CreatedAt,
+		UpdatedAt:        p.UpdatedAt,
+		DeletedAt:        p.DeletedAt,
+		Version:          p.Version,
 	}
 }
 
+// fileName returns the name of a digital product's file, if one has been uploaded.
+func fileName(p *Product) string {
+	if p.File == nil {
+		return ""
+	}
+	return p.File.Name
+}
+
+// productType returns the product's type for responses; products without one are simple.
+func productType(p *Product) string {
+	if p.Type == "" {
+		return TypeSimple
+	}
+	return p.Type
+}
+
 // CreateProduct handles the creation of a new product.
 func (s *service) CreateProduct(ctx context.Context, req *ProductCreateRequest) (*ProductResponse, error) {
 	// Convert CategoryID string to ObjectID
@@ -72,26 +173,87 @@ func (s *service) CreateProduct(ctx context.Context, req *ProductCreateRequest)
 	}
 
 	now := time.Now()
+	status, publishAt, err := resolvePublication(req.Status, req.PublishAt, now)
+	if err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = StatusPublished // Products go live straight away unless created as drafts
+	}
+
+	if req.Digital && (req.Stock != 0 || len(req.Components) > 0) {
+		return nil, errors.New("invalid digital product: digital products have no stock and cannot be bundles")
+	}
+	if req.GiftCard && !req.Digital {
+		return nil, errors.New("invalid gift card product: gift cards must be digital")
+	}
+	if (req.BackorderLimit > 0 || req.ReleaseAt != nil) && (req.Digital || len(req.Components) > 0) {
+		return nil, errors.New("invalid backorder settings: bundles and digital products cannot be backordered or pre-ordered")
+	}
+	var productType string
+	var components []BundleComponent
+	if len(req.Components) > 0 {
+		if req.Stock != 0 {
+			return nil, errors.New("invalid bundle: bundles take their stock from their components")
+		}
+		if components, err = s.buildComponents(ctx, primitive.NilObjectID, req.Components); err != nil {
+			return nil, err
+		}
+		productType = TypeBundle
+	}
+
 	product := &Product{
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		Price:       money.FromMajor(req.Price, s.baseCurrency),
 		SKU:         req.SKU,
 		CategoryID:  categoryObjectID,
-		Stock:       req.Stock,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		Version:     1,
+
+		ReorderThreshold: req.ReorderThreshold,
+		BackorderLimit:   req.BackorderLimit,
+		ReleaseAt:        req.ReleaseAt,
+		Status:           status,
+		PublishAt:        publishAt,
+		Type:             productType,
+		Components:       components,
+		Digital:          req.Digital,
+		GiftCard:         req.GiftCard,
 	}
 
-	result, err := s.productsCollection.InsertOne(ctx, product)
+	// Stock is the sum of per-warehouse levels, so the product starts empty and the
+	// initial stock is booked into the default warehouse in the same transaction.
+	err = database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		result, err := s.productsCollection.InsertOne(sessionContext, product)
+		if err != nil {
+			log.Printf("Error inserting new product: %v", err)
+			return errors.New("failed to create product")
+		}
+		product.ID = result.InsertedID.(primitive.ObjectID)
+
+		if err := s.recordPriceChange(sessionContext, PriceHistoryEntry{ProductID: product.ID, Event: PriceEventSet, NewPrice: &product.Price}); err != nil {
+			return err
+		}
+		if req.Stock > 0 {
+			if err := s.warehouseService.SetDefaultStock(sessionContext, product.ID, req.Stock); err != nil {
+				return err
+			}
+			product.Stock = req.Stock
+			product.Version++
+		}
+		return nil
+	})
 	if err != nil {
-		log.Printf("Error inserting new product: %v", err)
-		return nil, errors.New("failed to create product")
+		return nil, err
 	}
+	s.stockAlerts.StockChanged(product.ID)
 
-	product.ID = result.InsertedID.(primitive.ObjectID)
-
-	return productToResponse(product), nil
+	if err := s.deriveBundleStock(ctx, product); err != nil {
+		return nil, err
+	}
+	return pricedResponse(ctx, product), nil
 }
 
 // GetProductByID retrieves a product by its ID.
@@ -102,7 +264,7 @@ func (s *service) GetProductByID(ctx context.Context, id string) (*ProductRespon
 	}
 
 	var product Product
-	err = s.productsCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&product)
+	err = s.productsCollection.FindOne(ctx, activeFilter(bson.M{"_id": objID})).Decode(&product)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errors.New("product not found")
@@ -110,14 +272,22 @@ func (s *service) GetProductByID(ctx context.Context, id string) (*ProductRespon
 		log.Printf("Error finding product by ID: %v", err)
 		return nil, errors.New("database error retrieving product")
 	}
+	if err := s.deriveBundleStock(ctx, &product); err != nil {
+		return nil, err
+	}
 
-	return productToResponse(&product), nil
+	return pricedResponse(ctx, &product), nil
 }
 
-// GetAllProducts retrieves all products.
+// GetAllProducts retrieves all products that have not been archived. Drafts and unlisted
+// products are only included when includeUnpublished is set.
 // In a real application, you'd add pagination and filtering here.
-func (s *service) GetAllProducts(ctx context.Context) ([]ProductResponse, error) {
-	cursor, err := s.productsCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})) // Sort by creation date descending
+func (s *service) GetAllProducts(ctx context.Context, includeUnpublished bool) ([]ProductResponse, error) {
+	filter := activeFilter(bson.M{"status": StatusPublished})
+	if includeUnpublished {
+		filter = activeFilter(nil)
+	}
+	cursor, err := s.productsCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})) // Sort by creation date descending
 	if err != nil {
 		log.Printf("Error finding all products: %v", err)
 		return nil, errors.New("failed to retrieve products")
@@ -130,16 +300,26 @@ func (s *service) GetAllProducts(ctx context.Context) ([]ProductResponse, error)
 		return nil, errors.New("failed to process product data")
 	}
 
+	bundles := make([]*Product, 0)
+	for i := range products {
+		if products[i].IsBundle() {
+			bundles = append(bundles, &products[i])
+		}
+	}
+	if err := s.deriveBundleStock(ctx, bundles...); err != nil {
+		return nil, err
+	}
+
 	var productResponses []ProductResponse
 	for _, p := range products {
-		productResponses = append(productResponses, *productToResponse(&p))
+		productResponses = append(productResponses, *pricedResponse(ctx, &p))
 	}
 
 	return productResponses, nil
 }
 
 // UpdateProduct updates an existing product.
-func (s *service) UpdateProduct(ctx context.Context, id string, req *ProductUpdateRequest) (*ProductResponse, error) {
+func (s *service) UpdateProduct(ctx context.Context, id string, req *ProductUpdateRequest, expectedVersion *int64) (*ProductResponse, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, errors.New("invalid product ID format")
@@ -153,7 +333,7 @@ func (s *service) UpdateProduct(ctx context.Context, id string, req *ProductUpda
 		update["description"] = *req.Description
 	}
 	if req.Price != nil {
-		update["price"] = *req.Price
+		update["price"] = money.FromMajor(*req.Price, s.baseCurrency)
 	}
 	if req.SKU != nil {
 		update["sku"] = *req.SKU
@@ -165,59 +345,164 @@ func (s *service) UpdateProduct(ctx context.Context, id string, req *ProductUpda
 		}
 		update["categoryID"] = categoryObjectID
 	}
-	if req.Stock != nil {
-		update["stock"] = *req.Stock
+	if req.ReorderThreshold != nil {
+		update["reorderThreshold"] = *req.ReorderThreshold
+	}
+	if req.BackorderLimit != nil {
+		update["backorderLimit"] = *req.BackorderLimit
+	}
+	if req.ReleaseAt != nil {
+		update["releaseAt"] = *req.ReleaseAt
+	}
+	if req.Components != nil {
+		components, err := s.buildComponents(ctx, objID, req.Components)
+		if err != nil {
+			return nil, err
+		}
+		update["components"] = components
+	}
+	unset := bson.M{}
+	if req.Status != nil || req.PublishAt != nil {
+		var requested string
+		if req.Status != nil {
+			requested = *req.Status
+		}
+		status, publishAt, err := resolvePublication(requested, req.PublishAt, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		update["status"] = status
+		if publishAt != nil {
+			update["publishAt"] = *publishAt
+		} else {
+			unset["publishAt"] = ""
+		}
 	}
 
-	if len(update) == 0 {
+	if len(update) == 0 && req.Stock == nil {
 		return nil, errors.New("no fields provided for update")
 	}
 
 	update["updatedAt"] = time.Now() // Update the timestamp on any change
+	updateDoc := bson.M{"$set": update, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		updateDoc["$unset"] = unset
+	}
 
-	// Use $set to apply the updates
-	result := s.productsCollection.FindOneAndUpdate(
-		ctx,
-		bson.M{"_id": objID},
-		bson.M{"$set": update},
-		options.FindOneAndUpdate().SetReturnDocument(options.After), // Return the updated document
-	)
+	var updatedProduct Product
+	err = database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		var before Product
+		if req.Price != nil {
+			// The old price goes into the price history; a missing product is reported by the update below.
+			err := s.productsCollection.FindOne(sessionContext, bson.M{"_id": objID}).Decode(&before)
+			if err != nil && err != mongo.ErrNoDocuments {
+				log.Printf("Error reading product before update: %v", err)
+				return errors.New("failed to update product")
+			}
+		}
 
-	if result.Err() != nil {
-		if result.Err() == mongo.ErrNoDocuments {
-			return nil, errors.New("product not found")
+		// Use $set to apply the updates. Archived products must be restored before they can be edited.
+		err := s.productsCollection.FindOneAndUpdate(
+			sessionContext,
+			database.WithVersion(activeFilter(bson.M{"_id": objID}), expectedVersion),
+			updateDoc,
+			options.FindOneAndUpdate().SetReturnDocument(options.After), // Return the updated document
+		).Decode(&updatedProduct)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return s.missOrConflict(sessionContext, objID, expectedVersion)
+			}
+			log.Printf("Error updating product: %v", err)
+			return errors.New("failed to update product")
+		}
+		// Bundles are told apart only once the product is loaded; returning an error rolls the update back.
+		if req.Components != nil && !updatedProduct.IsBundle() {
+			return errors.New("invalid bundle: only bundles have components")
+		}
+		if req.Stock != nil && updatedProduct.IsBundle() {
+			return errors.New("invalid bundle: bundles take their stock from their components")
+		}
+		if req.Stock != nil && updatedProduct.Digital {
+			return errors.New("invalid digital product: digital products have no stock")
+		}
+		if (updatedProduct.BackorderLimit > 0 || updatedProduct.ReleaseAt != nil) && (updatedProduct.IsBundle() || updatedProduct.Digital) {
+			return errors.New("invalid backorder settings: bundles and digital products cannot be backordered or pre-ordered")
+		}
+		if req.Price != nil && updatedProduct.Price != before.Price {
+			entry := PriceHistoryEntry{ProductID: objID, Event: PriceEventSet, OldPrice: &before.Price, NewPrice: &updatedProduct.Price}
+			if err := s.recordPriceChange(sessionContext, entry); err != nil {
+				return err
+			}
 		}
-		log.Printf("Error updating product: %v", result.Err())
-		return nil, errors.New("failed to update product")
-	}
 
-	var updatedProduct Product
-	if err := result.Decode(&updatedProduct); err != nil {
-		log.Printf("Error decoding updated product: %v", err)
-		return nil, errors.New("failed to decode updated product data")
+		if req.Stock == nil {
+			return nil
+		}
+		// Stock on a product update sets the default warehouse; other warehouses keep theirs.
+		if err := s.warehouseService.SetDefaultStock(sessionContext, objID, *req.Stock); err != nil {
+			return err
+		}
+		if err := s.productsCollection.FindOne(sessionContext, bson.M{"_id": objID}).Decode(&updatedProduct); err != nil {
+			log.Printf("Error decoding updated product: %v", err)
+			return errors.New("failed to decode updated product data")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.Stock != nil || req.ReorderThreshold != nil {
+		s.stockAlerts.StockChanged(objID)
 	}
 
-	return productToResponse(&updatedProduct), nil
+	if err := s.deriveBundleStock(ctx, &updatedProduct); err != nil {
+		return nil, err
+	}
+	return pricedResponse(ctx, &updatedProduct), nil
 }
 
-// DeleteProduct deletes a product by its ID.
-func (s *service) DeleteProduct(ctx context.Context, id string) error {
+// DeleteProduct archives a product by its ID.
+// The document is kept so that existing orders referencing it stay intact; it is hidden
+// from public listings and can no longer be ordered.
+func (s *service) DeleteProduct(ctx context.Context, id string, expectedVersion *int64) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.New("invalid product ID format")
 	}
 
-	res, err := s.productsCollection.DeleteOne(ctx, bson.M{"_id": objID})
+	now := time.Now()
+	res, err := s.productsCollection.UpdateOne(
+		ctx,
+		database.WithVersion(activeFilter(bson.M{"_id": objID}), expectedVersion),
+		bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}, "$inc": bson.M{"version": 1}},
+	)
 	if err != nil {
-		log.Printf("Error deleting product: %v", err)
+		log.Printf("Error archiving product: %v", err)
 		return errors.New("failed to delete product")
 	}
-	if res.DeletedCount == 0 {
-		return errors.New("product not found")
+	if res.MatchedCount == 0 {
+		return s.missOrConflict(ctx, objID, expectedVersion)
 	}
 	return nil
 }
 
+// missOrConflict explains why a conditional write matched nothing: either the product
+// does not exist (or is archived), or it exists but has moved past the expected version.
+func (s *service) missOrConflict(ctx context.Context, objID primitive.ObjectID, expectedVersion *int64) error {
+	if expectedVersion == nil {
+		return errors.New("product not found")
+	}
+	count, err := s.productsCollection.CountDocuments(ctx, activeFilter(bson.M{"_id": objID}))
+	if err != nil {
+		log.Printf("Error checking product existence after conditional write: %v", err)
+		return errors.New("database error retrieving product")
+	}
+	if count == 0 {
+		return errors.New("product not found")
+	}
+	return errors.New("version mismatch")
+}
+
 // GetProductForOrder is an internal helper to retrieve product details needed for order processing.
 // It returns the full Product struct, not just the response version, as order logic needs stock.
 func (s *service) GetProductForOrder(ctx context.Context, id string) (*Product, error) {
@@ -235,5 +520,191 @@ func (s *service) GetProductForOrder(ctx context.Context, id string) (*Product,
 		log.Printf("Error finding product for order: %v", err)
 		return nil, errors.New("database error retrieving product for order")
 	}
+	if product.DeletedAt != nil {
+		return nil, errors.New("product is archived and can no longer be ordered")
+	}
+	if product.Status == StatusDraft {
+		return nil, errors.New("product is not published yet")
+	}
 	return &product, nil
 }
+
+// GetBackorderedProducts returns the released products that have units waiting for stock and
+// stock available to fill them.
+func (s *service) GetBackorderedProducts(ctx context.Context) ([]Product, error) {
+	cursor, err := s.productsCollection.Find(ctx, bson.M{
+		"backordered": bson.M{"$gt": 0},
+		"$or":         bson.A{bson.M{"releaseAt": nil}, bson.M{"releaseAt": bson.M{"$lte": time.Now()}}},
+		"$expr":       bson.M{"$gt": bson.A{"$stock", bson.M{"$ifNull": bson.A{"$reserved", 0}}}},
+	})
+	if err != nil {
+		log.Printf("Error finding backordered products: %v", err)
+		return nil, errors.New("failed to retrieve backordered products")
+	}
+	defer cursor.Close(ctx)
+
+	var products []Product
+	if err := cursor.All(ctx, &products); err != nil {
+		log.Printf("Error decoding backordered products: %v", err)
+		return nil, errors.New("failed to process product data")
+	}
+	return products, nil
+}
+
+// GetArchivedProducts retrieves all archived products, most recently archived first.
+func (s *service) GetArchivedProducts(ctx context.Context) ([]ProductResponse, error) {
+	cursor, err := s.productsCollection.Find(ctx, bson.M{"deletedAt": bson.M{"$ne": nil}}, options.Find().SetSort(bson.D{{Key: "deletedAt", Value: -1}}))
+	if err != nil {
+		log.Printf("Error finding archived products: %v", err)
+		return nil, errors.New("failed to retrieve archived products")
+	}
+	defer cursor.Close(ctx)
+
+	var products []Product
+	if err = cursor.All(ctx, &products); err != nil {
+		log.Printf("Error decoding archived products from cursor: %v", err)
+		return nil, errors.New("failed to process product data")
+	}
+
+	productResponses := make([]ProductResponse, 0, len(products))
+	for _, p := range products {
+		productResponses = append(productResponses, *productToResponse(&p))
+	}
+
+	return productResponses, nil
+}
+
+// RestoreProduct un-archives a product, making it visible and orderable again.
+func (s *service) RestoreProduct(ctx context.Context, id string) (*ProductResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+
+	result := s.productsCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objID, "deletedAt": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deletedAt": ""}, "$set": bson.M{"updatedAt": time.Now()}, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return nil, errors.New("archived product not found")
+		}
+		log.Printf("Error restoring product: %v", result.Err())
+		return nil, errors.New("failed to restore product")
+	}
+
+	var restored Product
+	if err := result.Decode(&restored); err != nil {
+		log.Printf("Error decoding restored product: %v", err)
+		return nil, errors.New("failed to decode restored product data")
+	}
+	s.stockAlerts.StockChanged(restored.ID) // Waiting subscribers can buy it again
+
+	return productToResponse(&restored), nil
+}
+
+// PurgeProduct permanently deletes an archived product once its retention period has passed
+// and no open order references it.
+func (s *service) PurgeProduct(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid product ID format")
+	}
+
+	var product Product
+	err = s.productsCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("product not found")
+		}
+		log.Printf("Error finding product to purge: %v", err)
+		return errors.New("database error retrieving product")
+	}
+
+	if err := s.checkPurgeable(ctx, &product); err != nil {
+		return err
+	}
+	return s.deleteArchived(ctx, objID)
+}
+
+// PurgeArchivedProducts purges every archived product that is past the retention period.
+// Products that are still referenced by open orders or bundles are skipped and reported.
+func (s *service) PurgeArchivedProducts(ctx context.Context) (*PurgeResult, error) {
+	cutoff := time.Now().Add(-s.purgeRetention)
+	cursor, err := s.productsCollection.Find(ctx, bson.M{"deletedAt": bson.M{"$ne": nil, "$lte": cutoff}})
+	if err != nil {
+		log.Printf("Error finding products to purge: %v", err)
+		return nil, errors.New("failed to retrieve archived products")
+	}
+	defer cursor.Close(ctx)
+
+	var products []Product
+	if err = cursor.All(ctx, &products); err != nil {
+		log.Printf("Error decoding products to purge: %v", err)
+		return nil, errors.New("failed to process product data")
+	}
+
+	result := &PurgeResult{Purged: []string{}, Skipped: []PurgeSkipped{}}
+	for _, p := range products {
+		if err := s.checkPurgeable(ctx, &p); err != nil {
+			result.Skipped = append(result.Skipped, PurgeSkipped{ID: p.ID.Hex(), Reason: err.Error()})
+			continue
+		}
+		if err := s.deleteArchived(ctx, p.ID); err != nil {
+			result.Skipped = append(result.Skipped, PurgeSkipped{ID: p.ID.Hex(), Reason: err.Error()})
+			continue
+		}
+		result.Purged = append(result.Purged, p.ID.Hex())
+	}
+
+	return result, nil
+}
+
+// checkPurgeable enforces the retention policy for a single product.
+func (s *service) checkPurgeable(ctx context.Context, p *Product) error {
+	if p.DeletedAt == nil {
+		return errors.New("product is not archived")
+	}
+	if time.Since(*p.DeletedAt) < s.purgeRetention {
+		return errors.New("product is still within the retention period")
+	}
+
+	// Bundle lines ship their components, so those count as referenced too.
+	openOrders, err := s.ordersCollection.CountDocuments(ctx, bson.M{
+		"$or":    bson.A{bson.M{"items.productID": p.ID}, bson.M{"items.components.productID": p.ID}},
+		"status": bson.M{"$in": openOrderStatuses},
+	})
+	if err != nil {
+		log.Printf("Error counting open orders for product %s: %v", p.ID.Hex(), err)
+		return errors.New("failed to check open orders")
+	}
+	if openOrders > 0 {
+		return errors.New("product is referenced by open orders")
+	}
+
+	// Archived bundles count as well, since they can be restored.
+	bundles, err := s.productsCollection.CountDocuments(ctx, bson.M{"components.productID": p.ID})
+	if err != nil {
+		log.Printf("Error counting bundles containing product %s: %v", p.ID.Hex(), err)
+		return errors.New("failed to check bundles")
+	}
+	if bundles > 0 {
+		return errors.New("product is a component of a bundle")
+	}
+	return nil
+}
+
+// deleteArchived hard-deletes a product and its stock levels, guarded so that a concurrent restore wins.
+func (s *service) deleteArchived(ctx context.Context, objID primitive.ObjectID) error {
+	res, err := s.productsCollection.DeleteOne(ctx, bson.M{"_id": objID, "deletedAt": bson.M{"$ne": nil}})
+	if err != nil {
+		log.Printf("Error purging product: %v", err)
+		return errors.New("failed to purge product")
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("product is not archived")
+	}
+	return s.warehouseService.DeleteStockLevels(ctx, objID)
+}
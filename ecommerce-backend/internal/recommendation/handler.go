@@ -0,0 +1,123 @@
+// internal/recommendation/handler.go
+package recommendation
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 50
+)
+
+// RecommendationHandler handles HTTP requests related to recommendations.
+type RecommendationHandler struct {
+	Service RecommendationService
+}
+
+// NewRecommendationHandler creates a new RecommendationHandler instance.
+func NewRecommendationHandler(s RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{Service: s}
+}
+
+// parseLimit reads the optional "limit" query parameter, clamped to [1, maxLimit].
+func parseLimit(c *gin.Context) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return defaultLimit, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 {
+		return 0, false
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	return limit, true
+}
+
+// GetRelatedProducts godoc
+// @Summary Get related products
+// @Description Retrieve products frequently bought together with the given product
+// @Tags Recommendations
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Param   limit query int false "Maximum number of results (default 10, max 50)"
+// @Success 200 {object} map[string]interface{} "List of related products"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format or limit"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/related [get]
+func (h *RecommendationHandler) GetRelatedProducts(c *gin.Context) {
+	productID := c.Param("id")
+	limit, ok := parseLimit(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	related, err := h.Service.GetRelatedProducts(ctx, productID, limit)
+	if err != nil {
+		if err.Error() == "product not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "invalid product ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"productId": productID, "related": related})
+}
+
+// GetUserRecommendations godoc
+// @Summary Get recommendations for the authenticated user
+// @Description Retrieve personalised product recommendations based on the user's order history
+// @Tags Recommendations
+// @Produce  json
+// @Param   limit query int false "Maximum number of results (default 10, max 50)"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of recommended products"
+// @Failure 400 {object} map[string]interface{} "Invalid limit"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/recommendations [get]
+func (h *RecommendationHandler) GetUserRecommendations(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+	limit, ok := parseLimit(c)
+	if !ok {
+		utils.RespondWithError(c, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	recommendations, err := h.Service.GetUserRecommendations(ctx, userID.(string), limit)
+	if err != nil {
+		if err.Error() == "invalid user ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"recommendations": recommendations})
+}
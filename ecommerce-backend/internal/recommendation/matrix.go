@@ -0,0 +1,131 @@
+// internal/recommendation/matrix.go
+package recommendation
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxNeighbours = 50 // Similar products kept per product in a snapshot
+	maxPopular    = 50 // Products kept in the popularity fallback list
+)
+
+// buildSnapshot turns raw order and rating data into a Snapshot.
+//
+// Every order a user places counts as one interaction with each product in it, so repeat
+// purchases weigh more than a single large order. Explicit ratings, where available, add
+// rating/5 on top. Products are then compared with cosine similarity over their user vectors.
+func buildSnapshot(orders []orderDoc, ratings []ratingDoc) *Snapshot {
+	userItems := make(map[primitive.ObjectID]map[primitive.ObjectID]float64)
+	add := func(userID, productID primitive.ObjectID, weight float64) {
+		items, ok := userItems[userID]
+		if !ok {
+			items = make(map[primitive.ObjectID]float64)
+			userItems[userID] = items
+		}
+		items[productID] += weight
+	}
+
+	for _, o := range orders {
+		for _, item := range o.Items {
+			if item.Quantity <= 0 {
+				continue
+			}
+			add(o.UserID, item.ProductID, 1)
+		}
+	}
+	for _, r := range ratings {
+		if r.Rating <= 0 {
+			continue
+		}
+		add(r.UserID, r.ProductID, math.Min(r.Rating, 5)/5)
+	}
+
+	// Accumulate dot products between every pair of products a user interacted with,
+	// plus each product's squared norm and overall popularity.
+	dots := make(map[primitive.ObjectID]map[primitive.ObjectID]float64)
+	norms := make(map[primitive.ObjectID]float64)
+	popularity := make(map[primitive.ObjectID]float64)
+	for _, items := range userItems {
+		for i, wi := range items {
+			norms[i] += wi * wi
+			popularity[i] += wi
+			for j, wj := range items {
+				if i == j {
+					continue
+				}
+				row, ok := dots[i]
+				if !ok {
+					row = make(map[primitive.ObjectID]float64)
+					dots[i] = row
+				}
+				row[j] += wi * wj
+			}
+		}
+	}
+
+	related := make(map[primitive.ObjectID][]ScoredProduct, len(dots))
+	for i, row := range dots {
+		neighbours := make([]ScoredProduct, 0, len(row))
+		for j, dot := range row {
+			denom := math.Sqrt(norms[i]) * math.Sqrt(norms[j])
+			if denom == 0 {
+				continue
+			}
+			neighbours = append(neighbours, ScoredProduct{ProductID: j, Score: dot / denom})
+		}
+		related[i] = topN(neighbours, maxNeighbours)
+	}
+
+	popular := make([]ScoredProduct, 0, len(popularity))
+	for id, score := range popularity {
+		popular = append(popular, ScoredProduct{ProductID: id, Score: score})
+	}
+
+	return &Snapshot{
+		Related:   related,
+		UserItems: userItems,
+		Popular:   topN(popular, maxPopular),
+		BuiltAt:   time.Now(),
+	}
+}
+
+// recommendForUser scores products by summing their similarity to everything the user has
+// already interacted with, weighted by how strongly they interacted. Known products are skipped.
+func recommendForUser(snap *Snapshot, userID primitive.ObjectID, limit int) []ScoredProduct {
+	items := snap.UserItems[userID]
+	scores := make(map[primitive.ObjectID]float64)
+	for i, wi := range items {
+		for _, neighbour := range snap.Related[i] {
+			if _, owned := items[neighbour.ProductID]; owned {
+				continue
+			}
+			scores[neighbour.ProductID] += wi * neighbour.Score
+		}
+	}
+
+	result := make([]ScoredProduct, 0, len(scores))
+	for id, score := range scores {
+		result = append(result, ScoredProduct{ProductID: id, Score: score})
+	}
+	return topN(result, limit)
+}
+
+// topN sorts candidates by descending score and keeps at most n of them.
+// Ties are broken by ID so results are stable between requests.
+func topN(candidates []ScoredProduct, n int) []ScoredProduct {
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].Score != candidates[b].Score {
+			return candidates[a].Score > candidates[b].Score
+		}
+		return candidates[a].ProductID.Hex() < candidates[b].ProductID.Hex()
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
@@ -0,0 +1,49 @@
+// internal/recommendation/model.go
+package recommendation
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+)
+
+// ScoredProduct pairs a product ID with a similarity or recommendation score.
+type ScoredProduct struct {
+	ProductID primitive.ObjectID
+	Score     float64
+}
+
+// Snapshot is an immutable, in-memory view of the user–product matrix and the
+// item-to-item similarities derived from it. A new snapshot is built on every
+// rebuild and swapped in atomically, so reads never block on a rebuild.
+type Snapshot struct {
+	Related   map[primitive.ObjectID][]ScoredProduct                // Top neighbours per product, best first
+	UserItems map[primitive.ObjectID]map[primitive.ObjectID]float64 // userID -> productID -> interaction weight
+	Popular   []ScoredProduct                                       // Fallback for users without history
+	BuiltAt   time.Time
+}
+
+// orderDoc is the subset of an order document needed to build the matrix.
+// It is decoded directly from the "orders" collection to avoid importing the order package.
+type orderDoc struct {
+	UserID primitive.ObjectID `bson:"userID"`
+	Items  []struct {
+		ProductID primitive.ObjectID `bson:"productID"`
+		Quantity  int                `bson:"quantity"`
+	} `bson:"items"`
+}
+
+// ratingDoc is an explicit product rating, read from the optional "ratings" collection.
+type ratingDoc struct {
+	UserID    primitive.ObjectID `bson:"userID"`
+	ProductID primitive.ObjectID `bson:"productID"`
+	Rating    float64            `bson:"rating"` // 1-5 stars
+}
+
+// RecommendedProduct defines the structure for a recommended product in API responses.
+type RecommendedProduct struct {
+	Product product.ProductResponse `json:"product"`
+	Score   float64                 `json:"score"`
+}
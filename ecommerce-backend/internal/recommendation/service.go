@@ -0,0 +1,168 @@
+// internal/recommendation/service.go
+package recommendation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+)
+
+// RecommendationService defines the interface for recommendation operations.
+type RecommendationService interface {
+	Rebuild(ctx context.Context) error
+	Start(ctx context.Context, interval time.Duration) // Runs the periodic rebuild job until ctx is cancelled
+	GetRelatedProducts(ctx context.Context, productID string, limit int) ([]RecommendedProduct, error)
+	GetUserRecommendations(ctx context.Context, userID string, limit int) ([]RecommendedProduct, error)
+}
+
+// service implements RecommendationService.
+type service struct {
+	ordersCollection  *mongo.Collection
+	ratingsCollection *mongo.Collection
+	productService    product.ProductService // Used to hydrate recommended IDs into full products
+	snapshot          atomic.Pointer[Snapshot]
+}
+
+// NewRecommendationService creates a new recommendation service.
+// The service starts with an empty snapshot; call Rebuild or Start to populate it.
+func NewRecommendationService(prodService product.ProductService) RecommendationService {
+	s := &service{
+		ordersCollection:  database.GetCollection("orders"),
+		ratingsCollection: database.GetCollection("ratings"), // Optional: may not exist
+		productService:    prodService,
+	}
+	s.snapshot.Store(buildSnapshot(nil, nil))
+	return s
+}
+
+// Rebuild reads all non-cancelled orders and any ratings, builds a fresh snapshot and swaps it in.
+// Unclaimed guest orders have no user and are left out, or they would all count as one shopper.
+func (s *service) Rebuild(ctx context.Context) error {
+	started := time.Now()
+
+	cursor, err := s.ordersCollection.Find(ctx,
+		bson.M{"status": bson.M{"$ne": "cancelled"}, "userID": bson.M{"$ne": primitive.NilObjectID}},
+		options.Find().SetProjection(bson.M{"userID": 1, "items.productID": 1, "items.quantity": 1}),
+	)
+	if err != nil {
+		log.Printf("Error reading orders for recommendations: %v", err)
+		return errors.New("failed to read order history")
+	}
+	var orders []orderDoc
+	if err = cursor.All(ctx, &orders); err != nil {
+		log.Printf("Error decoding orders for recommendations: %v", err)
+		return errors.New("failed to process order history")
+	}
+
+	cursor, err = s.ratingsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Printf("Error reading ratings for recommendations: %v", err)
+		return errors.New("failed to read ratings")
+	}
+	var ratings []ratingDoc
+	if err = cursor.All(ctx, &ratings); err != nil {
+		log.Printf("Error decoding ratings for recommendations: %v", err)
+		return errors.New("failed to process ratings")
+	}
+
+	snap := buildSnapshot(orders, ratings)
+	s.snapshot.Store(snap)
+	log.Printf("Recommendation snapshot rebuilt: %d orders, %d ratings, %d products in %s",
+		len(orders), len(ratings), len(snap.Related), time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// Start rebuilds the snapshot immediately and then on every tick of interval, in a background goroutine.
+func (s *service) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			rebuildCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+			if err := s.Rebuild(rebuildCtx); err != nil {
+				log.Printf("Recommendation rebuild failed: %v", err)
+			}
+			cancel()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// GetRelatedProducts returns products most often bought together with the given product.
+func (s *service) GetRelatedProducts(ctx context.Context, productID string, limit int) ([]RecommendedProduct, error) {
+	objID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+	p, err := s.productService.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == product.StatusDraft {
+		return nil, errors.New("product not found")
+	}
+
+	snap := s.snapshot.Load()
+	return s.hydrate(ctx, snap.Related[objID], limit), nil
+}
+
+// GetUserRecommendations returns personalised recommendations for a user, falling back to
+// the most popular products when the user has no purchase history yet.
+func (s *service) GetUserRecommendations(ctx context.Context, userID string, limit int) ([]RecommendedProduct, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+
+	snap := s.snapshot.Load()
+	// Over-fetch so that candidates which can no longer be loaded don't leave the list short.
+	candidates := recommendForUser(snap, userObjID, limit*2)
+	if len(candidates) == 0 {
+		owned := snap.UserItems[userObjID]
+		for _, p := range snap.Popular {
+			if _, ok := owned[p.ProductID]; !ok {
+				candidates = append(candidates, p)
+			}
+		}
+	}
+
+	return s.hydrate(ctx, candidates, limit), nil
+}
+
+// hydrate loads full product data for scored IDs, skipping products that no longer exist
+// or are not publicly listed.
+func (s *service) hydrate(ctx context.Context, candidates []ScoredProduct, limit int) []RecommendedProduct {
+	result := make([]RecommendedProduct, 0, limit)
+	for _, c := range candidates {
+		if len(result) == limit {
+			break
+		}
+		p, err := s.productService.GetProductByID(ctx, c.ProductID.Hex())
+		if err != nil {
+			if err.Error() != "product not found" {
+				log.Printf("Error loading recommended product %s: %v", c.ProductID.Hex(), err)
+			}
+			continue
+		}
+		if p.Status != product.StatusPublished {
+			continue
+		}
+		result = append(result, RecommendedProduct{Product: *p, Score: c.Score})
+	}
+	return result
+}
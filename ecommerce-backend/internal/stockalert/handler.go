@@ -0,0 +1,130 @@
+// internal/stockalert/handler.go
+package stockalert
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// StockAlertHandler handles HTTP requests related to stock alerts and subscriptions.
+type StockAlertHandler struct {
+	Service StockAlertService
+}
+
+// NewStockAlertHandler creates a new StockAlertHandler instance.
+func NewStockAlertHandler(s StockAlertService) *StockAlertHandler {
+	return &StockAlertHandler{Service: s}
+}
+
+// Subscribe godoc
+// @Summary Get notified when a product is back in stock
+// @Description Subscribe the authenticated user to a one-time email when an out-of-stock product is available again
+// @Tags Stock Alerts
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Already subscribed"
+// @Success 201 {object} map[string]interface{} "Subscribed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 409 {object} map[string]interface{} "Product is in stock"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/notify-me [post]
+func (h *StockAlertHandler) Subscribe(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, created, err := h.Service.Subscribe(ctx, c.Param("id"), userID.(string))
+	if err != nil {
+		switch err.Error() {
+		case "product not found", "user not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid product ID format", "invalid user ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		case "product is in stock":
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if !created {
+		utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Already subscribed", "subscription": sub})
+		return
+	}
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "You will be notified when this product is back in stock", "subscription": sub})
+}
+
+// Unsubscribe godoc
+// @Summary Cancel a back-in-stock notification
+// @Description Remove the authenticated user's pending back-in-stock subscription for a product
+// @Tags Stock Alerts
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Unsubscribed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Subscription not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /products/{id}/notify-me [delete]
+func (h *StockAlertHandler) Unsubscribe(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User ID not found in context")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Service.Unsubscribe(ctx, c.Param("id"), userID.(string)); err != nil {
+		switch err.Error() {
+		case "subscription not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid product ID format", "invalid user ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Unsubscribed successfully"})
+}
+
+// GetLowStockProducts godoc
+// @Summary List low-stock products (Admin only)
+// @Description Retrieve products whose available stock is at or below their reorder threshold
+// @Tags Stock Alerts
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of low-stock products"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/low-stock [get]
+func (h *StockAlertHandler) GetLowStockProducts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	products, err := h.Service.GetLowStockProducts(ctx)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"products": products})
+}
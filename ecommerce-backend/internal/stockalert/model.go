@@ -0,0 +1,63 @@
+// internal/stockalert/model.go
+package stockalert
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Subscription is a customer's request to be told when an out-of-stock product is back.
+// It is fulfilled once: NotifiedAt is set when the notification goes out.
+type Subscription struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID  primitive.ObjectID `bson:"productID" json:"productId"`
+	UserID     primitive.ObjectID `bson:"userID" json:"userId"`
+	Email      string             `bson:"email" json:"email"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	NotifiedAt *time.Time         `bson:"notifiedAt,omitempty" json:"notifiedAt,omitempty"`
+}
+
+// alertState remembers that a product's low-stock alert has fired, so it fires once per
+// crossing of the threshold rather than on every stock change below it.
+type alertState struct {
+	ProductID primitive.ObjectID `bson:"_id"`
+	Low       bool               `bson:"low"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+// productDoc is the subset of a product document needed to evaluate alerts.
+// It is decoded directly from the "products" collection so the product package can depend on this one.
+type productDoc struct {
+	ID               primitive.ObjectID `bson:"_id"`
+	Name             string             `bson:"name"`
+	SKU              string             `bson:"sku"`
+	Stock            int                `bson:"stock"`
+	Reserved         int                `bson:"reserved"`
+	Backordered      int                `bson:"backordered"`
+	ReorderThreshold int                `bson:"reorderThreshold"`
+	DeletedAt        *time.Time         `bson:"deletedAt"`
+	Digital          bool               `bson:"digital"` // Digital products never run out
+	Status           string             `bson:"status"`
+}
+
+// statusDraft mirrors product.StatusDraft: drafts are hidden from customers.
+const statusDraft = "draft"
+
+// available mirrors product.Product.Available.
+func (p *productDoc) available() int {
+	if available := p.Stock - p.Reserved - p.Backordered; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// LowStockProduct is a product at or below its reorder threshold, in API responses.
+type LowStockProduct struct {
+	ProductID        string `json:"productId"`
+	Name             string `json:"name"`
+	SKU              string `json:"sku"`
+	Stock            int    `json:"stock"`
+	Available        int    `json:"available"`
+	ReorderThreshold int    `json:"reorderThreshold"`
+}
@@ -0,0 +1,310 @@
+// internal/stockalert/service.go
+package stockalert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/notification"
+)
+
+// StockAlertService defines the interface for low-stock alerts and back-in-stock subscriptions.
+type StockAlertService interface {
+	// StockChanged re-evaluates alerts for products whose stock, reservations or threshold changed.
+	// It returns immediately; evaluation and notifications happen in the background.
+	// Call it only after the change is committed.
+	StockChanged(productIDs ...primitive.ObjectID)
+	Evaluate(ctx context.Context, productID primitive.ObjectID) error
+
+	Subscribe(ctx context.Context, productID, userID string) (*Subscription, bool, error) // The bool reports whether a new subscription was created
+	Unsubscribe(ctx context.Context, productID, userID string) error
+	GetLowStockProducts(ctx context.Context) ([]LowStockProduct, error) // Admin only
+}
+
+// service implements StockAlertService.
+type service struct {
+	productsCollection      *mongo.Collection
+	usersCollection         *mongo.Collection // Read-only: subscriber email addresses
+	subscriptionsCollection *mongo.Collection
+	alertsCollection        *mongo.Collection
+	notifier                notification.Notifier
+	adminEmails             []string
+}
+
+// NewStockAlertService creates a new stock alert service.
+func NewStockAlertService(cfg *config.Config, notifier notification.Notifier) StockAlertService {
+	return &service{
+		productsCollection:      database.GetCollection("products"),
+		usersCollection:         database.GetCollection("users"),
+		subscriptionsCollection: database.GetCollection("stock_subscriptions"),
+		alertsCollection:        database.GetCollection("stock_alerts"),
+		notifier:                notifier,
+		adminEmails:             cfg.AdminAlertEmails,
+	}
+}
+
+// StockChanged evaluates each product in a background goroutine.
+func (s *service) StockChanged(productIDs ...primitive.ObjectID) {
+	if len(productIDs) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		seen := make(map[primitive.ObjectID]bool, len(productIDs))
+		for _, id := range productIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if err := s.Evaluate(ctx, id); err != nil {
+				log.Printf("Stock alert evaluation failed for product %s: %v", id.Hex(), err)
+			}
+		}
+	}()
+}
+
+// Evaluate fires a low-stock alert when the product has crossed its reorder threshold and
+// notifies waiting subscribers when it has stock again.
+func (s *service) Evaluate(ctx context.Context, productID primitive.ObjectID) error {
+	var p productDoc
+	if err := s.productsCollection.FindOne(ctx, bson.M{"_id": productID}).Decode(&p); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil // Purged in the meantime
+		}
+		return fmt.Errorf("failed to load product: %w", err)
+	}
+	if p.DeletedAt != nil {
+		return nil // Archived products neither need restocking nor can be bought
+	}
+
+	available := p.available()
+	low := p.ReorderThreshold > 0 && available <= p.ReorderThreshold
+	if err := s.updateLowState(ctx, &p, low); err != nil {
+		return err
+	}
+	if available > 0 && p.Status != statusDraft {
+		return s.notifySubscribers(ctx, &p)
+	}
+	return nil
+}
+
+// updateLowState records whether the product is low on stock and alerts admins on the
+// transition into the low state. The conditional update makes concurrent evaluations alert once.
+func (s *service) updateLowState(ctx context.Context, p *productDoc, low bool) error {
+	now := time.Now()
+	if !low {
+		_, err := s.alertsCollection.UpdateOne(ctx,
+			bson.M{"_id": p.ID, "low": true},
+			bson.M{"$set": bson.M{"low": false, "updatedAt": now}},
+		)
+		return err
+	}
+
+	res, err := s.alertsCollection.UpdateOne(ctx,
+		bson.M{"_id": p.ID, "low": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"low": true, "updatedAt": now}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil // Already low: another evaluation got here first
+		}
+		return err
+	}
+	if res.ModifiedCount == 0 && res.UpsertedCount == 0 {
+		return nil
+	}
+
+	available := p.available()
+	return s.notifier.Notify(ctx, notification.Notification{
+		Event:   "stock.low",
+		To:      s.adminEmails,
+		Subject: fmt.Sprintf("Low stock: %s (%s)", p.Name, p.SKU),
+		Body: fmt.Sprintf("Only %d units of %s (SKU %s) are available to sell, at or below the reorder threshold of %d.\n",
+			available, p.Name, p.SKU, p.ReorderThreshold),
+		Data: map[string]interface{}{
+			"productId":        p.ID.Hex(),
+			"sku":              p.SKU,
+			"available":        available,
+			"stock":            p.Stock,
+			"reorderThreshold": p.ReorderThreshold,
+		},
+	})
+}
+
+// notifySubscribers tells every waiting subscriber that the product is back in stock.
+// Each subscription is claimed before sending so concurrent evaluations notify it once. If the
+// send fails the claim is released, and the next stock change while in stock tries again.
+func (s *service) notifySubscribers(ctx context.Context, p *productDoc) error {
+	cursor, err := s.subscriptionsCollection.Find(ctx, bson.M{"productID": p.ID, "notifiedAt": nil})
+	if err != nil {
+		return fmt.Errorf("failed to find subscriptions: %w", err)
+	}
+	var subscriptions []Subscription
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return fmt.Errorf("failed to decode subscriptions: %w", err)
+	}
+
+	for _, sub := range subscriptions {
+		claimedAt := time.Now()
+		res, err := s.subscriptionsCollection.UpdateOne(ctx,
+			bson.M{"_id": sub.ID, "notifiedAt": nil},
+			bson.M{"$set": bson.M{"notifiedAt": claimedAt}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to claim subscription: %w", err)
+		}
+		if res.ModifiedCount == 0 {
+			continue
+		}
+		err = s.notifier.Notify(ctx, notification.Notification{
+			Event:   "stock.back_in_stock",
+			To:      []string{sub.Email},
+			Subject: fmt.Sprintf("%s is back in stock", p.Name),
+			Body:    fmt.Sprintf("Good news: %s is available again. Order soon, stock is limited.\n", p.Name),
+			Data:    map[string]interface{}{"productId": p.ID.Hex(), "sku": p.SKU, "userId": sub.UserID.Hex()},
+		})
+		if err != nil {
+			log.Printf("Failed to send back-in-stock notification %s: %v", sub.ID.Hex(), err)
+			_, err = s.subscriptionsCollection.UpdateOne(ctx,
+				bson.M{"_id": sub.ID, "notifiedAt": claimedAt},
+				bson.M{"$unset": bson.M{"notifiedAt": ""}},
+			)
+			if err != nil {
+				log.Printf("Error releasing back-in-stock subscription %s: %v", sub.ID.Hex(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Subscribe asks to be notified when an out-of-stock product is available again.
+// Subscribing twice returns the existing subscription.
+func (s *service) Subscribe(ctx context.Context, productID, userID string) (*Subscription, bool, error) {
+	productObjID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, false, errors.New("invalid product ID format")
+	}
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, false, errors.New("invalid user ID format")
+	}
+
+	var p productDoc
+	// Drafts are reported as missing, like GET /products/:id does, so their IDs cannot be probed.
+	err = s.productsCollection.FindOne(ctx, bson.M{"_id": productObjID, "deletedAt": nil, "status": bson.M{"$ne": statusDraft}}).Decode(&p)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, false, errors.New("product not found")
+		}
+		log.Printf("Error finding product for subscription: %v", err)
+		return nil, false, errors.New("database error retrieving product")
+	}
+	if p.available() > 0 || p.Digital {
+		return nil, false, errors.New("product is in stock")
+	}
+
+	var existing Subscription
+	err = s.subscriptionsCollection.FindOne(ctx, bson.M{"productID": productObjID, "userID": userObjID, "notifiedAt": nil}).Decode(&existing)
+	if err == nil {
+		return &existing, false, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Printf("Error checking existing subscription: %v", err)
+		return nil, false, errors.New("database error checking subscription")
+	}
+
+	var user struct {
+		Email string `bson:"email"`
+	}
+	if err := s.usersCollection.FindOne(ctx, bson.M{"_id": userObjID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, false, errors.New("user not found")
+		}
+		log.Printf("Error finding subscriber: %v", err)
+		return nil, false, errors.New("database error retrieving user")
+	}
+
+	sub := &Subscription{
+		ProductID: productObjID,
+		UserID:    userObjID,
+		Email:     user.Email,
+		CreatedAt: time.Now(),
+	}
+	result, err := s.subscriptionsCollection.InsertOne(ctx, sub)
+	if err != nil {
+		log.Printf("Error inserting stock subscription: %v", err)
+		return nil, false, errors.New("failed to create subscription")
+	}
+	sub.ID = result.InsertedID.(primitive.ObjectID)
+	return sub, true, nil
+}
+
+// Unsubscribe cancels a user's pending back-in-stock subscription for a product.
+func (s *service) Unsubscribe(ctx context.Context, productID, userID string) error {
+	productObjID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return errors.New("invalid product ID format")
+	}
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID format")
+	}
+
+	res, err := s.subscriptionsCollection.DeleteMany(ctx, bson.M{"productID": productObjID, "userID": userObjID, "notifiedAt": nil})
+	if err != nil {
+		log.Printf("Error deleting stock subscription: %v", err)
+		return errors.New("failed to delete subscription")
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// GetLowStockProducts lists active products whose available stock is at or below their threshold.
+func (s *service) GetLowStockProducts(ctx context.Context) ([]LowStockProduct, error) {
+	cursor, err := s.productsCollection.Find(ctx, bson.M{
+		"deletedAt":        nil,
+		"reorderThreshold": bson.M{"$gt": 0},
+		"$expr": bson.M{"$lte": bson.A{
+			bson.M{"$subtract": bson.A{"$stock", bson.M{"$ifNull": bson.A{"$reserved", 0}}}},
+			"$reorderThreshold",
+		}},
+	}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding low-stock products: %v", err)
+		return nil, errors.New("failed to retrieve low-stock products")
+	}
+	defer cursor.Close(ctx)
+
+	var products []productDoc
+	if err = cursor.All(ctx, &products); err != nil {
+		log.Printf("Error decoding low-stock products: %v", err)
+		return nil, errors.New("failed to process product data")
+	}
+
+	result := make([]LowStockProduct, 0, len(products))
+	for _, p := range products {
+		result = append(result, LowStockProduct{
+			ProductID:        p.ID.Hex(),
+			Name:             p.Name,
+			SKU:              p.SKU,
+			Stock:            p.Stock,
+			Available:        p.available(),
+			ReorderThreshold: p.ReorderThreshold,
+		})
+	}
+	return result, nil
+}
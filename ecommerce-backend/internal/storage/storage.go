@@ -0,0 +1,109 @@
+// internal/storage/storage.go
+
+// Package storage holds uploaded files, such as the files delivered for digital products,
+// behind an interface so the backing store can be swapped (local disk, object storage, ...).
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+)
+
+// Storage saves and serves files by key. Keys are slash-separated paths such as "products/<id>/<name>".
+type Storage interface {
+	Save(ctx context.Context, key string, r io.Reader) (int64, error) // Returns the number of bytes written
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorage returns the configured storage. Only local disk (STORAGE_DIR) is built in.
+func NewStorage(cfg *config.Config) Storage {
+	return &LocalStorage{Dir: cfg.StorageDir}
+}
+
+// LocalStorage keeps files under a directory on local disk.
+type LocalStorage struct {
+	Dir string
+}
+
+// path resolves a key inside Dir, refusing keys that would escape it.
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(clean)), nil
+}
+
+// Save writes r to key, replacing any file already there. The file only becomes visible once fully written.
+func (s *LocalStorage) Save(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		log.Printf("Error creating storage directory: %v", err)
+		return 0, errors.New("failed to store file")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		log.Printf("Error creating temporary file: %v", err)
+		return 0, errors.New("failed to store file")
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Printf("Error writing file %s: %v", key, err)
+		return 0, errors.New("failed to store file")
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		log.Printf("Error moving file into place %s: %v", key, err)
+		return 0, errors.New("failed to store file")
+	}
+	return n, nil
+}
+
+// Open returns the file stored at key. The caller must close it.
+func (s *LocalStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errors.New("file not found")
+		}
+		log.Printf("Error opening file %s: %v", key, err)
+		return nil, errors.New("failed to open file")
+	}
+	return f, nil
+}
+
+// Delete removes the file at key. Deleting a missing file is not an error.
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Error deleting file %s: %v", key, err)
+		return errors.New("failed to delete file")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// internal/storecredit/handler.go
+package storecredit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// StoreCreditHandler handles HTTP requests related to store credit.
+type StoreCreditHandler struct {
+	Service   StoreCreditService
+	Validator *validator.Validate
+}
+
+// NewStoreCreditHandler creates a new StoreCreditHandler instance.
+func NewStoreCreditHandler(s StoreCreditService) *StoreCreditHandler {
+	return &StoreCreditHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// GetMyStoreCredit godoc
+// @Summary Get my store credit
+// @Description Retrieve the authenticated user's store credit balance and latest ledger entries
+// @Tags Store Credit
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Balance and ledger"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/store-credit [get]
+func (h *StoreCreditHandler) GetMyStoreCredit(c *gin.Context) {
+	h.getStatement(c, c.GetString("userID"))
+}
+
+// GetUserStoreCredit godoc
+// @Summary Get a user's store credit (Admin only)
+// @Description Retrieve a user's store credit balance and latest ledger entries
+// @Tags Store Credit
+// @Produce  json
+// @Param   id path string true "User ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Balance and ledger"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/users/{id}/store-credit [get]
+func (h *StoreCreditHandler) GetUserStoreCredit(c *gin.Context) {
+	h.getStatement(c, c.Param("id"))
+}
+
+// getStatement responds with the statement of userID.
+func (h *StoreCreditHandler) getStatement(c *gin.Context, userID string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	statement, err := h.Service.GetStatement(ctx, userID)
+	if err != nil {
+		if err.Error() == "invalid user ID format" {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"storeCredit": statement})
+}
+
+// AdjustStoreCredit godoc
+// @Summary Grant or take back store credit (Admin only)
+// @Description Add credit to a user's balance, or take it back with a negative amount. Every adjustment is recorded with its reason.
+// @Tags Store Credit
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "User ID"
+// @Param   request body AdjustRequest true "Amount in major units of the base currency and reason"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Store credit updated"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error or user ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Insufficient store credit"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/users/{id}/store-credit [post]
+func (h *StoreCreditHandler) AdjustStoreCredit(c *gin.Context) {
+	var req AdjustRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	statement, err := h.Service.Adjust(ctx, c.Param("id"), c.GetString("userID"), &req)
+	if err != nil {
+		switch {
+		case err.Error() == "user not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case strings.HasPrefix(err.Error(), "invalid"):
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		case err.Error() == "insufficient store credit":
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Store credit updated", "storeCredit": statement})
+}
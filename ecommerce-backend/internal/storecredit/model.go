@@ -0,0 +1,53 @@
+// internal/storecredit/model.go
+package storecredit
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// Ledger entry types.
+const (
+	EntryAdjustment = "adjustment" // Granted or taken back by an admin
+	EntryRedeem     = "redeem"     // Spent on an order
+	EntryRestore    = "restore"    // Given back after the order was cancelled or refunded
+)
+
+// statementLimit is how many ledger entries a statement shows.
+const statementLimit = 100
+
+// Account holds a user's store credit balance. The ledger entries explain how it got there.
+type Account struct {
+	UserID    primitive.ObjectID `bson:"_id"`
+	Balance   money.Money        `bson:"balance"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+// Entry is one change to a user's store credit.
+type Entry struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID  `bson:"userID" json:"userId"`
+	Type      string              `bson:"type" json:"type"`     // EntryAdjustment, EntryRedeem or EntryRestore
+	Amount    money.Money         `bson:"amount" json:"amount"` // Negative when credit is spent or taken back
+	Reason    string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	OrderID   *primitive.ObjectID `bson:"orderID,omitempty" json:"orderId,omitempty"`
+	CreatedBy *primitive.ObjectID `bson:"createdBy,omitempty" json:"createdBy,omitempty"` // Admin who made an adjustment
+	Reversed  bool                `bson:"reversed,omitempty" json:"reversed,omitempty"`   // Set on redemptions once restored
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// Statement is a user's balance with their latest ledger entries, newest first.
+type Statement struct {
+	UserID  string      `json:"userId"`
+	Balance money.Money `json:"balance"`
+	Entries []Entry     `json:"entries"`
+}
+
+// AdjustRequest defines the structure for granting (positive) or taking back (negative) credit.
+type AdjustRequest struct {
+	Amount float64 `json:"amount" validate:"required,ne=0"` // Major units of the base currency
+	Reason string  `json:"reason" validate:"required,max=200"`
+}
@@ -0,0 +1,220 @@
+// internal/storecredit/service.go
+package storecredit
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/money"
+)
+
+// StoreCreditService defines the interface for per-user store credit.
+type StoreCreditService interface {
+	GetStatement(ctx context.Context, userID string) (*Statement, error)
+	Adjust(ctx context.Context, userID, adminID string, req *AdjustRequest) (*Statement, error) // Admin only
+
+	// The methods below are called by the order service with its transaction's session
+	// context, so they commit or roll back together with the order.
+
+	// Redeem spends up to max of the user's credit on orderID and returns the amount spent,
+	// which is zero when the user has no credit.
+	Redeem(ctx context.Context, userID, orderID primitive.ObjectID, max money.Money) (money.Money, error)
+	// RestoreForOrder gives back the credit spent on orderID that has not been restored yet.
+	RestoreForOrder(ctx context.Context, orderID primitive.ObjectID) error
+}
+
+// service implements StoreCreditService.
+type service struct {
+	accountsCollection *mongo.Collection
+	entriesCollection  *mongo.Collection
+	usersCollection    *mongo.Collection // Read-only: checks adjusted users exist
+	baseCurrency       string
+}
+
+// NewStoreCreditService creates a new store credit service.
+func NewStoreCreditService(cfg *config.Config) StoreCreditService {
+	return &service{
+		accountsCollection: database.GetCollection("store_credit_accounts"),
+		entriesCollection:  database.GetCollection("store_credit_entries"),
+		usersCollection:    database.GetCollection("users"),
+		baseCurrency:       cfg.BaseCurrency,
+	}
+}
+
+// balance returns the user's current credit; users without an account have none.
+func (s *service) balance(ctx context.Context, userID primitive.ObjectID) (money.Money, error) {
+	var account Account
+	err := s.accountsCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&account)
+	if err == mongo.ErrNoDocuments {
+		return money.Zero(s.baseCurrency), nil
+	}
+	if err != nil {
+		log.Printf("Error finding store credit account: %v", err)
+		return money.Money{}, errors.New("database error retrieving store credit")
+	}
+	return account.Balance, nil
+}
+
+// change moves the user's balance by amount and records it in the ledger. Decreases only
+// apply when the balance covers them.
+func (s *service) change(ctx context.Context, userID primitive.ObjectID, entry Entry) error {
+	filter := bson.M{"_id": userID}
+	opts := options.Update()
+	if entry.Amount.IsNegative() {
+		filter["balance.amount"] = bson.M{"$gte": -entry.Amount.Amount}
+	} else {
+		opts.SetUpsert(true)
+	}
+	res, err := s.accountsCollection.UpdateOne(ctx, filter, bson.M{
+		"$inc":         bson.M{"balance.amount": entry.Amount.Amount},
+		"$set":         bson.M{"updatedAt": entry.CreatedAt},
+		"$setOnInsert": bson.M{"balance.currency": entry.Amount.Currency},
+	}, opts)
+	if err != nil {
+		log.Printf("Error updating store credit of user %s: %v", userID.Hex(), err)
+		return errors.New("failed to update store credit")
+	}
+	if res.MatchedCount == 0 && res.UpsertedCount == 0 {
+		return errors.New("insufficient store credit")
+	}
+
+	entry.UserID = userID
+	if _, err := s.entriesCollection.InsertOne(ctx, entry); err != nil {
+		log.Printf("Error recording store credit entry: %v", err)
+		return errors.New("failed to update store credit")
+	}
+	return nil
+}
+
+// GetStatement returns the user's balance and latest ledger entries.
+func (s *service) GetStatement(ctx context.Context, userID string) (*Statement, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	balance, err := s.balance(ctx, userObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.entriesCollection.Find(ctx, bson.M{"userID": userObjID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(statementLimit))
+	if err != nil {
+		log.Printf("Error finding store credit entries: %v", err)
+		return nil, errors.New("failed to retrieve store credit")
+	}
+	defer cursor.Close(ctx)
+
+	entries := []Entry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		log.Printf("Error decoding store credit entries: %v", err)
+		return nil, errors.New("failed to process store credit data")
+	}
+	return &Statement{UserID: userID, Balance: balance, Entries: entries}, nil
+}
+
+// Adjust grants a user credit, or takes it back when the amount is negative.
+func (s *service) Adjust(ctx context.Context, userID, adminID string, req *AdjustRequest) (*Statement, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	adminObjID, err := primitive.ObjectIDFromHex(adminID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	amount := money.FromMajor(req.Amount, s.baseCurrency)
+	if amount.IsZero() {
+		return nil, errors.New("invalid amount: must be at least one minor unit")
+	}
+
+	count, err := s.usersCollection.CountDocuments(ctx, bson.M{"_id": userObjID})
+	if err != nil {
+		log.Printf("Error finding user for store credit adjustment: %v", err)
+		return nil, errors.New("database error retrieving user")
+	}
+	if count == 0 {
+		return nil, errors.New("user not found")
+	}
+
+	err = database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		return s.change(sessionContext, userObjID, Entry{
+			Type:      EntryAdjustment,
+			Amount:    amount,
+			Reason:    req.Reason,
+			CreatedBy: &adminObjID,
+			CreatedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetStatement(ctx, userID)
+}
+
+// Redeem spends up to max of the user's credit on an order.
+func (s *service) Redeem(ctx context.Context, userID, orderID primitive.ObjectID, max money.Money) (money.Money, error) {
+	balance, err := s.balance(ctx, userID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if balance.Amount <= 0 || balance.Currency != max.Currency {
+		return money.Zero(max.Currency), nil
+	}
+
+	amount := money.Min(balance, max)
+	err = s.change(ctx, userID, Entry{
+		Type:      EntryRedeem,
+		Amount:    money.Zero(amount.Currency).Sub(amount),
+		OrderID:   &orderID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		if err.Error() == "insufficient store credit" {
+			return money.Money{}, errors.New("store credit was used at the same time, try again")
+		}
+		return money.Money{}, err
+	}
+	return amount, nil
+}
+
+// RestoreForOrder reverses the order's store credit redemptions that are still in effect.
+func (s *service) RestoreForOrder(ctx context.Context, orderID primitive.ObjectID) error {
+	cursor, err := s.entriesCollection.Find(ctx, bson.M{"orderID": orderID, "type": EntryRedeem, "reversed": bson.M{"$ne": true}})
+	if err != nil {
+		log.Printf("Error finding store credit redemptions of order %s: %v", orderID.Hex(), err)
+		return errors.New("failed to restore store credit")
+	}
+	var redemptions []Entry
+	if err := cursor.All(ctx, &redemptions); err != nil {
+		log.Printf("Error decoding store credit redemptions: %v", err)
+		return errors.New("failed to restore store credit")
+	}
+
+	for _, e := range redemptions {
+		if _, err := s.entriesCollection.UpdateOne(ctx, bson.M{"_id": e.ID}, bson.M{"$set": bson.M{"reversed": true}}); err != nil {
+			log.Printf("Error marking store credit redemption %s reversed: %v", e.ID.Hex(), err)
+			return errors.New("failed to restore store credit")
+		}
+		err := s.change(ctx, e.UserID, Entry{
+			Type:      EntryRestore,
+			Amount:    money.Zero(e.Amount.Currency).Sub(e.Amount),
+			Reason:    "order cancelled or refunded",
+			OrderID:   &orderID,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+// internal/subscription/handler.go
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// SubscriptionHandler handles HTTP requests related to subscriptions.
+type SubscriptionHandler struct {
+	Service   SubscriptionService
+	Validator *validator.Validate
+}
+
+// NewSubscriptionHandler creates a new SubscriptionHandler instance.
+func NewSubscriptionHandler(s SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// respondWithServiceError maps a subscription service error to an HTTP response.
+func respondWithServiceError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "subscription not found":
+		utils.RespondWithError(c, http.StatusNotFound, msg)
+	case strings.HasPrefix(msg, "invalid"):
+		utils.RespondWithError(c, http.StatusBadRequest, msg)
+	case strings.HasPrefix(msg, "subscription is") || strings.HasPrefix(msg, "only active subscriptions") ||
+		msg == "subscription was changed at the same time, try again":
+		utils.RespondWithError(c, http.StatusConflict, msg)
+	default:
+		utils.RespondWithError(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// bindJSON binds and validates a request body. On failure it has already written the response.
+func (h *SubscriptionHandler) bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return false
+	}
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return false
+	}
+	return true
+}
+
+// CreateSubscription godoc
+// @Summary Subscribe to products
+// @Description Order the same products every week or month. Each renewal places an order and charges the saved payment method.
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Param   request body CreateSubscriptionRequest true "Products, interval, address and payment method"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Subscription created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or a product cannot be ordered"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/subscriptions [post]
+func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
+	var req CreateSubscriptionRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, err := h.Service.CreateSubscription(ctx, c.GetString("userID"), &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Subscription created successfully", "subscription": sub})
+}
+
+// GetUserSubscriptions godoc
+// @Summary List my subscriptions
+// @Description Retrieve the authenticated user's subscriptions with their recent renewals
+// @Tags Subscriptions
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of subscriptions"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/subscriptions [get]
+func (h *SubscriptionHandler) GetUserSubscriptions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	subs, err := h.Service.GetUserSubscriptions(ctx, c.GetString("userID"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+// GetSubscription godoc
+// @Summary Get one of my subscriptions
+// @Description Retrieve a subscription of the authenticated user with its recent renewals
+// @Tags Subscriptions
+// @Produce  json
+// @Param   id path string true "Subscription ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Subscription data"
+// @Failure 400 {object} map[string]interface{} "Invalid subscription ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Subscription not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/subscriptions/{id} [get]
+func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, err := h.Service.GetSubscription(ctx, c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"subscription": sub})
+}
+
+// UpdateSubscription godoc
+// @Summary Change a subscription
+// @Description Change the products, interval, address or payment method. A new payment method on a past-due or unpaid subscription is charged on the next scheduler run.
+// @Tags Subscriptions
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Subscription ID"
+// @Param   request body UpdateSubscriptionRequest true "Fields to change"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Subscription updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or a product cannot be ordered"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Subscription not found"
+// @Failure 409 {object} map[string]interface{} "Subscription is cancelled or being renewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/subscriptions/{id} [patch]
+func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
+	var req UpdateSubscriptionRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, err := h.Service.UpdateSubscription(ctx, c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Subscription updated successfully", "subscription": sub})
+}
+
+// PauseSubscription godoc
+// @Summary Pause a subscription
+// @Description Stop renewals of an active subscription until it is resumed
+// @Tags Subscriptions
+// @Produce  json
+// @Param   id path string true "Subscription ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Subscription paused"
+// @Failure 400 {object} map[string]interface{} "Invalid subscription ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Subscription not found"
+// @Failure 409 {object} map[string]interface{} "Subscription is not active or being renewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) PauseSubscription(c *gin.Context) {
+	h.change(c, h.Service.Pause, "Subscription paused")
+}
+
+// ResumeSubscription godoc
+// @Summary Resume a subscription
+// @Description Restart a paused subscription. If a renewal was missed while paused, the next order is placed right away.
+// @Tags Subscriptions
+// @Produce  json
+// @Param   id path string true "Subscription ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Subscription resumed"
+// @Failure 400 {object} map[string]interface{} "Invalid subscription ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Subscription not found"
+// @Failure 409 {object} map[string]interface{} "Subscription is not paused"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) ResumeSubscription(c *gin.Context) {
+	h.change(c, h.Service.Resume, "Subscription resumed")
+}
+
+// SkipRenewal godoc
+// @Summary Skip the next renewal
+// @Description Skip the next order of an active subscription; later renewals stay on schedule
+// @Tags Subscriptions
+// @Produce  json
+// @Param   id path string true "Subscription ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Renewal skipped"
+// @Failure 400 {object} map[string]interface{} "Invalid subscription ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Subscription not found"
+// @Failure 409 {object} map[string]interface{} "Subscription is not active or being renewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/subscriptions/{id}/skip [post]
+func (h *SubscriptionHandler) SkipRenewal(c *gin.Context) {
+	h.change(c, h.Service.Skip, "Renewal skipped")
+}
+
+// CancelSubscription godoc
+// @Summary Cancel a subscription
+// @Description End a subscription; no further orders are placed. Orders already placed are not affected.
+// @Tags Subscriptions
+// @Produce  json
+// @Param   id path string true "Subscription ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Subscription cancelled"
+// @Failure 400 {object} map[string]interface{} "Invalid subscription ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Subscription not found"
+// @Failure 409 {object} map[string]interface{} "Subscription is already cancelled or being renewed"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
+	h.change(c, h.Service.Cancel, "Subscription cancelled")
+}
+
+// change runs a body-less state change on the caller's subscription.
+func (h *SubscriptionHandler) change(c *gin.Context, apply func(ctx context.Context, userID, id string) (*Subscription, error), message string) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, err := apply(ctx, c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": message, "subscription": sub})
+}
+
+// GetAllSubscriptions godoc
+// @Summary List all subscriptions (Admin only)
+// @Description Retrieve every subscription, e.g. ?status=past_due for those in dunning
+// @Tags Subscriptions
+// @Produce  json
+// @Param   status query string false "Filter by status (active, paused, past_due, unpaid, cancelled)"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of subscriptions"
+// @Failure 400 {object} map[string]interface{} "Invalid status"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/subscriptions [get]
+func (h *SubscriptionHandler) GetAllSubscriptions(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", StatusActive, StatusPaused, StatusPastDue, StatusUnpaid, StatusCancelled:
+	default:
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid status: "+status)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	subs, err := h.Service.GetAllSubscriptions(ctx, status)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"subscriptions": subs})
+}
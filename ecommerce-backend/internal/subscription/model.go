@@ -0,0 +1,108 @@
+// internal/subscription/model.go
+package subscription
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+)
+
+// Renewal intervals.
+const (
+	IntervalWeekly  = "weekly"
+	IntervalMonthly = "monthly"
+)
+
+// Subscription statuses.
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusPastDue   = "past_due" // A renewal payment failed and is being retried (dunning)
+	StatusUnpaid    = "unpaid"   // Every retry failed; renewals stop until the payment method is updated
+	StatusCancelled = "cancelled"
+)
+
+// Renewal results recorded in a subscription's history.
+const (
+	ResultPaid          = "paid"
+	ResultSkipped       = "skipped"        // Skipped by the customer
+	ResultOrderFailed   = "order_failed"   // The order could not be placed, e.g. out of stock; the cycle is skipped
+	ResultPaymentFailed = "payment_failed" // The order was cancelled and the payment will be retried
+)
+
+// retryDelays are the waits before each retry of a failed renewal payment. Once they are
+// used up the subscription becomes unpaid.
+var retryDelays = []time.Duration{24 * time.Hour, 3 * 24 * time.Hour, 5 * 24 * time.Hour}
+
+const (
+	maxItems     = 20 // Products per subscription
+	historyLimit = 24 // Renewals kept in a subscription's history
+)
+
+// Subscription orders the same products for a customer on a fixed interval.
+type Subscription struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID `bson:"userID" json:"userId"`
+	Items           []Item             `bson:"items" json:"items"`
+	Interval        string             `bson:"interval" json:"interval"` // IntervalWeekly or IntervalMonthly
+	ShippingAddress order.Address      `bson:"shippingAddress" json:"shippingAddress"`
+	ShippingRegion  string             `bson:"shippingRegion,omitempty" json:"shippingRegion,omitempty"`
+	PaymentMethod   string             `bson:"paymentMethod" json:"paymentMethod"` // Payment provider token charged on renewal
+	Status          string             `bson:"status" json:"status"`
+	// Renewals run at AnchorAt plus Cycle intervals, so monthly renewals keep their day of
+	// the month instead of drifting after short months.
+	AnchorAt       time.Time  `bson:"anchorAt" json:"-"`
+	Cycle          int        `bson:"cycle" json:"-"`
+	NextRunAt      time.Time  `bson:"nextRunAt" json:"nextRunAt"`
+	RetryAt        *time.Time `bson:"retryAt,omitempty" json:"retryAt,omitempty"` // Next payment retry while past due
+	FailedAttempts int        `bson:"failedAttempts" json:"failedAttempts"`       // Failed payments for the current renewal
+	History        []Renewal  `bson:"history,omitempty" json:"history"`           // Latest renewals, oldest first
+	LockedUntil    *time.Time `bson:"lockedUntil,omitempty" json:"-"`             // Set while the scheduler renews the subscription
+	PausedAt       *time.Time `bson:"pausedAt,omitempty" json:"pausedAt,omitempty"`
+	CancelledAt    *time.Time `bson:"cancelledAt,omitempty" json:"cancelledAt,omitempty"`
+	CreatedAt      time.Time  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time  `bson:"updatedAt" json:"updatedAt"`
+	Version        int64      `bson:"version" json:"version"`
+}
+
+// Item is a product and the quantity ordered on every renewal.
+type Item struct {
+	ProductID primitive.ObjectID `bson:"productID" json:"productId"`
+	Quantity  int                `bson:"quantity" json:"quantity"`
+}
+
+// Renewal records what happened to one cycle of a subscription.
+type Renewal struct {
+	At      time.Time           `bson:"at" json:"at"`
+	Result  string              `bson:"result" json:"result"` // ResultPaid, ResultSkipped, ResultOrderFailed or ResultPaymentFailed
+	OrderID *primitive.ObjectID `bson:"orderID,omitempty" json:"orderId,omitempty"`
+	Error   string              `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// CreateSubscriptionRequest defines the structure for subscribing to products.
+type CreateSubscriptionRequest struct {
+	Items           []order.OrderItemRequest `json:"items" validate:"required,min=1,max=20,dive"`
+	Interval        string                   `json:"interval" validate:"required,oneof=weekly monthly"`
+	ShippingAddress order.Address            `json:"shippingAddress" validate:"required"`
+	ShippingRegion  string                   `json:"shippingRegion,omitempty" validate:"omitempty,max=50"`
+	PaymentMethod   string                   `json:"paymentMethod" validate:"required,max=200"`
+	StartAt         *time.Time               `json:"startAt,omitempty"` // First order; defaults to now
+}
+
+// UpdateSubscriptionRequest defines the structure for changing a subscription. Omitted fields
+// are left unchanged; Items replaces the whole list.
+type UpdateSubscriptionRequest struct {
+	Items           []order.OrderItemRequest `json:"items,omitempty" validate:"omitempty,min=1,max=20,dive"`
+	Interval        *string                  `json:"interval,omitempty" validate:"omitempty,oneof=weekly monthly"`
+	ShippingAddress *order.Address           `json:"shippingAddress,omitempty"`
+	ShippingRegion  *string                  `json:"shippingRegion,omitempty" validate:"omitempty,max=50"`
+	PaymentMethod   *string                  `json:"paymentMethod,omitempty" validate:"omitempty,min=1,max=200"`
+}
+
+// userDoc is the part of a user document renewals need.
+type userDoc struct {
+	Email   string `bson:"email"`
+	Segment string `bson:"segment"`
+}
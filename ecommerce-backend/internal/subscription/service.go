@@ -0,0 +1,549 @@
+// internal/subscription/service.go
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/notification"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/payment"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+)
+
+// renewalLock is how long the scheduler holds a subscription while renewing it. A crashed
+// renewal becomes due again once the lock runs out.
+const renewalLock = 10 * time.Minute
+
+// SubscriptionService defines the interface for recurring orders.
+type SubscriptionService interface {
+	CreateSubscription(ctx context.Context, userID string, req *CreateSubscriptionRequest) (*Subscription, error)
+	GetUserSubscriptions(ctx context.Context, userID string) ([]Subscription, error)
+	GetSubscription(ctx context.Context, userID, id string) (*Subscription, error)
+	UpdateSubscription(ctx context.Context, userID, id string, req *UpdateSubscriptionRequest) (*Subscription, error)
+	Pause(ctx context.Context, userID, id string) (*Subscription, error)
+	Resume(ctx context.Context, userID, id string) (*Subscription, error)
+	Skip(ctx context.Context, userID, id string) (*Subscription, error) // Skips the next renewal
+	Cancel(ctx context.Context, userID, id string) (*Subscription, error)
+	GetAllSubscriptions(ctx context.Context, status string) ([]Subscription, error) // Admin only; status is optional
+
+	// RunDue renews every subscription that is due and returns how many it processed.
+	RunDue(ctx context.Context) (int, error)
+	StartScheduler(ctx context.Context, interval time.Duration) // Runs RunDue until ctx is cancelled
+}
+
+// service implements SubscriptionService.
+type service struct {
+	subscriptionsCollection *mongo.Collection
+	usersCollection         *mongo.Collection      // Read-only: email for dunning notices, segment for pricing
+	productService          product.ProductService // Checks subscribed products can be ordered
+	orderService            order.OrderService     // Places the order for each renewal
+	payments                payment.Provider
+	notifier                notification.Notifier
+}
+
+// NewSubscriptionService creates a new subscription service.
+func NewSubscriptionService(productService product.ProductService, orderService order.OrderService, payments payment.Provider, notifier notification.Notifier) SubscriptionService {
+	return &service{
+		subscriptionsCollection: database.GetCollection("subscriptions"),
+		usersCollection:         database.GetCollection("users"),
+		productService:          productService,
+		orderService:            orderService,
+		payments:                payments,
+		notifier:                notifier,
+	}
+}
+
+// runAt returns when the n-th renewal after anchor is due. Monthly renewals that would
+// overflow a short month (January 31 plus one month) fall on its last day instead.
+func runAt(anchor time.Time, interval string, n int) time.Time {
+	if interval == IntervalWeekly {
+		return anchor.AddDate(0, 0, 7*n)
+	}
+	t := anchor.AddDate(0, n, 0)
+	if t.Day() != anchor.Day() {
+		t = t.AddDate(0, 0, -t.Day())
+	}
+	return t
+}
+
+// advance moves the subscription to its first renewal after now.
+func (sub *Subscription) advance(now time.Time) {
+	for {
+		sub.Cycle++
+		sub.NextRunAt = runAt(sub.AnchorAt, sub.Interval, sub.Cycle)
+		if sub.NextRunAt.After(now) {
+			return
+		}
+	}
+}
+
+// parseIDs converts the owner and subscription IDs from their hex form.
+func parseIDs(userID, id string) (primitive.ObjectID, primitive.ObjectID, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid user ID format")
+	}
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid subscription ID format")
+	}
+	return userObjID, objID, nil
+}
+
+// buildItems checks every requested product can be ordered and converts the items.
+func (s *service) buildItems(ctx context.Context, reqItems []order.OrderItemRequest) ([]Item, error) {
+	items := make([]Item, 0, len(reqItems))
+	seen := make(map[primitive.ObjectID]bool, len(reqItems))
+	for _, reqItem := range reqItems {
+		p, err := s.productService.GetProductForOrder(ctx, reqItem.ProductID)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "database error") {
+				return nil, err
+			}
+			return nil, fmt.Errorf("invalid items: %s: %v", reqItem.ProductID, err)
+		}
+		if seen[p.ID] {
+			return nil, fmt.Errorf("invalid items: product %s is listed twice", reqItem.ProductID)
+		}
+		seen[p.ID] = true
+		items = append(items, Item{ProductID: p.ID, Quantity: reqItem.Quantity})
+	}
+	return items, nil
+}
+
+// CreateSubscription subscribes the user to products. The first order is placed at StartAt,
+// or on the scheduler's next run when it is not set.
+func (s *service) CreateSubscription(ctx context.Context, userID string, req *CreateSubscriptionRequest) (*Subscription, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	if len(req.Items) > maxItems {
+		return nil, fmt.Errorf("invalid items: at most %d products per subscription", maxItems)
+	}
+	items, err := s.buildItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	start := now
+	if req.StartAt != nil {
+		if req.StartAt.Before(now) {
+			return nil, errors.New("invalid startAt: must not be in the past")
+		}
+		start = *req.StartAt
+	}
+
+	sub := Subscription{
+		ID:              primitive.NewObjectID(),
+		UserID:          userObjID,
+		Items:           items,
+		Interval:        req.Interval,
+		ShippingAddress: req.ShippingAddress,
+		ShippingRegion:  req.ShippingRegion,
+		PaymentMethod:   req.PaymentMethod,
+		Status:          StatusActive,
+		AnchorAt:        start,
+		NextRunAt:       start,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Version:         1,
+	}
+	if _, err := s.subscriptionsCollection.InsertOne(ctx, &sub); err != nil {
+		log.Printf("Error inserting subscription: %v", err)
+		return nil, errors.New("failed to create subscription")
+	}
+	return &sub, nil
+}
+
+// GetUserSubscriptions returns the user's subscriptions, newest first.
+func (s *service) GetUserSubscriptions(ctx context.Context, userID string) ([]Subscription, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+	return s.find(ctx, bson.M{"userID": userObjID})
+}
+
+// GetAllSubscriptions returns every subscription, optionally only those with status.
+func (s *service) GetAllSubscriptions(ctx context.Context, status string) ([]Subscription, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	return s.find(ctx, filter)
+}
+
+// find returns the subscriptions matching filter, newest first.
+func (s *service) find(ctx context.Context, filter bson.M) ([]Subscription, error) {
+	cursor, err := s.subscriptionsCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}))
+	if err != nil {
+		log.Printf("Error finding subscriptions: %v", err)
+		return nil, errors.New("failed to retrieve subscriptions")
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := []Subscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		log.Printf("Error decoding subscriptions: %v", err)
+		return nil, errors.New("failed to process subscription data")
+	}
+	return subscriptions, nil
+}
+
+// GetSubscription returns one of the user's subscriptions.
+func (s *service) GetSubscription(ctx context.Context, userID, id string) (*Subscription, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.findOwned(ctx, userObjID, objID)
+}
+
+// findOwned loads a subscription belonging to the user.
+func (s *service) findOwned(ctx context.Context, userObjID, objID primitive.ObjectID) (*Subscription, error) {
+	var sub Subscription
+	err := s.subscriptionsCollection.FindOne(ctx, bson.M{"_id": objID, "userID": userObjID}).Decode(&sub)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("subscription not found")
+		}
+		log.Printf("Error finding subscription: %v", err)
+		return nil, errors.New("database error retrieving subscription")
+	}
+	return &sub, nil
+}
+
+// modify applies a customer's change to one of their subscriptions. change edits the loaded
+// subscription and returns the fields to set, plus a renewal to record if any. Subscriptions
+// the scheduler is renewing cannot be changed until it is done.
+func (s *service) modify(ctx context.Context, userID, id string, change func(sub *Subscription, now time.Time) (bson.M, *Renewal, error)) (*Subscription, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := s.findOwned(ctx, userObjID, objID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if sub.LockedUntil != nil && sub.LockedUntil.After(now) {
+		return nil, errors.New("subscription is being renewed, try again shortly")
+	}
+
+	set, renewal, err := change(sub, now)
+	if err != nil {
+		return nil, err
+	}
+	set["updatedAt"] = now
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if renewal != nil {
+		update["$push"] = bson.M{"history": bson.M{"$each": []Renewal{*renewal}, "$slice": -historyLimit}}
+	}
+
+	var updated Subscription
+	err = s.subscriptionsCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "userID": userObjID, "version": sub.Version, "lockedUntil": bson.M{"$not": bson.M{"$gt": now}}},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("subscription was changed at the same time, try again")
+		}
+		log.Printf("Error updating subscription: %v", err)
+		return nil, errors.New("failed to update subscription")
+	}
+	return &updated, nil
+}
+
+// UpdateSubscription changes a subscription's products, interval, address or payment method.
+// A new interval starts counting from the next renewal. A new payment method on a past-due or
+// unpaid subscription is tried on the scheduler's next run.
+func (s *service) UpdateSubscription(ctx context.Context, userID, id string, req *UpdateSubscriptionRequest) (*Subscription, error) {
+	var items []Item
+	if len(req.Items) > 0 {
+		var err error
+		if items, err = s.buildItems(ctx, req.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.modify(ctx, userID, id, func(sub *Subscription, now time.Time) (bson.M, *Renewal, error) {
+		if sub.Status == StatusCancelled {
+			return nil, nil, errors.New("subscription is cancelled")
+		}
+		set := bson.M{}
+		if items != nil {
+			set["items"] = items
+		}
+		if req.Interval != nil && *req.Interval != sub.Interval {
+			set["interval"], set["anchorAt"], set["cycle"] = *req.Interval, sub.NextRunAt, 0
+		}
+		if req.ShippingAddress != nil {
+			set["shippingAddress"] = *req.ShippingAddress
+		}
+		if req.ShippingRegion != nil {
+			set["shippingRegion"] = *req.ShippingRegion
+		}
+		if req.PaymentMethod != nil {
+			set["paymentMethod"] = *req.PaymentMethod
+			switch sub.Status {
+			case StatusPastDue:
+				set["retryAt"] = now
+			case StatusUnpaid:
+				// One more attempt: if it fails too, the subscription is unpaid again.
+				set["status"], set["retryAt"], set["failedAttempts"] = StatusPastDue, now, len(retryDelays)
+			}
+		}
+		return set, nil, nil
+	})
+}
+
+// Pause stops renewals of an active subscription until it is resumed.
+func (s *service) Pause(ctx context.Context, userID, id string) (*Subscription, error) {
+	return s.modify(ctx, userID, id, func(sub *Subscription, now time.Time) (bson.M, *Renewal, error) {
+		if sub.Status != StatusActive {
+			return nil, nil, errors.New("only active subscriptions can be paused")
+		}
+		return bson.M{"status": StatusPaused, "pausedAt": now}, nil, nil
+	})
+}
+
+// Resume restarts a paused subscription. If renewals were missed while it was paused, the
+// next one runs right away and later ones follow from there.
+func (s *service) Resume(ctx context.Context, userID, id string) (*Subscription, error) {
+	return s.modify(ctx, userID, id, func(sub *Subscription, now time.Time) (bson.M, *Renewal, error) {
+		if sub.Status != StatusPaused {
+			return nil, nil, errors.New("subscription is not paused")
+		}
+		set := bson.M{"status": StatusActive, "pausedAt": nil}
+		if sub.NextRunAt.Before(now) {
+			set["anchorAt"], set["cycle"], set["nextRunAt"] = now, 0, now
+		}
+		return set, nil, nil
+	})
+}
+
+// Skip skips the next renewal of an active subscription.
+func (s *service) Skip(ctx context.Context, userID, id string) (*Subscription, error) {
+	return s.modify(ctx, userID, id, func(sub *Subscription, now time.Time) (bson.M, *Renewal, error) {
+		if sub.Status != StatusActive {
+			return nil, nil, errors.New("only active subscriptions can skip a renewal")
+		}
+		skipped := sub.NextRunAt
+		sub.advance(maxTime(now, skipped))
+		return bson.M{"cycle": sub.Cycle, "nextRunAt": sub.NextRunAt}, &Renewal{At: skipped, Result: ResultSkipped}, nil
+	})
+}
+
+// Cancel ends a subscription. No further orders are placed.
+func (s *service) Cancel(ctx context.Context, userID, id string) (*Subscription, error) {
+	return s.modify(ctx, userID, id, func(sub *Subscription, now time.Time) (bson.M, *Renewal, error) {
+		if sub.Status == StatusCancelled {
+			return nil, nil, errors.New("subscription is already cancelled")
+		}
+		return bson.M{"status": StatusCancelled, "cancelledAt": now, "retryAt": nil}, nil, nil
+	})
+}
+
+// maxTime returns the later of a and b.
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// RunDue claims due subscriptions one at a time and renews them.
+func (s *service) RunDue(ctx context.Context) (int, error) {
+	processed := 0
+	for {
+		now := time.Now()
+		lock := now.Add(renewalLock)
+		var sub Subscription
+		err := s.subscriptionsCollection.FindOneAndUpdate(ctx,
+			bson.M{
+				"$or": []bson.M{
+					{"status": StatusActive, "nextRunAt": bson.M{"$lte": now}},
+					{"status": StatusPastDue, "retryAt": bson.M{"$lte": now}},
+				},
+				"lockedUntil": bson.M{"$not": bson.M{"$gt": now}},
+			},
+			bson.M{"$set": bson.M{"lockedUntil": lock}},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextRunAt", Value: 1}}).SetReturnDocument(options.After),
+		).Decode(&sub)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return processed, nil
+			}
+			return processed, fmt.Errorf("failed to claim due subscription: %w", err)
+		}
+
+		s.renew(ctx, &sub, now)
+		processed++
+	}
+}
+
+// renew places and pays for the order of one renewal, then schedules the next step:
+// the next renewal after a payment or a rejected order, a retry after a declined payment.
+// Errors that are nobody's fault (database, provider outage) keep the subscription locked,
+// so the renewal is tried again once the lock runs out.
+func (s *service) renew(ctx context.Context, sub *Subscription, now time.Time) {
+	var user userDoc
+	if err := s.usersCollection.FindOne(ctx, bson.M{"_id": sub.UserID}).Decode(&user); err != nil {
+		log.Printf("Error loading user of subscription %s: %v", sub.ID.Hex(), err)
+		return
+	}
+
+	items := make([]order.OrderItemRequest, 0, len(sub.Items))
+	for _, item := range sub.Items {
+		items = append(items, order.OrderItemRequest{ProductID: item.ProductID.Hex(), Quantity: item.Quantity})
+	}
+	address := sub.ShippingAddress
+	o, err := s.orderService.CreateOrder(product.WithSegment(ctx, user.Segment), sub.UserID.Hex(), &order.CreateOrderRequest{
+		Items:           items,
+		ShippingRegion:  sub.ShippingRegion,
+		ShippingAddress: &address,
+	})
+	if err != nil {
+		if !orderRejected(err) {
+			log.Printf("Error placing order for subscription %s: %v", sub.ID.Hex(), err)
+			return
+		}
+		// The customer cannot act on a stock problem, so skip this renewal rather than retry it.
+		s.notify(ctx, sub, user, "subscription.order_failed", "We couldn't place your subscription order",
+			fmt.Sprintf("We couldn't place this cycle's order for your subscription: %v.\nYour next order is scheduled as usual.\n", err))
+		sub.advance(now)
+		s.unlock(ctx, sub, bson.M{
+			"status": StatusActive, "cycle": sub.Cycle, "nextRunAt": sub.NextRunAt, "failedAttempts": 0, "retryAt": nil,
+		}, &Renewal{At: now, Result: ResultOrderFailed, Error: err.Error()})
+		return
+	}
+	orderObjID, _ := primitive.ObjectIDFromHex(o.ID)
+
+	charge, err := s.payments.Charge(ctx, payment.ChargeRequest{
+		Amount:         o.TotalAmount,
+		PaymentMethod:  sub.PaymentMethod,
+		Description:    "Subscription " + sub.ID.Hex() + ", order " + o.ID,
+		IdempotencyKey: "order-" + o.ID,
+	})
+	if err == nil {
+		if _, err = s.orderService.ConfirmPayment(ctx, o.ID, charge, nil); err != nil {
+			log.Printf("Error confirming payment of subscription order %s: %v", o.ID, err)
+			if refundErr := s.payments.Refund(ctx, charge); refundErr != nil {
+				log.Printf("Error refunding charge %s of order %s: %v", charge.ID, o.ID, refundErr)
+			}
+		}
+	}
+	if err != nil {
+		if _, cancelErr := s.orderService.CancelUnpaidOrder(ctx, o.ID, "payment failed"); cancelErr != nil {
+			log.Printf("Error cancelling unpaid subscription order %s: %v", o.ID, cancelErr)
+		}
+		if !errors.Is(err, payment.ErrDeclined) {
+			log.Printf("Payment for subscription %s failed: %v", sub.ID.Hex(), err)
+			return
+		}
+		s.paymentFailed(ctx, sub, user, now, &orderObjID, err)
+		return
+	}
+
+	sub.advance(now)
+	s.unlock(ctx, sub, bson.M{
+		"status": StatusActive, "cycle": sub.Cycle, "nextRunAt": sub.NextRunAt, "failedAttempts": 0, "retryAt": nil,
+	}, &Renewal{At: now, Result: ResultPaid, OrderID: &orderObjID})
+}
+
+// paymentFailed moves a subscription into dunning after a declined payment: it is retried
+// after each of retryDelays, then becomes unpaid. The customer is told every time.
+func (s *service) paymentFailed(ctx context.Context, sub *Subscription, user userDoc, now time.Time, orderID *primitive.ObjectID, cause error) {
+	attempts := sub.FailedAttempts + 1
+	renewal := &Renewal{At: now, Result: ResultPaymentFailed, OrderID: orderID, Error: cause.Error()}
+
+	if attempts > len(retryDelays) {
+		s.notify(ctx, sub, user, "subscription.unpaid", "Your subscription is on hold",
+			"We couldn't take payment for your subscription after several attempts, so it is on hold.\n"+
+				"Update your payment method to resume it.\n")
+		s.unlock(ctx, sub, bson.M{"status": StatusUnpaid, "failedAttempts": attempts, "retryAt": nil}, renewal)
+		return
+	}
+
+	retryAt := now.Add(retryDelays[attempts-1])
+	s.notify(ctx, sub, user, "subscription.payment_failed", "Payment for your subscription failed",
+		fmt.Sprintf("We couldn't take payment for your subscription order (%v).\n"+
+			"We'll try again on %s. You can update your payment method before then.\n", cause, retryAt.Format("January 2, 2006")))
+	s.unlock(ctx, sub, bson.M{"status": StatusPastDue, "failedAttempts": attempts, "retryAt": retryAt}, renewal)
+}
+
+// unlock releases the scheduler's lock on a subscription, applying set and recording renewal.
+func (s *service) unlock(ctx context.Context, sub *Subscription, set bson.M, renewal *Renewal) {
+	set["updatedAt"] = time.Now()
+	update := bson.M{
+		"$set":   set,
+		"$unset": bson.M{"lockedUntil": ""},
+		"$inc":   bson.M{"version": 1},
+		"$push":  bson.M{"history": bson.M{"$each": []Renewal{*renewal}, "$slice": -historyLimit}},
+	}
+	_, err := s.subscriptionsCollection.UpdateOne(ctx, bson.M{"_id": sub.ID, "lockedUntil": sub.LockedUntil}, update)
+	if err != nil {
+		log.Printf("Error saving renewal of subscription %s: %v", sub.ID.Hex(), err)
+	}
+}
+
+// notify emails the subscriber. Failures are only logged; the renewal outcome stands.
+func (s *service) notify(ctx context.Context, sub *Subscription, user userDoc, event, subject, body string) {
+	err := s.notifier.Notify(ctx, notification.Notification{
+		Event:   event,
+		To:      []string{user.Email},
+		Subject: subject,
+		Body:    body,
+		Data:    map[string]interface{}{"subscriptionId": sub.ID.Hex(), "userId": sub.UserID.Hex()},
+	})
+	if err != nil {
+		log.Printf("Failed to send %s notification for subscription %s: %v", event, sub.ID.Hex(), err)
+	}
+}
+
+// orderRejected reports whether CreateOrder refused the order itself (stock, unavailable
+// products) rather than failing internally.
+func orderRejected(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "insufficient stock") || strings.Contains(msg, "product not found") ||
+		strings.Contains(msg, "not available for download") || strings.Contains(msg, "invalid product ID format") ||
+		strings.Contains(msg, "is priced in")
+}
+
+// StartScheduler runs RunDue on every tick of interval, in a background goroutine.
+func (s *service) StartScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+			if n, err := s.RunDue(runCtx); err != nil {
+				log.Printf("Subscription run failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Subscription run renewed %d subscriptions", n)
+			}
+			cancel()
+		}
+	}()
+}
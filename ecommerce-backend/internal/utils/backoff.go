@@ -0,0 +1,17 @@
+// internal/utils/backoff.go
+package utils
+
+import "time"
+
+// Backoff is the exponential retry delay after attempts failed attempts: base after the
+// first, doubling with each further one, and never more than max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
@@ -0,0 +1,66 @@
+// internal/utils/etag.go
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FormatETag returns the strong entity tag for a document version, e.g. "3" (quotes included).
+func FormatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// FormatVariantETag returns an entity tag for a representation that depends on more than
+// the document version, e.g. prices converted at a given exchange rate.
+func FormatVariantETag(version int64, variant string) string {
+	return `"` + strconv.FormatInt(version, 10) + "-" + variant + `"`
+}
+
+// ExpectedVersion reads the If-Match header for a conditional write.
+// It returns nil when the header is absent or "*", meaning any current version is acceptable.
+// Weak tags (W/"3") never match for writes, so they are rejected like any other unusable tag.
+// A variant tag from FormatVariantETag ("3-EUR@0.92") stands for the version before its first
+// "-", so clients can send back whichever tag a read gave them.
+func ExpectedVersion(c *gin.Context) (*int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+	if strings.Contains(header, ",") {
+		return nil, errors.New("If-Match must contain a single entity tag")
+	}
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 2 {
+		return nil, errors.New("If-Match must be a strong entity tag such as \"3\"")
+	}
+	tag := strings.Trim(header, `"`)
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil {
+		return nil, errors.New("If-Match does not refer to a known version")
+	}
+	return &version, nil
+}
+
+// NotModified reports whether the If-None-Match header matches etag, using the weak
+// comparison that RFC 9110 requires for GET. When it does, the caller should reply 304.
+func NotModified(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
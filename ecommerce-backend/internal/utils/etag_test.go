@@ -0,0 +1,60 @@
+// internal/utils/etag_test.go
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExpectedVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    int64 // -1 when any version is acceptable
+		wantErr bool
+	}{
+		{"absent", "", -1, false},
+		{"any version", "*", -1, false},
+		{"plain tag", `"5"`, 5, false},
+		{"surrounding space", `  "5" `, 5, false},
+		{"converted prices", `"5-EUR@0.9215"`, 5, false},
+		{"sale and segment", `"5-segvip-sale6650a1b2c3d4e5f6a7b8c9d0"`, 5, false},
+		{"derived stock", `"12-stock3.2"`, 12, false},
+		{"as built by FormatVariantETag", FormatVariantETag(7, "stock1.0-segvip-JPY@151.37"), 7, false},
+		{"weak tag", `W/"5"`, 0, true},
+		{"unquoted", `5`, 0, true},
+		{"several tags", `"5", "6"`, 0, true},
+		{"not a version", `"abc"`, 0, true},
+		{"variant without a version", `"-EUR@0.92"`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("PUT", "/products/1", nil)
+			if tt.ifMatch != "" {
+				c.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			got, err := ExpectedVersion(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpectedVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.want == -1 {
+				if got != nil {
+					t.Errorf("ExpectedVersion() = %d, want nil", *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.want {
+				t.Errorf("ExpectedVersion() = %v, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// internal/warehouse/allocate.go
+package warehouse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// availability maps productID -> warehouseID -> units available to allocate.
+type availability map[primitive.ObjectID]map[primitive.ObjectID]int
+
+// plan assigns every line to one or more warehouses. warehouses must be sorted by priority.
+// avail is consumed as lines are allocated, so repeated products within an order are handled.
+func plan(lines []Line, warehouses []Warehouse, avail availability, strategy, region string) ([]Allocation, error) {
+	ordered := warehouses
+	if strategy == StrategyNearest && region != "" {
+		ordered = byRegion(warehouses, region)
+	}
+
+	var allocations []Allocation
+	remaining := lines
+	if strategy == StrategyFewestSplits {
+		allocations, remaining = allocateWhole(lines, ordered, avail)
+	}
+
+	for _, line := range remaining {
+		need := line.Quantity
+		for _, w := range ordered {
+			if need == 0 {
+				break
+			}
+			take := min(need, avail[line.ProductID][w.ID])
+			if take <= 0 {
+				continue
+			}
+			avail[line.ProductID][w.ID] -= take
+			need -= take
+			allocations = append(allocations, Allocation{ProductID: line.ProductID, WarehouseID: w.ID, WarehouseCode: w.Code, Quantity: take})
+		}
+		if need > 0 {
+			return nil, fmt.Errorf("insufficient stock for product %s across warehouses (short by %d)", line.ProductID.Hex(), need)
+		}
+	}
+	return merge(allocations), nil
+}
+
+// byRegion returns warehouses in region first, keeping priority order within each group.
+func byRegion(warehouses []Warehouse, region string) []Warehouse {
+	ordered := append([]Warehouse(nil), warehouses...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return strings.EqualFold(ordered[i].Region, region) && !strings.EqualFold(ordered[j].Region, region)
+	})
+	return ordered
+}
+
+// allocateWhole greedily picks the warehouse that can ship the most remaining lines in full,
+// until no warehouse can ship any remaining line whole. Lines left over are returned to be split.
+func allocateWhole(lines []Line, warehouses []Warehouse, avail availability) ([]Allocation, []Line) {
+	var allocations []Allocation
+	remaining := lines
+	for len(remaining) > 0 {
+		var best *Warehouse
+		var bestLines, bestRest []Line
+		for i := range warehouses {
+			w := &warehouses[i]
+			whole, rest := coverable(remaining, w.ID, avail)
+			if len(whole) > len(bestLines) { // Strictly greater keeps the higher-priority warehouse on ties
+				best, bestLines, bestRest = w, whole, rest
+			}
+		}
+		if best == nil {
+			break
+		}
+		for _, line := range bestLines {
+			avail[line.ProductID][best.ID] -= line.Quantity
+			allocations = append(allocations, Allocation{ProductID: line.ProductID, WarehouseID: best.ID, WarehouseCode: best.Code, Quantity: line.Quantity})
+		}
+		remaining = bestRest
+	}
+	return allocations, remaining
+}
+
+// coverable splits lines into those warehouseID can ship in full and the rest.
+func coverable(lines []Line, warehouseID primitive.ObjectID, avail availability) (whole, rest []Line) {
+	used := map[primitive.ObjectID]int{}
+	for _, line := range lines {
+		if avail[line.ProductID][warehouseID]-used[line.ProductID] >= line.Quantity {
+			used[line.ProductID] += line.Quantity
+			whole = append(whole, line)
+		} else {
+			rest = append(rest, line)
+		}
+	}
+	return whole, rest
+}
+
+// merge combines allocations of the same product from the same warehouse.
+func merge(allocations []Allocation) []Allocation {
+	type key struct{ product, warehouse primitive.ObjectID }
+	index := map[key]int{}
+	merged := make([]Allocation, 0, len(allocations))
+	for _, a := range allocations {
+		k := key{a.ProductID, a.WarehouseID}
+		if i, ok := index[k]; ok {
+			merged[i].Quantity += a.Quantity
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, a)
+	}
+	return merged
+}
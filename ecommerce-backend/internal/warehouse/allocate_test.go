@@ -0,0 +1,271 @@
+// internal/warehouse/allocate_test.go
+package warehouse
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Fixture: three warehouses in priority order, A in the US and B and C in the EU.
+var (
+	testWarehouses = []Warehouse{
+		{ID: primitive.NewObjectID(), Code: "A", Region: "us-east", Priority: 1},
+		{ID: primitive.NewObjectID(), Code: "B", Region: "eu-west", Priority: 2},
+		{ID: primitive.NewObjectID(), Code: "C", Region: "eu-west", Priority: 3},
+	}
+	testProducts = map[string]primitive.ObjectID{
+		"p1": primitive.NewObjectID(),
+		"p2": primitive.NewObjectID(),
+		"p3": primitive.NewObjectID(),
+	}
+)
+
+// stock is what each warehouse code has of each product name.
+type stock map[string]map[string]int
+
+// alloc is an Allocation by product name and warehouse code.
+type alloc struct {
+	product   string
+	warehouse string
+	quantity  int
+}
+
+func (s stock) availability() availability {
+	avail := availability{}
+	for _, id := range testProducts {
+		avail[id] = map[primitive.ObjectID]int{}
+	}
+	for _, w := range testWarehouses {
+		for product, qty := range s[w.Code] {
+			avail[testProducts[product]][w.ID] = qty
+		}
+	}
+	return avail
+}
+
+// lines builds order lines from product name and quantity pairs.
+func lines(pairs ...interface{}) []Line {
+	var ls []Line
+	for i := 0; i < len(pairs); i += 2 {
+		ls = append(ls, Line{ProductID: testProducts[pairs[i].(string)], Quantity: pairs[i+1].(int)})
+	}
+	return ls
+}
+
+// named turns allocations back into names, checking each warehouse ID matches its code.
+func named(t *testing.T, allocations []Allocation) []alloc {
+	t.Helper()
+	productNames := map[primitive.ObjectID]string{}
+	for name, id := range testProducts {
+		productNames[id] = name
+	}
+	codes := map[primitive.ObjectID]string{}
+	for _, w := range testWarehouses {
+		codes[w.ID] = w.Code
+	}
+
+	var got []alloc
+	for _, a := range allocations {
+		if codes[a.WarehouseID] != a.WarehouseCode {
+			t.Errorf("allocation from %s has warehouse code %q", codes[a.WarehouseID], a.WarehouseCode)
+		}
+		got = append(got, alloc{productNames[a.ProductID], a.WarehouseCode, a.Quantity})
+	}
+	return got
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		region   string
+		stock    stock
+		lines    []Line
+		want     []alloc
+		wantErr  bool
+	}{
+		{
+			name:     "priority ships from the first warehouse with stock",
+			strategy: StrategyPriority,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 5}},
+			lines:    lines("p1", 2),
+			want:     []alloc{{"p1", "A", 2}},
+		},
+		{
+			name:     "priority splits a line across warehouses",
+			strategy: StrategyPriority,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 4}},
+			lines:    lines("p1", 7),
+			want:     []alloc{{"p1", "A", 5}, {"p1", "B", 2}},
+		},
+		{
+			name:     "priority ships each line from its own first warehouse",
+			strategy: StrategyPriority,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 2, "p2": 2}},
+			lines:    lines("p1", 2, "p2", 2),
+			want:     []alloc{{"p1", "A", 2}, {"p2", "B", 2}},
+		},
+		{
+			name:     "repeated product lines are merged",
+			strategy: StrategyPriority,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 5}},
+			lines:    lines("p1", 3, "p1", 3),
+			want:     []alloc{{"p1", "A", 5}, {"p1", "B", 1}},
+		},
+		{
+			name:     "not enough stock across warehouses",
+			strategy: StrategyPriority,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 4}},
+			lines:    lines("p1", 10),
+			wantErr:  true,
+		},
+		{
+			name:     "nearest prefers the shipping region",
+			strategy: StrategyNearest,
+			region:   "eu-west",
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 1}, "C": {"p1": 5}},
+			lines:    lines("p1", 3),
+			want:     []alloc{{"p1", "B", 1}, {"p1", "C", 2}},
+		},
+		{
+			name:     "nearest matches the region case-insensitively",
+			strategy: StrategyNearest,
+			region:   "EU-West",
+			stock:    stock{"A": {"p1": 5}, "C": {"p1": 5}},
+			lines:    lines("p1", 3),
+			want:     []alloc{{"p1", "C", 3}},
+		},
+		{
+			name:     "nearest falls back to other regions",
+			strategy: StrategyNearest,
+			region:   "eu-west",
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 2}},
+			lines:    lines("p1", 6),
+			want:     []alloc{{"p1", "B", 2}, {"p1", "A", 4}},
+		},
+		{
+			name:     "nearest without a region is priority",
+			strategy: StrategyNearest,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 5}},
+			lines:    lines("p1", 3),
+			want:     []alloc{{"p1", "A", 3}},
+		},
+		{
+			name:     "fewest splits ships the order from one warehouse",
+			strategy: StrategyFewestSplits,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 2, "p2": 2}},
+			lines:    lines("p1", 2, "p2", 2),
+			want:     []alloc{{"p1", "B", 2}, {"p2", "B", 2}},
+		},
+		{
+			name:     "fewest splits keeps priority on ties",
+			strategy: StrategyFewestSplits,
+			stock:    stock{"A": {"p1": 5, "p2": 5}, "B": {"p1": 5, "p2": 5}},
+			lines:    lines("p1", 2, "p2", 2),
+			want:     []alloc{{"p1", "A", 2}, {"p2", "A", 2}},
+		},
+		{
+			name:     "fewest splits counts repeated products together",
+			strategy: StrategyFewestSplits,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 6}},
+			lines:    lines("p1", 3, "p1", 3),
+			want:     []alloc{{"p1", "B", 6}},
+		},
+		{
+			name:     "fewest splits splits lines no warehouse can ship whole",
+			strategy: StrategyFewestSplits,
+			stock:    stock{"A": {"p1": 5}, "B": {"p1": 4, "p2": 1}},
+			lines:    lines("p1", 6, "p2", 1),
+			want:     []alloc{{"p2", "B", 1}, {"p1", "A", 5}, {"p1", "B", 1}},
+		},
+		{
+			name:     "fewest splits with not enough stock",
+			strategy: StrategyFewestSplits,
+			stock:    stock{"A": {"p1": 5, "p2": 1}},
+			lines:    lines("p1", 1, "p2", 2),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plan(tt.lines, testWarehouses, tt.stock.availability(), tt.strategy, tt.region)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("plan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if names := named(t, got); !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("plan() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateWhole(t *testing.T) {
+	tests := []struct {
+		name      string
+		stock     stock
+		lines     []Line
+		want      []alloc
+		remaining []Line
+		left      stock // Availability afterwards, for the products given
+	}{
+		{
+			name:  "one warehouse ships everything",
+			stock: stock{"A": {"p1": 2, "p2": 3}},
+			lines: lines("p1", 2, "p2", 1),
+			want:  []alloc{{"p1", "A", 2}, {"p2", "A", 1}},
+			left:  stock{"A": {"p1": 0, "p2": 2}},
+		},
+		{
+			name:  "the warehouse shipping the most lines goes first",
+			stock: stock{"A": {"p1": 1}, "B": {"p1": 1, "p2": 1, "p3": 1}},
+			lines: lines("p1", 1, "p2", 1, "p3", 1),
+			want:  []alloc{{"p1", "B", 1}, {"p2", "B", 1}, {"p3", "B", 1}},
+			left:  stock{"A": {"p1": 1}, "B": {"p1": 0, "p2": 0, "p3": 0}},
+		},
+		{
+			name:  "lines are spread over warehouses when needed",
+			stock: stock{"A": {"p1": 1}, "B": {"p2": 1, "p3": 1}},
+			lines: lines("p1", 1, "p2", 1, "p3", 1),
+			want:  []alloc{{"p2", "B", 1}, {"p3", "B", 1}, {"p1", "A", 1}},
+		},
+		{
+			name:      "lines no warehouse can ship whole are left over",
+			stock:     stock{"A": {"p1": 1, "p2": 5}, "B": {"p1": 1}},
+			lines:     lines("p1", 2, "p2", 5),
+			want:      []alloc{{"p2", "A", 5}},
+			remaining: lines("p1", 2),
+			left:      stock{"A": {"p1": 1, "p2": 0}, "B": {"p1": 1}},
+		},
+		{
+			name:      "nothing can be shipped whole",
+			stock:     stock{"A": {"p1": 1}, "B": {"p1": 1}},
+			lines:     lines("p1", 2),
+			remaining: lines("p1", 2),
+			left:      stock{"A": {"p1": 1}, "B": {"p1": 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avail := tt.stock.availability()
+			got, remaining := allocateWhole(tt.lines, testWarehouses, avail)
+			if names := named(t, got); !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("allocateWhole() allocations = %v, want %v", names, tt.want)
+			}
+			if !reflect.DeepEqual(remaining, tt.remaining) {
+				t.Errorf("allocateWhole() remaining = %v, want %v", remaining, tt.remaining)
+			}
+			for _, w := range testWarehouses {
+				for product, want := range tt.left[w.Code] {
+					if got := avail[testProducts[product]][w.ID]; got != want {
+						t.Errorf("%s has %d of %s left, want %d", w.Code, got, product, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// internal/warehouse/handler.go
+package warehouse
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// WarehouseHandler handles HTTP requests related to warehouses and their stock.
+type WarehouseHandler struct {
+	Service   WarehouseService
+	Validator *validator.Validate
+}
+
+// NewWarehouseHandler creates a new WarehouseHandler instance.
+func NewWarehouseHandler(s WarehouseService) *WarehouseHandler {
+	return &WarehouseHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// CreateWarehouse godoc
+// @Summary Create a warehouse (Admin only)
+// @Description Add a location stock can be held and shipped from
+// @Tags Warehouses
+// @Accept  json
+// @Produce  json
+// @Param   request body WarehouseCreateRequest true "Warehouse details"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Warehouse created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 409 {object} map[string]interface{} "Warehouse with this code already exists"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/warehouses [post]
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
+	var req WarehouseCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	w, err := h.Service.CreateWarehouse(ctx, &req)
+	if err != nil {
+		if err.Error() == "warehouse with this code already exists" {
+			utils.RespondWithError(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Warehouse created successfully", "warehouse": w})
+}
+
+// GetAllWarehouses godoc
+// @Summary List warehouses (Admin only)
+// @Description Retrieve every warehouse in allocation priority order
+// @Tags Warehouses
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of warehouses"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/warehouses [get]
+func (h *WarehouseHandler) GetAllWarehouses(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	warehouses, err := h.Service.GetAllWarehouses(ctx)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"warehouses": warehouses})
+}
+
+// UpdateWarehouse godoc
+// @Summary Update a warehouse (Admin only)
+// @Description Change a warehouse's name, region or priority
+// @Tags Warehouses
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Warehouse ID"
+// @Param   request body WarehouseUpdateRequest true "Fields to update"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Warehouse updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error or ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Warehouse not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/warehouses/{id} [patch]
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
+	var req WarehouseUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	w, err := h.Service.UpdateWarehouse(ctx, c.Param("id"), &req)
+	if err != nil {
+		switch err.Error() {
+		case "warehouse not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid warehouse ID format", "no fields provided for update":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Warehouse updated successfully", "warehouse": w})
+}
+
+// GetStockLevels godoc
+// @Summary Get a product's stock per warehouse (Admin only)
+// @Description Retrieve on-hand, reserved and available stock of a product in each warehouse
+// @Tags Warehouses
+// @Produce  json
+// @Param   id path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Stock levels"
+// @Failure 400 {object} map[string]interface{} "Invalid product ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/products/{id}/stock [get]
+func (h *WarehouseHandler) GetStockLevels(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	levels, err := h.Service.GetStockLevels(ctx, c.Param("id"))
+	if err != nil {
+		switch err.Error() {
+		case "product not found":
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+		case "invalid product ID format":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"productId": c.Param("id"), "levels": levels})
+}
+
+// SetStock godoc
+// @Summary Set a product's stock in a warehouse (Admin only)
+// @Description Set on-hand stock of a product in one warehouse; the product's total is updated to match
+// @Tags Warehouses
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Warehouse ID"
+// @Param   productId path string true "Product ID"
+// @Param   request body SetStockRequest true "On-hand stock"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Stock updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, IDs, or stock below reserved units"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Warehouse or product not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/warehouses/{id}/stock/{productId} [put]
+func (h *WarehouseHandler) SetStock(c *gin.Context) {
+	var req SetStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	levels, err := h.Service.SetProductStock(ctx, c.Param("id"), c.Param("productId"), *req.Stock)
+	if err != nil {
+		if err.Error() == "warehouse not found" || err.Error() == "product not found" {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid") {
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.RespondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Stock updated successfully", "productId": c.Param("productId"), "levels": levels})
+}
@@ -0,0 +1,91 @@
+// internal/warehouse/model.go
+package warehouse
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Allocation strategies, selected with ALLOCATION_STRATEGY.
+const (
+	StrategyPriority     = "priority"      // Fill from the lowest Priority warehouse first
+	StrategyNearest      = "nearest"       // Prefer warehouses in the shipping region, then priority
+	StrategyFewestSplits = "fewest_splits" // Ship as many lines as possible from a single warehouse
+)
+
+// Warehouse is a location stock is held and shipped from.
+type Warehouse struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code      string             `bson:"code" json:"code"`         // Short unique code, e.g. "MAIN"
+	Name      string             `bson:"name" json:"name"`         // Display name
+	Region    string             `bson:"region" json:"region"`     // Matched against the order's shipping region, e.g. "eu-west"
+	Priority  int                `bson:"priority" json:"priority"` // Lower ships first
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// StockLevel is the stock of one product in one warehouse.
+// The product document keeps the sum over all warehouses in its own stock and reserved
+// fields, so listings never have to aggregate levels.
+type StockLevel struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	WarehouseID primitive.ObjectID `bson:"warehouseID" json:"warehouseId"`
+	ProductID   primitive.ObjectID `bson:"productID" json:"productId"`
+	Stock       int                `bson:"stock" json:"stock"`       // On hand in this warehouse
+	Reserved    int                `bson:"reserved" json:"reserved"` // Held by unpaid orders allocated to this warehouse
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Available returns the units in this warehouse not held by any reservation.
+func (l *StockLevel) Available() int {
+	if available := l.Stock - l.Reserved; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// Line is a quantity of a product to allocate.
+type Line struct {
+	ProductID primitive.ObjectID
+	Quantity  int
+}
+
+// Allocation assigns part (or all) of an order line to the warehouse that will ship it.
+type Allocation struct {
+	ProductID     primitive.ObjectID `bson:"productID" json:"productId"`
+	WarehouseID   primitive.ObjectID `bson:"warehouseID" json:"warehouseId"`
+	WarehouseCode string             `bson:"warehouseCode" json:"warehouseCode"` // Denormalized for fulfillment
+	Quantity      int                `bson:"quantity" json:"quantity"`
+}
+
+// WarehouseCreateRequest defines the structure for creating a warehouse.
+type WarehouseCreateRequest struct {
+	Code     string `json:"code" validate:"required,alphanum,min=2,max=20"`
+	Name     string `json:"name" validate:"required,min=3,max=100"`
+	Region   string `json:"region" validate:"required,max=50"`
+	Priority int    `json:"priority" validate:"gte=0"`
+}
+
+// WarehouseUpdateRequest defines the structure for updating a warehouse. All fields are optional.
+type WarehouseUpdateRequest struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,min=3,max=100"`
+	Region   *string `json:"region,omitempty" validate:"omitempty,max=50"`
+	Priority *int    `json:"priority,omitempty" validate:"omitempty,gte=0"`
+}
+
+// SetStockRequest defines the structure for setting a product's stock in a warehouse.
+type SetStockRequest struct {
+	Stock *int `json:"stock" validate:"required,gte=0"`
+}
+
+// StockLevelResponse defines a per-warehouse stock level in API responses.
+type StockLevelResponse struct {
+	WarehouseID   string    `json:"warehouseId"`
+	WarehouseCode string    `json:"warehouseCode"`
+	Region        string    `json:"region"`
+	Stock         int       `json:"stock"`
+	Reserved      int       `json:"reserved"`
+	Available     int       `json:"available"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,347 @@
+// internal/warehouse/service.go
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/stockalert"
+)
+
+// WarehouseService defines the interface for warehouse and per-warehouse stock operations.
+// SetStock, SetDefaultStock and Allocate read or write several documents and should be called
+// with the SessionContext of the caller's transaction.
+type WarehouseService interface {
+	CreateWarehouse(ctx context.Context, req *WarehouseCreateRequest) (*Warehouse, error)
+	GetAllWarehouses(ctx context.Context) ([]Warehouse, error)
+	UpdateWarehouse(ctx context.Context, id string, req *WarehouseUpdateRequest) (*Warehouse, error)
+
+	GetStockLevels(ctx context.Context, productID string) ([]StockLevelResponse, error)
+	SetProductStock(ctx context.Context, warehouseID, productID string, stock int) ([]StockLevelResponse, error) // Admin endpoint; runs its own transaction
+	SetStock(ctx context.Context, warehouseID, productID primitive.ObjectID, stock int) error
+	SetDefaultStock(ctx context.Context, productID primitive.ObjectID, stock int) error // Stock given on product create/update
+	DeleteStockLevels(ctx context.Context, productID primitive.ObjectID) error
+
+	Allocate(ctx context.Context, lines []Line, region string) ([]Allocation, error)
+}
+
+// service implements WarehouseService.
+type service struct {
+	warehousesCollection  *mongo.Collection
+	stockLevelsCollection *mongo.Collection
+	productsCollection    *mongo.Collection // Holds the per-product totals kept in sync with stock levels
+	stockAlerts           stockalert.StockAlertService
+	events                event.Recorder // Stock adjustments are recorded as ProductStockChanged
+	defaultCode           string
+	strategy              string
+}
+
+// NewWarehouseService creates a new warehouse service.
+func NewWarehouseService(cfg *config.Config, stockAlerts stockalert.StockAlertService, events event.Recorder) WarehouseService {
+	return &service{
+		warehousesCollection:  database.GetCollection("warehouses"),
+		stockLevelsCollection: database.GetCollection("stock_levels"),
+		productsCollection:    database.GetCollection("products"),
+		stockAlerts:           stockAlerts,
+		events:                events,
+		defaultCode:           cfg.DefaultWarehouse,
+		strategy:              cfg.AllocationStrategy,
+	}
+}
+
+// CreateWarehouse handles the creation of a new warehouse.
+func (s *service) CreateWarehouse(ctx context.Context, req *WarehouseCreateRequest) (*Warehouse, error) {
+	err := s.warehousesCollection.FindOne(ctx, bson.M{"code": req.Code}).Err()
+	if err == nil {
+		return nil, errors.New("warehouse with this code already exists")
+	}
+	if err != mongo.ErrNoDocuments {
+		log.Printf("Error checking for existing warehouse code: %v", err)
+		return nil, errors.New("database error during code check")
+	}
+
+	now := time.Now()
+	w := &Warehouse{
+		Code:      req.Code,
+		Name:      req.Name,
+		Region:    req.Region,
+		Priority:  req.Priority,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	result, err := s.warehousesCollection.InsertOne(ctx, w)
+	if err != nil {
+		log.Printf("Error inserting new warehouse: %v", err)
+		return nil, errors.New("failed to create warehouse")
+	}
+	w.ID = result.InsertedID.(primitive.ObjectID)
+	return w, nil
+}
+
+// GetAllWarehouses retrieves all warehouses in allocation priority order.
+func (s *service) GetAllWarehouses(ctx context.Context) ([]Warehouse, error) {
+	cursor, err := s.warehousesCollection.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "code", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding warehouses: %v", err)
+		return nil, errors.New("failed to retrieve warehouses")
+	}
+	defer cursor.Close(ctx)
+
+	warehouses := []Warehouse{}
+	if err = cursor.All(ctx, &warehouses); err != nil {
+		log.Printf("Error decoding warehouses: %v", err)
+		return nil, errors.New("failed to process warehouse data")
+	}
+	return warehouses, nil
+}
+
+// UpdateWarehouse updates a warehouse's name, region or priority.
+func (s *service) UpdateWarehouse(ctx context.Context, id string, req *WarehouseUpdateRequest) (*Warehouse, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid warehouse ID format")
+	}
+
+	update := bson.M{}
+	if req.Name != nil {
+		update["name"] = *req.Name
+	}
+	if req.Region != nil {
+		update["region"] = *req.Region
+	}
+	if req.Priority != nil {
+		update["priority"] = *req.Priority
+	}
+	if len(update) == 0 {
+		return nil, errors.New("no fields provided for update")
+	}
+	update["updatedAt"] = time.Now()
+
+	var w Warehouse
+	err = s.warehousesCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&w)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("warehouse not found")
+		}
+		log.Printf("Error updating warehouse: %v", err)
+		return nil, errors.New("failed to update warehouse")
+	}
+	return &w, nil
+}
+
+// GetStockLevels lists a product's stock in every warehouse that has (or had) some.
+func (s *service) GetStockLevels(ctx context.Context, productID string) ([]StockLevelResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+	if err := s.productsCollection.FindOne(ctx, bson.M{"_id": objID}).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("product not found")
+		}
+		log.Printf("Error finding product for stock levels: %v", err)
+		return nil, errors.New("database error retrieving product")
+	}
+
+	warehouses, err := s.GetAllWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.findLevels(ctx, bson.M{"productID": objID})
+	if err != nil {
+		return nil, err
+	}
+	byWarehouse := make(map[primitive.ObjectID]StockLevel, len(levels))
+	for _, l := range levels {
+		byWarehouse[l.WarehouseID] = l
+	}
+
+	// Follow warehouse priority order so the response reads like the allocation order.
+	responses := []StockLevelResponse{}
+	for _, w := range warehouses {
+		l, ok := byWarehouse[w.ID]
+		if !ok {
+			continue
+		}
+		responses = append(responses, StockLevelResponse{
+			WarehouseID:   w.ID.Hex(),
+			WarehouseCode: w.Code,
+			Region:        w.Region,
+			Stock:         l.Stock,
+			Reserved:      l.Reserved,
+			Available:     l.Available(),
+			UpdatedAt:     l.UpdatedAt,
+		})
+	}
+	return responses, nil
+}
+
+// SetProductStock sets a product's on-hand stock in one warehouse and returns its updated levels.
+func (s *service) SetProductStock(ctx context.Context, warehouseID, productID string, stock int) ([]StockLevelResponse, error) {
+	warehouseObjID, err := primitive.ObjectIDFromHex(warehouseID)
+	if err != nil {
+		return nil, errors.New("invalid warehouse ID format")
+	}
+	productObjID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+
+	err = database.RunTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+		return s.SetStock(sessionContext, warehouseObjID, productObjID, stock)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.stockAlerts.StockChanged(productObjID)
+	return s.GetStockLevels(ctx, productID)
+}
+
+// SetStock sets the on-hand stock of a product in a warehouse and applies the difference
+// to the product's total, so the two never drift apart.
+func (s *service) SetStock(ctx context.Context, warehouseID, productID primitive.ObjectID, stock int) error {
+	if err := s.warehousesCollection.FindOne(ctx, bson.M{"_id": warehouseID}).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("warehouse not found")
+		}
+		log.Printf("Error finding warehouse %s: %v", warehouseID.Hex(), err)
+		return errors.New("database error retrieving warehouse")
+	}
+	// Bundles and digital products have no stock of their own. "bundle" mirrors
+	// product.TypeBundle; duplicated here to avoid an import cycle.
+	stockless, err := s.productsCollection.CountDocuments(ctx, bson.M{"_id": productID, "$or": bson.A{
+		bson.M{"type": "bundle"},
+		bson.M{"digital": true},
+	}})
+	if err != nil {
+		log.Printf("Error checking product type for stock update: %v", err)
+		return errors.New("database error retrieving product")
+	}
+	if stockless > 0 {
+		return errors.New("invalid stock: bundles and digital products have no stock of their own")
+	}
+
+	var current StockLevel
+	err = s.stockLevelsCollection.FindOne(ctx, bson.M{"warehouseID": warehouseID, "productID": productID}).Decode(&current)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("Error finding stock level: %v", err)
+		return errors.New("database error retrieving stock level")
+	}
+	if stock < current.Reserved {
+		return fmt.Errorf("invalid stock: %d units are reserved in this warehouse", current.Reserved)
+	}
+	delta := stock - current.Stock
+
+	now := time.Now()
+	if delta != 0 {
+		res, err := s.productsCollection.UpdateOne(ctx,
+			bson.M{"_id": productID},
+			bson.M{"$inc": bson.M{"stock": delta, "version": 1}, "$set": bson.M{"updatedAt": now}},
+		)
+		if err != nil {
+			log.Printf("Error updating product stock total: %v", err)
+			return errors.New("failed to update product stock")
+		}
+		if res.MatchedCount == 0 {
+			return errors.New("product not found")
+		}
+		err = s.events.Record(ctx, event.ProductStockChanged, productID.Hex(), event.ProductStockChangedData{
+			ProductID:   productID.Hex(),
+			WarehouseID: warehouseID.Hex(),
+			Delta:       delta,
+			Reason:      "adjusted",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.stockLevelsCollection.UpdateOne(ctx,
+		bson.M{"warehouseID": warehouseID, "productID": productID},
+		bson.M{"$set": bson.M{"stock": stock, "updatedAt": now}, "$setOnInsert": bson.M{"reserved": 0}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Error updating stock level: %v", err)
+		return errors.New("failed to update stock level")
+	}
+	return nil
+}
+
+// SetDefaultStock sets a product's stock in the default warehouse (DEFAULT_WAREHOUSE).
+func (s *service) SetDefaultStock(ctx context.Context, productID primitive.ObjectID, stock int) error {
+	var w Warehouse
+	if err := s.warehousesCollection.FindOne(ctx, bson.M{"code": s.defaultCode}).Decode(&w); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("default warehouse %s not found", s.defaultCode)
+		}
+		log.Printf("Error finding default warehouse: %v", err)
+		return errors.New("database error retrieving warehouse")
+	}
+	return s.SetStock(ctx, w.ID, productID, stock)
+}
+
+// DeleteStockLevels removes every stock level of a purged product.
+func (s *service) DeleteStockLevels(ctx context.Context, productID primitive.ObjectID) error {
+	if _, err := s.stockLevelsCollection.DeleteMany(ctx, bson.M{"productID": productID}); err != nil {
+		log.Printf("Error deleting stock levels of product %s: %v", productID.Hex(), err)
+		return errors.New("failed to delete stock levels")
+	}
+	return nil
+}
+
+// Allocate decides which warehouses ship each line, using the configured strategy.
+// It only plans; the caller reserves the allocated quantities.
+func (s *service) Allocate(ctx context.Context, lines []Line, region string) ([]Allocation, error) {
+	warehouses, err := s.GetAllWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]primitive.ObjectID, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	levels, err := s.findLevels(ctx, bson.M{"productID": bson.M{"$in": productIDs}})
+	if err != nil {
+		return nil, err
+	}
+
+	avail := availability{}
+	for _, l := range levels {
+		if avail[l.ProductID] == nil {
+			avail[l.ProductID] = map[primitive.ObjectID]int{}
+		}
+		avail[l.ProductID][l.WarehouseID] = l.Available()
+	}
+	return plan(lines, warehouses, avail, s.strategy, region)
+}
+
+func (s *service) findLevels(ctx context.Context, filter bson.M) ([]StockLevel, error) {
+	cursor, err := s.stockLevelsCollection.Find(ctx, filter)
+	if err != nil {
+		log.Printf("Error finding stock levels: %v", err)
+		return nil, errors.New("failed to retrieve stock levels")
+	}
+	defer cursor.Close(ctx)
+
+	var levels []StockLevel
+	if err = cursor.All(ctx, &levels); err != nil {
+		log.Printf("Error decoding stock levels: %v", err)
+		return nil, errors.New("failed to process stock level data")
+	}
+	return levels, nil
+}
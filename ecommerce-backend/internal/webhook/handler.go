@@ -0,0 +1,317 @@
+// internal/webhook/handler.go
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils" // For standardized responses
+)
+
+// WebhookHandler handles HTTP requests related to webhook endpoints and their deliveries.
+type WebhookHandler struct {
+	Service   WebhookService
+	Validator *validator.Validate
+}
+
+// NewWebhookHandler creates a new WebhookHandler instance.
+func NewWebhookHandler(s WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// respondWithServiceError maps webhook service errors to HTTP statuses.
+func respondWithServiceError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "webhook endpoint not found" || msg == "webhook delivery not found":
+		utils.RespondWithError(c, http.StatusNotFound, msg)
+	case strings.HasPrefix(msg, "invalid"):
+		utils.RespondWithError(c, http.StatusBadRequest, msg)
+	case msg == "webhook delivery is being sent; try again shortly":
+		utils.RespondWithError(c, http.StatusConflict, msg)
+	default:
+		utils.RespondWithError(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// bindJSON binds and validates a request body. On failure it has already written the response.
+func (h *WebhookHandler) bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return false
+	}
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return false
+	}
+	return true
+}
+
+// CreateEndpoint godoc
+// @Summary Register a webhook endpoint (Admin only)
+// @Description Register a URL that receives a signed POST for every event of the given types (order.created, order.status_changed, product.stock_changed, user.registered). The response includes the signing secret; it is not shown again.
+// @Tags Webhooks
+// @Accept  json
+// @Produce  json
+// @Param   request body CreateEndpointRequest true "URL, event types and description"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Webhook endpoint created, with its secret"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, URL or event type"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhooks [post]
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	var req CreateEndpointRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	endpoint, err := h.Service.CreateEndpoint(ctx, &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Webhook endpoint created successfully", "endpoint": endpoint})
+}
+
+// GetEndpoints godoc
+// @Summary List webhook endpoints (Admin only)
+// @Description Retrieve every webhook endpoint; secrets are not included
+// @Tags Webhooks
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of webhook endpoints"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhooks [get]
+func (h *WebhookHandler) GetEndpoints(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	endpoints, err := h.Service.GetEndpoints(ctx)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"endpoints": endpoints})
+}
+
+// GetEndpoint godoc
+// @Summary Get a webhook endpoint (Admin only)
+// @Description Retrieve a webhook endpoint by ID; its secret is not included
+// @Tags Webhooks
+// @Produce  json
+// @Param   id path string true "Webhook endpoint ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Webhook endpoint data"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook endpoint ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Webhook endpoint not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhooks/{id} [get]
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	endpoint, err := h.Service.GetEndpoint(ctx, c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"endpoint": endpoint})
+}
+
+// UpdateEndpoint godoc
+// @Summary Update a webhook endpoint (Admin only)
+// @Description Change a webhook endpoint's URL, event types or description, or disable it with active: false. Deliveries to a disabled endpoint are marked dead and can be redelivered once it is enabled again.
+// @Tags Webhooks
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Webhook endpoint ID"
+// @Param   request body UpdateEndpointRequest true "Fields to change"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Webhook endpoint updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, ID, URL or event type"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Webhook endpoint not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhooks/{id} [patch]
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	var req UpdateEndpointRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	endpoint, err := h.Service.UpdateEndpoint(ctx, c.Param("id"), &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Webhook endpoint updated successfully", "endpoint": endpoint})
+}
+
+// DeleteEndpoint godoc
+// @Summary Delete a webhook endpoint (Admin only)
+// @Description Delete a webhook endpoint together with its delivery log
+// @Tags Webhooks
+// @Produce  json
+// @Param   id path string true "Webhook endpoint ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Webhook endpoint deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook endpoint ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Webhook endpoint not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Service.DeleteEndpoint(ctx, c.Param("id")); err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Webhook endpoint deleted successfully"})
+}
+
+// RotateSecret godoc
+// @Summary Rotate a webhook signing secret (Admin only)
+// @Description Replace a webhook endpoint's signing secret. Every delivery sent from now on, retries included, is signed with the new secret returned here.
+// @Tags Webhooks
+// @Produce  json
+// @Param   id path string true "Webhook endpoint ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Secret rotated; the response includes the new secret"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook endpoint ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Webhook endpoint not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhooks/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	endpoint, err := h.Service.RotateSecret(ctx, c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Webhook secret rotated", "endpoint": endpoint})
+}
+
+// GetDeliveries godoc
+// @Summary List webhook deliveries (Admin only)
+// @Description Retrieve the latest 100 deliveries to a webhook endpoint, newest first, with their attempts
+// @Tags Webhooks
+// @Produce  json
+// @Param   id path string true "Webhook endpoint ID"
+// @Param   status query string false "Filter by status (pending, succeeded, dead)"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of deliveries"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or status"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Webhook endpoint not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", DeliveryPending, DeliverySucceeded, DeliveryDead:
+	default:
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid status: "+status)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	deliveries, err := h.Service.GetDeliveries(ctx, c.Param("id"), status)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// GetDelivery godoc
+// @Summary Get a webhook delivery (Admin only)
+// @Description Retrieve a webhook delivery with its payload and attempts
+// @Tags Webhooks
+// @Produce  json
+// @Param   id path string true "Webhook delivery ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Delivery data"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook delivery ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Webhook delivery not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhook-deliveries/{id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	delivery, err := h.Service.GetDelivery(ctx, c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"delivery": delivery})
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook (Admin only)
+// @Description Queue a delivery to be sent again right away with the same payload and a fresh set of attempts, e.g. after a dead delivery's endpoint was fixed
+// @Tags Webhooks
+// @Produce  json
+// @Param   id path string true "Webhook delivery ID"
+// @Security ApiKeyAuth
+// @Success 202 {object} map[string]interface{} "Redelivery queued"
+// @Failure 400 {object} map[string]interface{} "Invalid webhook delivery ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 403 {object} map[string]interface{} "Forbidden: Insufficient permissions (requires admin)"
+// @Failure 404 {object} map[string]interface{} "Webhook delivery not found"
+// @Failure 409 {object} map[string]interface{} "Delivery is being sent right now"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/webhook-deliveries/{id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	delivery, err := h.Service.Redeliver(ctx, c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusAccepted, gin.H{"message": "Redelivery queued", "delivery": delivery})
+}
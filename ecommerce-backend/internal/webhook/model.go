@@ -0,0 +1,101 @@
+// internal/webhook/model.go
+package webhook
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "pending"   // Waiting for its first attempt or a retry
+	DeliverySucceeded = "succeeded" // The endpoint answered with a 2xx status
+	DeliveryDead      = "dead"      // Every attempt failed, or the endpoint was disabled; can be redelivered by hand
+)
+
+// Retry backoff: the first retry comes after retryBaseDelay, doubling up to retryMaxDelay.
+const (
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = 6 * time.Hour
+)
+
+// sendLock is how long the dispatcher holds a delivery it is sending.
+const sendLock = time.Minute
+
+// Limits on what is kept per delivery.
+const (
+	attemptHistoryLimit = 50  // Attempts kept in a delivery's log, newest last
+	responseBodyLimit   = 512 // Bytes of each response body kept for debugging
+	deliveryListLimit   = 100 // Deliveries returned by the delivery log, newest first
+)
+
+// Endpoint is an external URL that receives signed POSTs for the event types it subscribes to.
+type Endpoint struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	URL         string             `bson:"url" json:"url"`
+	Events      []string           `bson:"events" json:"events"` // Event types, e.g. "order.created"
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Active      bool               `bson:"active" json:"active"`
+	Secret      string             `bson:"secret" json:"-"` // HMAC-SHA256 key; only shown when created or rotated
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Delivery is one event sent (or to be sent) to one endpoint. Its payload is fixed when the
+// event arrives, so retries and redeliveries send the same body.
+type Delivery struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	EndpointID    primitive.ObjectID `bson:"endpointID" json:"endpointId"`
+	EventID       primitive.ObjectID `bson:"eventID" json:"eventId"`
+	EventType     string             `bson:"eventType" json:"eventType"`
+	Payload       string             `bson:"payload" json:"payload"` // JSON body
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"` // Since it was created or last redelivered
+	NextAttemptAt time.Time          `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	LastError     string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	History       []Attempt          `bson:"history,omitempty" json:"history,omitempty"`
+	LockedUntil   *time.Time         `bson:"lockedUntil,omitempty" json:"-"`
+	DeliveredAt   *time.Time         `bson:"deliveredAt,omitempty" json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Attempt records one POST of a delivery.
+type Attempt struct {
+	At           time.Time `bson:"at" json:"at"`
+	StatusCode   int       `bson:"statusCode,omitempty" json:"statusCode,omitempty"` // Missing when no response came back
+	ResponseBody string    `bson:"responseBody,omitempty" json:"responseBody,omitempty"`
+	Error        string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs   int64     `bson:"durationMs" json:"durationMs"`
+}
+
+// payload is the JSON body posted to endpoints.
+type payload struct {
+	ID         string      `json:"id"` // Event ID; the same across retries, for receivers to deduplicate
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// EndpointResponse defines the structure for webhook endpoint data in API responses.
+type EndpointResponse struct {
+	Endpoint
+	Secret string `json:"secret,omitempty"` // Only returned when the endpoint is created or its secret rotated
+}
+
+// CreateEndpointRequest defines the structure for registering a webhook endpoint.
+type CreateEndpointRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=2048"`
+	Events      []string `json:"events" validate:"required,min=1,dive,required"`
+	Description string   `json:"description,omitempty" validate:"max=200"`
+}
+
+// UpdateEndpointRequest defines the structure for changing a webhook endpoint. Fields left
+// out are kept.
+type UpdateEndpointRequest struct {
+	URL         *string  `json:"url,omitempty" validate:"omitempty,url,max=2048"`
+	Events      []string `json:"events,omitempty" validate:"omitempty,min=1,dive,required"`
+	Description *string  `json:"description,omitempty" validate:"omitempty,max=200"`
+	Active      *bool    `json:"active,omitempty"`
+}
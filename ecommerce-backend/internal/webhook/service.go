@@ -0,0 +1,529 @@
+// internal/webhook/service.go
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/config"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/event"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils"
+)
+
+// WebhookService defines the interface for outbound webhook operations. Endpoints are
+// managed by admins.
+type WebhookService interface {
+	CreateEndpoint(ctx context.Context, req *CreateEndpointRequest) (*EndpointResponse, error)
+	GetEndpoints(ctx context.Context) ([]Endpoint, error)
+	GetEndpoint(ctx context.Context, id string) (*Endpoint, error)
+	UpdateEndpoint(ctx context.Context, id string, req *UpdateEndpointRequest) (*Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id string) error // Its delivery log goes with it
+	RotateSecret(ctx context.Context, id string) (*EndpointResponse, error)
+
+	// Delivery log
+	GetDeliveries(ctx context.Context, endpointID, status string) ([]Delivery, error)
+	GetDelivery(ctx context.Context, id string) (*Delivery, error)
+	Redeliver(ctx context.Context, id string) (*Delivery, error) // Queues the same payload again
+
+	// HandleEvent queues an event for every active endpoint subscribed to its type. It is
+	// registered with the event bus.
+	HandleEvent(ctx context.Context, e *event.Event) error
+	// DispatchDue sends due deliveries one at a time; StartDispatcher runs it every interval.
+	DispatchDue(ctx context.Context) (int, error)
+	StartDispatcher(ctx context.Context, interval time.Duration)
+}
+
+// service implements WebhookService.
+type service struct {
+	endpointsCollection  *mongo.Collection
+	deliveriesCollection *mongo.Collection
+	client               *http.Client
+	maxAttempts          int
+}
+
+// NewWebhookService creates a new webhook service.
+func NewWebhookService(cfg *config.Config) WebhookService {
+	return &service{
+		endpointsCollection:  database.GetCollection("webhook_endpoints"),
+		deliveriesCollection: database.GetCollection("webhook_deliveries"),
+		client:               &http.Client{Timeout: cfg.WebhookTimeout},
+		maxAttempts:          cfg.WebhookMaxAttempts,
+	}
+}
+
+// Sign returns the X-Webhook-Signature header for a body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>" keyed with the secret>".
+// Receivers recompute it and should reject timestamps too far in the past.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// newSecret returns a random signing secret.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// validateURL accepts absolute http and https URLs.
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid URL: must be an http or https URL")
+	}
+	return nil
+}
+
+// normalizeEvents checks the event types and drops duplicates.
+func normalizeEvents(types []string) ([]string, error) {
+	known := make(map[string]bool, len(event.Types))
+	for _, t := range event.Types {
+		known[t] = true
+	}
+	seen := make(map[string]bool, len(types))
+	events := make([]string, 0, len(types))
+	for _, t := range types {
+		if !known[t] {
+			return nil, fmt.Errorf("invalid event type %q", t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			events = append(events, t)
+		}
+	}
+	return events, nil
+}
+
+// CreateEndpoint registers an active endpoint with a new signing secret.
+func (s *service) CreateEndpoint(ctx context.Context, req *CreateEndpointRequest) (*EndpointResponse, error) {
+	if err := validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := normalizeEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		log.Printf("Error generating webhook secret: %v", err)
+		return nil, errors.New("failed to create webhook endpoint")
+	}
+
+	now := time.Now()
+	ep := Endpoint{
+		URL:         req.URL,
+		Events:      events,
+		Description: req.Description,
+		Active:      true,
+		Secret:      secret,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	result, err := s.endpointsCollection.InsertOne(ctx, ep)
+	if err != nil {
+		log.Printf("Error inserting webhook endpoint: %v", err)
+		return nil, errors.New("failed to create webhook endpoint")
+	}
+	ep.ID = result.InsertedID.(primitive.ObjectID)
+	return &EndpointResponse{Endpoint: ep, Secret: secret}, nil
+}
+
+// GetEndpoints lists every endpoint, oldest first.
+func (s *service) GetEndpoints(ctx context.Context) ([]Endpoint, error) {
+	cursor, err := s.endpointsCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding webhook endpoints: %v", err)
+		return nil, errors.New("failed to retrieve webhook endpoints")
+	}
+	defer cursor.Close(ctx)
+
+	endpoints := []Endpoint{}
+	if err = cursor.All(ctx, &endpoints); err != nil {
+		log.Printf("Error decoding webhook endpoints: %v", err)
+		return nil, errors.New("failed to process webhook endpoint data")
+	}
+	return endpoints, nil
+}
+
+// GetEndpoint retrieves an endpoint by ID.
+func (s *service) GetEndpoint(ctx context.Context, id string) (*Endpoint, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid webhook endpoint ID format")
+	}
+	var ep Endpoint
+	if err := s.endpointsCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&ep); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("webhook endpoint not found")
+		}
+		log.Printf("Error finding webhook endpoint: %v", err)
+		return nil, errors.New("database error retrieving webhook endpoint")
+	}
+	return &ep, nil
+}
+
+// UpdateEndpoint changes an endpoint's URL, event types, description or active flag.
+// Deliveries already queued are sent to the new URL.
+func (s *service) UpdateEndpoint(ctx context.Context, id string, req *UpdateEndpointRequest) (*Endpoint, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid webhook endpoint ID format")
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	if req.URL != nil {
+		if err := validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+		set["url"] = *req.URL
+	}
+	if req.Events != nil {
+		events, err := normalizeEvents(req.Events)
+		if err != nil {
+			return nil, err
+		}
+		set["events"] = events
+	}
+	if req.Description != nil {
+		set["description"] = *req.Description
+	}
+	if req.Active != nil {
+		set["active"] = *req.Active
+	}
+
+	var ep Endpoint
+	err = s.endpointsCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&ep)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("webhook endpoint not found")
+		}
+		log.Printf("Error updating webhook endpoint: %v", err)
+		return nil, errors.New("failed to update webhook endpoint")
+	}
+	return &ep, nil
+}
+
+// DeleteEndpoint removes an endpoint and its delivery log.
+func (s *service) DeleteEndpoint(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid webhook endpoint ID format")
+	}
+	res, err := s.endpointsCollection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		log.Printf("Error deleting webhook endpoint: %v", err)
+		return errors.New("failed to delete webhook endpoint")
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("webhook endpoint not found")
+	}
+	if _, err := s.deliveriesCollection.DeleteMany(ctx, bson.M{"endpointID": objID}); err != nil {
+		// Leftover deliveries go dead when they find the endpoint gone.
+		log.Printf("Error deleting deliveries of webhook endpoint %s: %v", id, err)
+	}
+	return nil
+}
+
+// RotateSecret replaces an endpoint's signing secret. Deliveries sent from now on, including
+// retries, are signed with the new one.
+func (s *service) RotateSecret(ctx context.Context, id string) (*EndpointResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid webhook endpoint ID format")
+	}
+	secret, err := newSecret()
+	if err != nil {
+		log.Printf("Error generating webhook secret: %v", err)
+		return nil, errors.New("failed to rotate webhook secret")
+	}
+
+	var ep Endpoint
+	err = s.endpointsCollection.FindOneAndUpdate(ctx, bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"secret": secret, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&ep)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("webhook endpoint not found")
+		}
+		log.Printf("Error rotating webhook secret: %v", err)
+		return nil, errors.New("failed to rotate webhook secret")
+	}
+	return &EndpointResponse{Endpoint: ep, Secret: secret}, nil
+}
+
+// GetDeliveries lists an endpoint's latest deliveries, newest first, optionally by status.
+func (s *service) GetDeliveries(ctx context.Context, endpointID, status string) ([]Delivery, error) {
+	ep, err := s.GetEndpoint(ctx, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"endpointID": ep.ID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := s.deliveriesCollection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(deliveryListLimit))
+	if err != nil {
+		log.Printf("Error finding webhook deliveries: %v", err)
+		return nil, errors.New("failed to retrieve webhook deliveries")
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []Delivery{}
+	if err = cursor.All(ctx, &deliveries); err != nil {
+		log.Printf("Error decoding webhook deliveries: %v", err)
+		return nil, errors.New("failed to process webhook delivery data")
+	}
+	return deliveries, nil
+}
+
+// GetDelivery retrieves a delivery with its attempts.
+func (s *service) GetDelivery(ctx context.Context, id string) (*Delivery, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid webhook delivery ID format")
+	}
+	var d Delivery
+	if err := s.deliveriesCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&d); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("webhook delivery not found")
+		}
+		log.Printf("Error finding webhook delivery: %v", err)
+		return nil, errors.New("database error retrieving webhook delivery")
+	}
+	return &d, nil
+}
+
+// Redeliver queues a delivery for sending again right away, whatever its status, with a
+// fresh set of attempts. Its earlier attempts stay in the log.
+func (s *service) Redeliver(ctx context.Context, id string) (*Delivery, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid webhook delivery ID format")
+	}
+
+	now := time.Now()
+	var d Delivery
+	err = s.deliveriesCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": objID, "lockedUntil": bson.M{"$not": bson.M{"$gt": now}}},
+		bson.M{"$set": bson.M{"status": DeliveryPending, "attempts": 0, "nextAttemptAt": now, "updatedAt": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&d)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			if _, findErr := s.GetDelivery(ctx, id); findErr != nil {
+				return nil, findErr
+			}
+			return nil, errors.New("webhook delivery is being sent; try again shortly")
+		}
+		log.Printf("Error queueing webhook redelivery: %v", err)
+		return nil, errors.New("failed to queue webhook redelivery")
+	}
+	return &d, nil
+}
+
+// HandleEvent queues a delivery of the event to every active endpoint subscribed to its type.
+// An event handed over twice is queued once per endpoint.
+func (s *service) HandleEvent(ctx context.Context, e *event.Event) error {
+	cursor, err := s.endpointsCollection.Find(ctx, bson.M{"active": true, "events": e.Type})
+	if err != nil {
+		return fmt.Errorf("failed to find webhook endpoints: %w", err)
+	}
+	var endpoints []Endpoint
+	if err = cursor.All(ctx, &endpoints); err != nil {
+		return fmt.Errorf("failed to decode webhook endpoints: %w", err)
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	data, err := e.DecodeData()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(payload{ID: e.ID.Hex(), Type: e.Type, OccurredAt: e.OccurredAt, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	now := time.Now()
+	for _, ep := range endpoints {
+		_, err := s.deliveriesCollection.InsertOne(ctx, Delivery{
+			EndpointID:    ep.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       string(body),
+			Status:        DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("failed to queue webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// DispatchDue claims due deliveries one at a time, oldest first, and sends them.
+func (s *service) DispatchDue(ctx context.Context) (int, error) {
+	processed := 0
+	for {
+		now := time.Now()
+		var d Delivery
+		err := s.deliveriesCollection.FindOneAndUpdate(ctx,
+			bson.M{
+				"status":        DeliveryPending,
+				"nextAttemptAt": bson.M{"$lte": now},
+				"lockedUntil":   bson.M{"$not": bson.M{"$gt": now}},
+			},
+			bson.M{"$set": bson.M{"lockedUntil": now.Add(sendLock)}},
+			options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).SetReturnDocument(options.After),
+		).Decode(&d)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return processed, nil
+			}
+			return processed, fmt.Errorf("failed to claim due webhook delivery: %w", err)
+		}
+
+		s.send(ctx, &d)
+		processed++
+	}
+}
+
+// send posts a claimed delivery to its endpoint and records the outcome: succeeded on a 2xx
+// response, a retry with exponential backoff otherwise, and dead after the last attempt.
+func (s *service) send(ctx context.Context, d *Delivery) {
+	var ep Endpoint
+	if err := s.endpointsCollection.FindOne(ctx, bson.M{"_id": d.EndpointID}).Decode(&ep); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Error loading endpoint of webhook delivery %s: %v", d.ID.Hex(), err)
+			return // The lock runs out and the delivery is tried again
+		}
+		s.finish(ctx, d, bson.M{"status": DeliveryDead, "lastError": "endpoint was deleted"}, nil)
+		return
+	}
+	if !ep.Active {
+		s.finish(ctx, d, bson.M{"status": DeliveryDead, "lastError": "endpoint is disabled"}, nil)
+		return
+	}
+
+	attempt := s.post(ctx, &ep, d)
+	attempts := d.Attempts + 1
+	set := bson.M{"attempts": attempts}
+	switch {
+	case attempt.Error == "":
+		set["status"] = DeliverySucceeded
+		set["deliveredAt"] = attempt.At
+	case attempts >= s.maxAttempts:
+		set["status"] = DeliveryDead
+		set["lastError"] = attempt.Error
+		log.Printf("Webhook delivery %s to %s is dead after %d attempts: %s", d.ID.Hex(), ep.URL, attempts, attempt.Error)
+	default:
+		set["lastError"] = attempt.Error
+		set["nextAttemptAt"] = time.Now().Add(retryDelay(attempts))
+	}
+	s.finish(ctx, d, set, attempt)
+}
+
+// post sends one attempt of a delivery, signed with the endpoint's current secret.
+func (s *service) post(ctx context.Context, ep *Endpoint, d *Delivery) *Attempt {
+	started := time.Now()
+	attempt := &Attempt{At: started}
+	defer func() { attempt.DurationMs = time.Since(started).Milliseconds() }()
+
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("failed to build request: %v", err)
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ecommerce-backend-webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", d.ID.Hex())
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(started.Unix(), 10))
+	req.Header.Set("X-Webhook-Signature", Sign(ep.Secret, started, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = fmt.Sprintf("request failed: %v", err)
+		return attempt
+	}
+	defer resp.Body.Close()
+	attempt.StatusCode = resp.StatusCode
+	if snippet, err := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit)); err == nil {
+		attempt.ResponseBody = string(snippet)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// finish releases the dispatcher's lock on a delivery, applying set and logging attempt.
+func (s *service) finish(ctx context.Context, d *Delivery, set bson.M, attempt *Attempt) {
+	set["updatedAt"] = time.Now()
+	update := bson.M{"$set": set, "$unset": bson.M{"lockedUntil": ""}}
+	if attempt != nil {
+		update["$push"] = bson.M{"history": bson.M{"$each": []Attempt{*attempt}, "$slice": -attemptHistoryLimit}}
+	}
+	_, err := s.deliveriesCollection.UpdateOne(ctx, bson.M{"_id": d.ID, "lockedUntil": d.LockedUntil}, update)
+	if err != nil {
+		log.Printf("Error saving webhook delivery %s: %v", d.ID.Hex(), err)
+	}
+}
+
+// retryDelay is how long to wait before the next attempt after attempts failed ones.
+func retryDelay(attempts int) time.Duration {
+	return utils.Backoff(attempts, retryBaseDelay, retryMaxDelay)
+}
+
+// StartDispatcher sends due deliveries in the background until ctx is cancelled.
+func (s *service) StartDispatcher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+			if _, err := s.DispatchDue(runCtx); err != nil {
+				log.Printf("Webhook dispatch failed: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
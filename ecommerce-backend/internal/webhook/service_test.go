@@ -0,0 +1,348 @@
+// internal/webhook/service_test.go
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const testSecret = "whsec_test"
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"evt_1"}`)
+
+	tests := []struct {
+		name   string
+		secret string
+		at     time.Time
+		body   []byte
+		want   string
+	}{
+		{"known value", testSecret, at, body, "t=1700000000,v1=c89214b5b5da833daed6f0b8c5bb6bd58cea9022bd80ccc78230f3942d632925"},
+		{"sub-second timestamp is truncated", testSecret, at.Add(900 * time.Millisecond), body, "t=1700000000,v1=c89214b5b5da833daed6f0b8c5bb6bd58cea9022bd80ccc78230f3942d632925"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.at, tt.body); got != tt.want {
+				t.Errorf("Sign() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	base := Sign(testSecret, at, body)
+	changed := map[string]string{
+		"secret":    Sign("whsec_other", at, body),
+		"timestamp": Sign(testSecret, at.Add(time.Second), body),
+		"body":      Sign(testSecret, at, []byte(`{"id":"evt_2"}`)),
+	}
+	for what, sig := range changed {
+		if sig == base {
+			t.Errorf("signature did not change with the %s", what)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, retryBaseDelay},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, retryMaxDelay},
+		{100, retryMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// receiver is an endpoint that checks each request's signature the way a subscriber would,
+// from X-Webhook-Timestamp and the raw body, and answers with a fixed status.
+type receiver struct {
+	status int
+
+	mu       sync.Mutex
+	requests int
+	errors   []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests++
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		rc.errors = append(rc.errors, "reading body: "+err.Error())
+	}
+	ts := r.Header.Get("X-Webhook-Timestamp")
+	if _, err := strconv.ParseInt(ts, 10, 64); err != nil {
+		rc.errors = append(rc.errors, "bad X-Webhook-Timestamp "+strconv.Quote(ts))
+	}
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	want := "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+	if got := r.Header.Get("X-Webhook-Signature"); !hmac.Equal([]byte(got), []byte(want)) {
+		rc.errors = append(rc.errors, "signature "+got+" does not match "+want)
+	}
+	if got := r.Header.Get("X-Webhook-Event"); got != "order.created" {
+		rc.errors = append(rc.errors, "X-Webhook-Event = "+got)
+	}
+	if got := r.Header.Get("Content-Type"); got != "application/json" {
+		rc.errors = append(rc.errors, "Content-Type = "+got)
+	}
+
+	w.WriteHeader(rc.status)
+	io.WriteString(w, "ok")
+}
+
+// toDoc converts v to a document for mock server replies.
+func toDoc(t testing.TB, v interface{}) bson.D {
+	t.Helper()
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return doc
+}
+
+// sentUpdate returns the filter and update of the single update command the client sent.
+func sentUpdate(mt *mtest.T) (filter, update bson.M) {
+	mt.Helper()
+	for _, ev := range mt.GetAllStartedEvents() {
+		if ev.CommandName != "update" {
+			continue
+		}
+		var cmd struct {
+			Updates []struct {
+				Q bson.M `bson:"q"`
+				U bson.M `bson:"u"`
+			} `bson:"updates"`
+		}
+		if err := bson.Unmarshal(ev.Command, &cmd); err != nil || len(cmd.Updates) != 1 {
+			mt.Fatalf("unexpected update command %s: %v", ev.Command, err)
+		}
+		return cmd.Updates[0].Q, cmd.Updates[0].U
+	}
+	mt.Fatal("no update command was sent")
+	return nil, nil
+}
+
+func TestSend(t *testing.T) {
+	const maxAttempts = 5
+
+	tests := []struct {
+		name          string
+		status        int
+		priorAttempts int
+		wantStatus    string // Empty when the delivery stays pending
+		wantError     string
+	}{
+		{"2xx succeeds", http.StatusNoContent, 0, DeliverySucceeded, ""},
+		{"5xx schedules a retry", http.StatusServiceUnavailable, 2, "", "endpoint responded with status 503"},
+		{"4xx schedules a retry", http.StatusNotFound, 0, "", "endpoint responded with status 404"},
+		{"last attempt goes dead", http.StatusInternalServerError, maxAttempts - 1, DeliveryDead, "endpoint responded with status 500"},
+	}
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			rc := &receiver{status: tt.status}
+			srv := httptest.NewServer(rc)
+			defer srv.Close()
+
+			s := &service{
+				endpointsCollection:  mt.DB.Collection("webhook_endpoints"),
+				deliveriesCollection: mt.DB.Collection("webhook_deliveries"),
+				client:               srv.Client(),
+				maxAttempts:          maxAttempts,
+			}
+			ep := Endpoint{ID: primitive.NewObjectID(), URL: srv.URL, Events: []string{"order.created"}, Active: true, Secret: testSecret}
+			lockedUntil := time.Now().Add(sendLock).Truncate(time.Millisecond)
+			d := &Delivery{
+				ID:          primitive.NewObjectID(),
+				EndpointID:  ep.ID,
+				EventType:   "order.created",
+				Payload:     `{"id":"evt_1","type":"order.created"}`,
+				Status:      DeliveryPending,
+				Attempts:    tt.priorAttempts,
+				LockedUntil: &lockedUntil,
+			}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "test.webhook_endpoints", mtest.FirstBatch, toDoc(mt, ep)),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			)
+
+			before := time.Now()
+			s.send(context.Background(), d)
+			after := time.Now()
+
+			if rc.requests != 1 {
+				mt.Fatalf("receiver got %d requests, want 1", rc.requests)
+			}
+			for _, e := range rc.errors {
+				mt.Error(e)
+			}
+
+			filter, update := sentUpdate(mt)
+			if got, ok := filter["lockedUntil"].(primitive.DateTime); !ok || !got.Time().Equal(lockedUntil) {
+				mt.Errorf("update is not guarded by the dispatcher's lock: %v", filter)
+			}
+			if _, ok := update["$unset"].(bson.M)["lockedUntil"]; !ok {
+				mt.Errorf("lock is not released: %v", update)
+			}
+			if _, ok := update["$push"].(bson.M)["history"]; !ok {
+				mt.Errorf("attempt is not logged: %v", update)
+			}
+
+			set := update["$set"].(bson.M)
+			if got := set["attempts"]; got != int32(tt.priorAttempts+1) {
+				mt.Errorf("attempts = %v, want %d", got, tt.priorAttempts+1)
+			}
+			if got, _ := set["status"].(string); got != tt.wantStatus {
+				mt.Errorf("status = %q, want %q", got, tt.wantStatus)
+			}
+			if got, _ := set["lastError"].(string); got != tt.wantError {
+				mt.Errorf("lastError = %q, want %q", got, tt.wantError)
+			}
+
+			next, scheduled := set["nextAttemptAt"].(primitive.DateTime)
+			if tt.wantStatus != "" {
+				if scheduled {
+					mt.Errorf("nextAttemptAt set on a %s delivery", tt.wantStatus)
+				}
+				return
+			}
+			delay := retryDelay(tt.priorAttempts + 1)
+			earliest := before.Add(delay).Truncate(time.Millisecond)
+			if !scheduled || next.Time().Before(earliest) || next.Time().After(after.Add(delay)) {
+				mt.Errorf("nextAttemptAt = %v, want about now + %s", next.Time(), delay)
+			}
+		})
+	}
+}
+
+func TestSendInactiveEndpoint(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("goes dead without a request", func(mt *mtest.T) {
+		rc := &receiver{status: http.StatusOK}
+		srv := httptest.NewServer(rc)
+		defer srv.Close()
+
+		s := &service{
+			endpointsCollection:  mt.DB.Collection("webhook_endpoints"),
+			deliveriesCollection: mt.DB.Collection("webhook_deliveries"),
+			client:               srv.Client(),
+			maxAttempts:          5,
+		}
+		ep := Endpoint{ID: primitive.NewObjectID(), URL: srv.URL, Active: false, Secret: testSecret}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.webhook_endpoints", mtest.FirstBatch, toDoc(mt, ep)),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+		)
+
+		s.send(context.Background(), &Delivery{ID: primitive.NewObjectID(), EndpointID: ep.ID, EventType: "order.created"})
+
+		if rc.requests != 0 {
+			mt.Errorf("receiver got %d requests, want none", rc.requests)
+		}
+		_, update := sentUpdate(mt)
+		if got := update["$set"].(bson.M)["status"]; got != DeliveryDead {
+			mt.Errorf("status = %v, want %q", got, DeliveryDead)
+		}
+	})
+}
+
+func TestRedeliver(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("resets attempts and status", func(mt *mtest.T) {
+		s := &service{deliveriesCollection: mt.DB.Collection("webhook_deliveries")}
+		id := primitive.NewObjectID()
+		queued := Delivery{ID: id, Status: DeliveryPending, Attempts: 0, NextAttemptAt: time.Now()}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: toDoc(mt, queued)}))
+
+		d, err := s.Redeliver(context.Background(), id.Hex())
+		if err != nil {
+			mt.Fatalf("Redeliver() error = %v", err)
+		}
+		if d.ID != id || d.Status != DeliveryPending || d.Attempts != 0 {
+			mt.Errorf("Redeliver() = %+v, want pending with no attempts", d)
+		}
+
+		var cmd struct {
+			Query  bson.M `bson:"query"`
+			Update bson.M `bson:"update"`
+		}
+		ev := mt.GetStartedEvent()
+		if ev == nil || ev.CommandName != "findAndModify" {
+			mt.Fatalf("unexpected command %v", ev)
+		}
+		if err := bson.Unmarshal(ev.Command, &cmd); err != nil {
+			mt.Fatal(err)
+		}
+		if _, ok := cmd.Query["lockedUntil"]; !ok {
+			mt.Errorf("redelivery does not skip a delivery being sent: %v", cmd.Query)
+		}
+		set := cmd.Update["$set"].(bson.M)
+		if set["status"] != DeliveryPending || set["attempts"] != int32(0) {
+			mt.Errorf("redelivery sets %v, want pending with attempts 0", set)
+		}
+		if _, ok := set["nextAttemptAt"].(primitive.DateTime); !ok {
+			mt.Errorf("redelivery does not schedule an attempt: %v", set)
+		}
+	})
+
+	mt.Run("refuses a delivery being sent", func(mt *mtest.T) {
+		s := &service{deliveriesCollection: mt.DB.Collection("webhook_deliveries")}
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+			mtest.CreateCursorResponse(0, "test.webhook_deliveries", mtest.FirstBatch, toDoc(mt, Delivery{ID: id})),
+		)
+
+		_, err := s.Redeliver(context.Background(), id.Hex())
+		if err == nil || !strings.Contains(err.Error(), "being sent") {
+			mt.Errorf("Redeliver() error = %v, want the delivery is being sent", err)
+		}
+	})
+
+	mt.Run("unknown delivery", func(mt *mtest.T) {
+		s := &service{deliveriesCollection: mt.DB.Collection("webhook_deliveries")}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+			mtest.CreateCursorResponse(0, "test.webhook_deliveries", mtest.FirstBatch),
+		)
+
+		_, err := s.Redeliver(context.Background(), primitive.NewObjectID().Hex())
+		if err == nil || err.Error() != "webhook delivery not found" {
+			mt.Errorf("Redeliver() error = %v, want webhook delivery not found", err)
+		}
+	})
+}
@@ -0,0 +1,383 @@
+// internal/wishlist/handler.go
+package wishlist
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product" // For sale pricing by customer segment
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/utils"   // For standardized responses
+)
+
+// WishlistHandler handles HTTP requests related to wishlists.
+type WishlistHandler struct {
+	Service   WishlistService
+	Validator *validator.Validate
+}
+
+// NewWishlistHandler creates a new WishlistHandler instance.
+func NewWishlistHandler(s WishlistService) *WishlistHandler {
+	return &WishlistHandler{
+		Service:   s,
+		Validator: validator.New(),
+	}
+}
+
+// respondWithServiceError maps wishlist service errors to HTTP statuses.
+func respondWithServiceError(c *gin.Context, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "wishlist not found" || msg == "product not found":
+		utils.RespondWithError(c, http.StatusNotFound, msg)
+	case strings.HasPrefix(msg, "invalid") || strings.HasSuffix(msg, "is not on this wishlist"):
+		utils.RespondWithError(c, http.StatusBadRequest, msg)
+	case strings.HasPrefix(msg, "wishlist limit reached") || strings.HasPrefix(msg, "wishlist is full"):
+		utils.RespondWithError(c, http.StatusConflict, msg)
+	default:
+		utils.RespondWithError(c, http.StatusInternalServerError, msg)
+	}
+}
+
+// bindJSON binds and validates a request body. On failure it has already written the response.
+func (h *WishlistHandler) bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return false
+	}
+	if err := h.Validator.Struct(req); err != nil {
+		validationErrors := err.(validator.ValidationErrors)
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation failed: "+validationErrors.Error())
+		return false
+	}
+	return true
+}
+
+// requestContext returns a timeout context that prices products for the caller's segment.
+func requestContext(c *gin.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	return product.WithSegment(ctx, c.GetString("userSegment")), cancel
+}
+
+// CreateWishlist godoc
+// @Summary Create a wishlist
+// @Description Create a new, private, named wishlist for the authenticated user
+// @Tags Wishlists
+// @Accept  json
+// @Produce  json
+// @Param   request body WishlistRequest true "Wishlist name"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Wishlist created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or validation error"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "Wishlist limit reached"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists [post]
+func (h *WishlistHandler) CreateWishlist(c *gin.Context) {
+	var req WishlistRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	wishlist, err := h.Service.CreateWishlist(ctx, c.GetString("userID"), &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Wishlist created successfully", "wishlist": wishlist})
+}
+
+// GetUserWishlists godoc
+// @Summary List my wishlists
+// @Description Retrieve the authenticated user's wishlists with live product prices and stock
+// @Tags Wishlists
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "List of wishlists"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists [get]
+func (h *WishlistHandler) GetUserWishlists(c *gin.Context) {
+	ctx, cancel := requestContext(c, 10*time.Second) // Every saved product is loaded
+	defer cancel()
+
+	wishlists, err := h.Service.GetUserWishlists(ctx, c.GetString("userID"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"wishlists": wishlists})
+}
+
+// GetWishlist godoc
+// @Summary Get one of my wishlists
+// @Description Retrieve a wishlist of the authenticated user with live product prices and stock
+// @Tags Wishlists
+// @Produce  json
+// @Param   id path string true "Wishlist ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Wishlist data"
+// @Failure 400 {object} map[string]interface{} "Invalid wishlist ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists/{id} [get]
+func (h *WishlistHandler) GetWishlist(c *gin.Context) {
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	wishlist, err := h.Service.GetWishlist(ctx, c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"wishlist": wishlist})
+}
+
+// RenameWishlist godoc
+// @Summary Rename a wishlist
+// @Description Change the name of one of the authenticated user's wishlists
+// @Tags Wishlists
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Wishlist ID"
+// @Param   request body WishlistRequest true "New name"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Wishlist renamed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request body, validation error, or invalid ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists/{id} [patch]
+func (h *WishlistHandler) RenameWishlist(c *gin.Context) {
+	var req WishlistRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	wishlist, err := h.Service.RenameWishlist(ctx, c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Wishlist renamed successfully", "wishlist": wishlist})
+}
+
+// DeleteWishlist godoc
+// @Summary Delete a wishlist
+// @Description Delete one of the authenticated user's wishlists; its share link stops working
+// @Tags Wishlists
+// @Produce  json
+// @Param   id path string true "Wishlist ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Wishlist deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid wishlist ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists/{id} [delete]
+func (h *WishlistHandler) DeleteWishlist(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Service.DeleteWishlist(ctx, c.GetString("userID"), c.Param("id")); err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Wishlist deleted successfully"})
+}
+
+// AddItem godoc
+// @Summary Save a product to a wishlist
+// @Description Add a product to one of the authenticated user's wishlists; saving it twice has no effect
+// @Tags Wishlists
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Wishlist ID"
+// @Param   request body AddItemRequest true "Product to save"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Product saved to wishlist"
+// @Failure 400 {object} map[string]interface{} "Invalid request body or ID"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Wishlist or product not found"
+// @Failure 409 {object} map[string]interface{} "Wishlist is full"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists/{id}/items [post]
+func (h *WishlistHandler) AddItem(c *gin.Context) {
+	var req AddItemRequest
+	if !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	wishlist, err := h.Service.AddItem(ctx, c.GetString("userID"), c.Param("id"), req.ProductID)
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Product saved to wishlist", "wishlist": wishlist})
+}
+
+// RemoveItem godoc
+// @Summary Remove a product from a wishlist
+// @Description Remove a saved product from one of the authenticated user's wishlists
+// @Tags Wishlists
+// @Produce  json
+// @Param   id path string true "Wishlist ID"
+// @Param   productId path string true "Product ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Product removed from wishlist"
+// @Failure 400 {object} map[string]interface{} "Invalid ID or product not on the wishlist"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists/{id}/items/{productId} [delete]
+func (h *WishlistHandler) RemoveItem(c *gin.Context) {
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	wishlist, err := h.Service.RemoveItem(ctx, c.GetString("userID"), c.Param("id"), c.Param("productId"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Product removed from wishlist", "wishlist": wishlist})
+}
+
+// ShareWishlist godoc
+// @Summary Share a wishlist
+// @Description Create an unguessable public link to one of the authenticated user's wishlists
+// @Tags Wishlists
+// @Produce  json
+// @Param   id path string true "Wishlist ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Wishlist shared; the response includes shareUrl"
+// @Failure 400 {object} map[string]interface{} "Invalid wishlist ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists/{id}/share [post]
+func (h *WishlistHandler) ShareWishlist(c *gin.Context) {
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	wishlist, err := h.Service.Share(ctx, c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Wishlist shared", "wishlist": wishlist})
+}
+
+// UnshareWishlist godoc
+// @Summary Stop sharing a wishlist
+// @Description Make a wishlist private again; its public link stops working
+// @Tags Wishlists
+// @Produce  json
+// @Param   id path string true "Wishlist ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]interface{} "Wishlist is private"
+// @Failure 400 {object} map[string]interface{} "Invalid wishlist ID format"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists/{id}/share [delete]
+func (h *WishlistHandler) UnshareWishlist(c *gin.Context) {
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	wishlist, err := h.Service.Unshare(ctx, c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"message": "Wishlist is private", "wishlist": wishlist})
+}
+
+// GetSharedWishlist godoc
+// @Summary View a shared wishlist
+// @Description Retrieve a wishlist through its public share link, with live product prices and stock
+// @Tags Wishlists
+// @Produce  json
+// @Param   shareId path string true "Share ID from the wishlist's share link"
+// @Success 200 {object} map[string]interface{} "Wishlist data"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /wishlists/shared/{shareId} [get]
+func (h *WishlistHandler) GetSharedWishlist(c *gin.Context) {
+	ctx, cancel := requestContext(c, 5*time.Second)
+	defer cancel()
+
+	wishlist, err := h.Service.GetSharedWishlist(ctx, c.Param("shareId"))
+	if err != nil {
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"wishlist": wishlist})
+}
+
+// MoveToOrder godoc
+// @Summary Order products from a wishlist
+// @Description Place an order for products on a wishlist and remove them from it. Without items, every in-stock product is ordered once.
+// @Tags Wishlists
+// @Accept  json
+// @Produce  json
+// @Param   id path string true "Wishlist ID"
+// @Param   request body MoveToOrderRequest false "Products and quantities to order, checkout currency and shipping region"
+// @Security ApiKeyAuth
+// @Success 201 {object} map[string]interface{} "Order created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request, product not on the wishlist, or insufficient stock"
+// @Failure 401 {object} map[string]interface{} "Unauthorized"
+// @Failure 404 {object} map[string]interface{} "Wishlist not found"
+// @Failure 409 {object} map[string]interface{} "No wishlist products are in stock"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/me/wishlists/{id}/order [post]
+func (h *WishlistHandler) MoveToOrder(c *gin.Context) {
+	var req MoveToOrderRequest
+	if c.Request.ContentLength != 0 && !h.bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := requestContext(c, 10*time.Second) // Longer timeout for the order transaction
+	defer cancel()
+
+	orderResp, err := h.Service.MoveToOrder(ctx, c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		msg := err.Error()
+		// Order errors are classified like POST /orders does.
+		if strings.Contains(msg, "insufficient stock") || strings.Contains(msg, "product not found") || strings.Contains(msg, "not available for download") ||
+			strings.Contains(msg, "invalid product ID format") || msg == "unsupported currency" || msg == "invalid currency code" {
+			utils.RespondWithError(c, http.StatusBadRequest, msg)
+			return
+		}
+		if msg == "no wishlist products are in stock" {
+			utils.RespondWithError(c, http.StatusConflict, msg)
+			return
+		}
+		respondWithServiceError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, gin.H{"message": "Order created successfully", "order": orderResp})
+}
@@ -0,0 +1,72 @@
+// internal/wishlist/model.go
+package wishlist
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+)
+
+// Limits that keep wishlists small enough to hydrate with live product data on every read.
+const (
+	maxWishlistsPerUser = 20
+	maxItemsPerWishlist = 100
+)
+
+// Wishlist is a named list of products a customer has saved for later.
+type Wishlist struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"userID" json:"userId"`
+	Name      string             `bson:"name" json:"name"`
+	Items     []Item             `bson:"items" json:"items"`
+	ShareID   string             `bson:"shareId,omitempty" json:"-"` // Random, unguessable; set while the list is shared publicly
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Item is a product saved on a wishlist.
+type Item struct {
+	ProductID primitive.ObjectID `bson:"productID" json:"productId"`
+	AddedAt   time.Time          `bson:"addedAt" json:"addedAt"`
+}
+
+// WishlistResponse defines the structure for wishlist data in API responses.
+type WishlistResponse struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Items     []ItemResponse `json:"items"`
+	Shared    bool           `json:"shared"`
+	ShareURL  string         `json:"shareUrl,omitempty"` // Only shown to the owner
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// ItemResponse is a saved product with its live price and stock.
+type ItemResponse struct {
+	ProductID string                   `json:"productId"`
+	AddedAt   time.Time                `json:"addedAt"`
+	Product   *product.ProductResponse `json:"product,omitempty"` // Missing once the product is archived or unpublished
+	InStock   bool                     `json:"inStock"`
+}
+
+// WishlistRequest defines the structure for creating or renaming a wishlist.
+type WishlistRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=50"`
+}
+
+// AddItemRequest defines the structure for saving a product to a wishlist.
+type AddItemRequest struct {
+	ProductID string `json:"productId" validate:"required"`
+}
+
+// MoveToOrderRequest defines the structure for ordering products from a wishlist.
+// Without Items, every in-stock product on the list is ordered once.
+type MoveToOrderRequest struct {
+	Items           []order.OrderItemRequest `json:"items,omitempty" validate:"omitempty,dive"`
+	Currency        string                   `json:"currency,omitempty" validate:"omitempty,len=3"`
+	ShippingRegion  string                   `json:"shippingRegion,omitempty" validate:"omitempty,max=50"`
+	ShippingAddress *order.Address           `json:"shippingAddress,omitempty"`
+}
@@ -0,0 +1,430 @@
+// internal/wishlist/service.go
+package wishlist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/database"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/order"
+	"github.com/u-s1ddhar7h/ecommerce-backend/internal/product"
+)
+
+// WishlistService defines the interface for wishlist operations.
+// Reads show live product data, priced for the segment set with product.WithSegment.
+type WishlistService interface {
+	CreateWishlist(ctx context.Context, userID string, req *WishlistRequest) (*WishlistResponse, error)
+	GetUserWishlists(ctx context.Context, userID string) ([]WishlistResponse, error)
+	GetWishlist(ctx context.Context, userID, id string) (*WishlistResponse, error)
+	RenameWishlist(ctx context.Context, userID, id string, req *WishlistRequest) (*WishlistResponse, error)
+	DeleteWishlist(ctx context.Context, userID, id string) error
+	AddItem(ctx context.Context, userID, id, productID string) (*WishlistResponse, error)
+	RemoveItem(ctx context.Context, userID, id, productID string) (*WishlistResponse, error)
+
+	// Public sharing through an unguessable link
+	Share(ctx context.Context, userID, id string) (*WishlistResponse, error)
+	Unshare(ctx context.Context, userID, id string) (*WishlistResponse, error)
+	GetSharedWishlist(ctx context.Context, shareID string) (*WishlistResponse, error)
+
+	// MoveToOrder orders products from the list and removes them from it.
+	MoveToOrder(ctx context.Context, userID, id string, req *MoveToOrderRequest) (*order.OrderResponse, error)
+}
+
+// service implements WishlistService.
+type service struct {
+	wishlistsCollection *mongo.Collection
+	productService      product.ProductService // Live price and stock for saved products
+	orderService        order.OrderService     // Places orders for "move to order"
+}
+
+// NewWishlistService creates a new wishlist service.
+func NewWishlistService(productService product.ProductService, orderService order.OrderService) WishlistService {
+	return &service{
+		wishlistsCollection: database.GetCollection("wishlists"),
+		productService:      productService,
+		orderService:        orderService,
+	}
+}
+
+// newShareID returns a random, URL-safe identifier for a public wishlist link.
+func newShareID() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// parseIDs converts the owner and wishlist IDs from their hex form.
+func parseIDs(userID, id string) (primitive.ObjectID, primitive.ObjectID, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid user ID format")
+	}
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, errors.New("invalid wishlist ID format")
+	}
+	return userObjID, objID, nil
+}
+
+// toResponse converts a wishlist for API responses, loading each saved product's current
+// price and stock. owner controls whether the share link is included.
+func (s *service) toResponse(ctx context.Context, w *Wishlist, owner bool) *WishlistResponse {
+	resp := &WishlistResponse{
+		ID:        w.ID.Hex(),
+		Name:      w.Name,
+		Items:     make([]ItemResponse, 0, len(w.Items)),
+		Shared:    w.ShareID != "",
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+	if owner && w.ShareID != "" {
+		resp.ShareURL = "/api/wishlists/shared/" + w.ShareID
+	}
+
+	for _, item := range w.Items {
+		itemResp := ItemResponse{ProductID: item.ProductID.Hex(), AddedAt: item.AddedAt}
+		p, err := s.productService.GetProductByID(ctx, item.ProductID.Hex())
+		if err != nil && err.Error() != "product not found" {
+			log.Printf("Error loading wishlist product %s: %v", item.ProductID.Hex(), err)
+		}
+		// Archived and draft products stay on the list but show no details.
+		if err == nil && p.Status != product.StatusDraft {
+			itemResp.Product = p
+			itemResp.InStock = p.Available > 0 || p.Digital
+		}
+		resp.Items = append(resp.Items, itemResp)
+	}
+	return resp
+}
+
+// findOwned loads a wishlist belonging to the user.
+func (s *service) findOwned(ctx context.Context, userObjID, objID primitive.ObjectID) (*Wishlist, error) {
+	var w Wishlist
+	err := s.wishlistsCollection.FindOne(ctx, bson.M{"_id": objID, "userID": userObjID}).Decode(&w)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("wishlist not found")
+		}
+		log.Printf("Error finding wishlist: %v", err)
+		return nil, errors.New("database error retrieving wishlist")
+	}
+	return &w, nil
+}
+
+// updateOwned applies update to a wishlist belonging to the user and returns the result.
+func (s *service) updateOwned(ctx context.Context, userObjID, objID primitive.ObjectID, filter, update bson.M) (*Wishlist, error) {
+	filter["_id"], filter["userID"] = objID, userObjID
+	var w Wishlist
+	err := s.wishlistsCollection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&w)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("wishlist not found")
+		}
+		log.Printf("Error updating wishlist: %v", err)
+		return nil, errors.New("failed to update wishlist")
+	}
+	return &w, nil
+}
+
+// CreateWishlist creates an empty, private wishlist.
+func (s *service) CreateWishlist(ctx context.Context, userID string, req *WishlistRequest) (*WishlistResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+
+	count, err := s.wishlistsCollection.CountDocuments(ctx, bson.M{"userID": userObjID})
+	if err != nil {
+		log.Printf("Error counting wishlists: %v", err)
+		return nil, errors.New("database error retrieving wishlists")
+	}
+	if count >= maxWishlistsPerUser {
+		return nil, fmt.Errorf("wishlist limit reached: at most %d lists", maxWishlistsPerUser)
+	}
+
+	now := time.Now()
+	w := &Wishlist{UserID: userObjID, Name: req.Name, Items: []Item{}, CreatedAt: now, UpdatedAt: now}
+	result, err := s.wishlistsCollection.InsertOne(ctx, w)
+	if err != nil {
+		log.Printf("Error inserting wishlist: %v", err)
+		return nil, errors.New("failed to create wishlist")
+	}
+	w.ID = result.InsertedID.(primitive.ObjectID)
+	return s.toResponse(ctx, w, true), nil
+}
+
+// GetUserWishlists lists the user's wishlists, oldest first.
+func (s *service) GetUserWishlists(ctx context.Context, userID string) ([]WishlistResponse, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID format")
+	}
+
+	cursor, err := s.wishlistsCollection.Find(ctx, bson.M{"userID": userObjID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		log.Printf("Error finding wishlists: %v", err)
+		return nil, errors.New("failed to retrieve wishlists")
+	}
+	defer cursor.Close(ctx)
+
+	var wishlists []Wishlist
+	if err = cursor.All(ctx, &wishlists); err != nil {
+		log.Printf("Error decoding wishlists: %v", err)
+		return nil, errors.New("failed to process wishlist data")
+	}
+
+	responses := make([]WishlistResponse, 0, len(wishlists))
+	for i := range wishlists {
+		responses = append(responses, *s.toResponse(ctx, &wishlists[i], true))
+	}
+	return responses, nil
+}
+
+// GetWishlist retrieves one of the user's wishlists.
+func (s *service) GetWishlist(ctx context.Context, userID, id string) (*WishlistResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.findOwned(ctx, userObjID, objID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, w, true), nil
+}
+
+// RenameWishlist changes a wishlist's name.
+func (s *service) RenameWishlist(ctx context.Context, userID, id string, req *WishlistRequest) (*WishlistResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.updateOwned(ctx, userObjID, objID, bson.M{},
+		bson.M{"$set": bson.M{"name": req.Name, "updatedAt": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, w, true), nil
+}
+
+// DeleteWishlist deletes a wishlist, which also invalidates its share link.
+func (s *service) DeleteWishlist(ctx context.Context, userID, id string) error {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return err
+	}
+	res, err := s.wishlistsCollection.DeleteOne(ctx, bson.M{"_id": objID, "userID": userObjID})
+	if err != nil {
+		log.Printf("Error deleting wishlist: %v", err)
+		return errors.New("failed to delete wishlist")
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("wishlist not found")
+	}
+	return nil
+}
+
+// AddItem saves a product to a wishlist. Saving a product that is already on the list is a no-op.
+func (s *service) AddItem(ctx context.Context, userID, id, productID string) (*WishlistResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	productObjID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+	p, err := s.productService.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == product.StatusDraft {
+		return nil, errors.New("product not found")
+	}
+
+	w, err := s.findOwned(ctx, userObjID, objID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range w.Items {
+		if item.ProductID == productObjID {
+			return s.toResponse(ctx, w, true), nil
+		}
+	}
+	if len(w.Items) >= maxItemsPerWishlist {
+		return nil, fmt.Errorf("wishlist is full: at most %d products", maxItemsPerWishlist)
+	}
+
+	// The filter keeps a concurrent add of the same product from saving it twice.
+	now := time.Now()
+	w, err = s.updateOwned(ctx, userObjID, objID,
+		bson.M{"items.productID": bson.M{"$ne": productObjID}},
+		bson.M{
+			"$push": bson.M{"items": Item{ProductID: productObjID, AddedAt: now}},
+			"$set":  bson.M{"updatedAt": now},
+		})
+	if err != nil {
+		if err.Error() == "wishlist not found" {
+			return s.GetWishlist(ctx, userID, id) // Added concurrently
+		}
+		return nil, err
+	}
+	return s.toResponse(ctx, w, true), nil
+}
+
+// RemoveItem removes a product from a wishlist.
+func (s *service) RemoveItem(ctx context.Context, userID, id, productID string) (*WishlistResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	productObjID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, errors.New("invalid product ID format")
+	}
+
+	w, err := s.updateOwned(ctx, userObjID, objID,
+		bson.M{"items.productID": productObjID},
+		bson.M{
+			"$pull": bson.M{"items": bson.M{"productID": productObjID}},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		})
+	if err != nil {
+		if err.Error() == "wishlist not found" {
+			if _, findErr := s.findOwned(ctx, userObjID, objID); findErr != nil {
+				return nil, findErr
+			}
+			return nil, errors.New("product is not on this wishlist")
+		}
+		return nil, err
+	}
+	return s.toResponse(ctx, w, true), nil
+}
+
+// Share makes a wishlist viewable by anyone with its link. Sharing an already shared list keeps its link.
+func (s *service) Share(ctx context.Context, userID, id string) (*WishlistResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.findOwned(ctx, userObjID, objID)
+	if err != nil {
+		return nil, err
+	}
+	if w.ShareID != "" {
+		return s.toResponse(ctx, w, true), nil
+	}
+
+	shareID, err := newShareID()
+	if err != nil {
+		log.Printf("Error generating wishlist share ID: %v", err)
+		return nil, errors.New("failed to share wishlist")
+	}
+	w, err = s.updateOwned(ctx, userObjID, objID, bson.M{},
+		bson.M{"$set": bson.M{"shareId": shareID, "updatedAt": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, w, true), nil
+}
+
+// Unshare makes a wishlist private again; its old link stops working for good.
+func (s *service) Unshare(ctx context.Context, userID, id string) (*WishlistResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.updateOwned(ctx, userObjID, objID, bson.M{},
+		bson.M{"$unset": bson.M{"shareId": ""}, "$set": bson.M{"updatedAt": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, w, true), nil
+}
+
+// GetSharedWishlist retrieves a publicly shared wishlist by its share ID.
+func (s *service) GetSharedWishlist(ctx context.Context, shareID string) (*WishlistResponse, error) {
+	var w Wishlist
+	err := s.wishlistsCollection.FindOne(ctx, bson.M{"shareId": shareID}).Decode(&w)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("wishlist not found")
+		}
+		log.Printf("Error finding shared wishlist: %v", err)
+		return nil, errors.New("database error retrieving wishlist")
+	}
+	return s.toResponse(ctx, &w, false), nil
+}
+
+// MoveToOrder places an order for products on a wishlist and, once it is placed, removes
+// them from the list.
+func (s *service) MoveToOrder(ctx context.Context, userID, id string, req *MoveToOrderRequest) (*order.OrderResponse, error) {
+	userObjID, objID, err := parseIDs(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.findOwned(ctx, userObjID, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	onList := make(map[string]bool, len(w.Items))
+	for _, item := range w.Items {
+		onList[item.ProductID.Hex()] = true
+	}
+	items := req.Items
+	if len(items) == 0 {
+		for _, item := range s.toResponse(ctx, w, true).Items {
+			if item.InStock {
+				items = append(items, order.OrderItemRequest{ProductID: item.ProductID, Quantity: 1})
+			}
+		}
+		if len(items) == 0 {
+			return nil, errors.New("no wishlist products are in stock")
+		}
+	}
+	for _, item := range items {
+		if !onList[item.ProductID] {
+			return nil, fmt.Errorf("product %s is not on this wishlist", item.ProductID)
+		}
+	}
+
+	orderResp, err := s.orderService.CreateOrder(ctx, userID, &order.CreateOrderRequest{
+		Items:           items,
+		Currency:        req.Currency,
+		ShippingRegion:  req.ShippingRegion,
+		ShippingAddress: req.ShippingAddress,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]primitive.ObjectID, 0, len(items))
+	for _, item := range items {
+		productObjID, _ := primitive.ObjectIDFromHex(item.ProductID) // Validated by the order
+		ordered = append(ordered, productObjID)
+	}
+	_, err = s.wishlistsCollection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+		"$pull": bson.M{"items": bson.M{"productID": bson.M{"$in": ordered}}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		// The order stands; the products simply stay on the list.
+		log.Printf("Error removing ordered products from wishlist %s: %v", objID.Hex(), err)
+	}
+	return orderResp, nil
+}